@@ -0,0 +1,170 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"kos-muhandis/backend/database"
+	"kos-muhandis/backend/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+var tipeAkun = map[string]bool{
+	"aset": true, "kewajiban": true, "ekuitas": true, "pendapatan": true, "beban": true,
+}
+
+// GetAkun - Bagan akun, urut kode. Query: tipe
+func GetAkun(c *gin.Context) {
+	query := database.DB.Order("kode ASC")
+	if tipe := strings.ToLower(c.Query("tipe")); tipe != "" {
+		query = query.Where("tipe = ?", tipe)
+	}
+	var akun []models.Akun
+	if err := query.Find(&akun).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch akun"})
+		return
+	}
+	c.JSON(http.StatusOK, akun)
+}
+
+// CreateAkun - Tambah akun baru (untuk jurnal manual)
+func CreateAkun(c *gin.Context) {
+	var input struct {
+		Kode string `json:"kode" binding:"required"`
+		Nama string `json:"nama" binding:"required"`
+		Tipe string `json:"tipe" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	akun := models.Akun{
+		Kode: strings.TrimSpace(input.Kode),
+		Nama: strings.TrimSpace(input.Nama),
+		Tipe: strings.ToLower(input.Tipe),
+	}
+	if msg := validateAkun(akun); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+	if err := database.DB.Create(&akun).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create akun"})
+		return
+	}
+	c.JSON(http.StatusCreated, akun)
+}
+
+// UpdateAkun - Ubah akun. Kode & tipe akun sistem, serta tipe akun milik rekening, tidak bisa diubah.
+func UpdateAkun(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	var akun models.Akun
+	if err := database.DB.First(&akun, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Akun not found"})
+		return
+	}
+	var input struct {
+		Kode string `json:"kode"`
+		Nama string `json:"nama"`
+		Tipe string `json:"tipe"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if akun.Sistem && ((input.Kode != "" && input.Kode != akun.Kode) || (input.Tipe != "" && !strings.EqualFold(input.Tipe, akun.Tipe))) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Kode dan tipe akun sistem tidak bisa diubah"})
+		return
+	}
+	if input.Tipe != "" && !strings.EqualFold(input.Tipe, akun.Tipe) {
+		rekening, err := rekeningAkun(akun.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update akun"})
+			return
+		}
+		if rekening > 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Tipe akun rekening tidak bisa diubah"})
+			return
+		}
+	}
+	if input.Kode != "" {
+		akun.Kode = strings.TrimSpace(input.Kode)
+	}
+	if input.Nama != "" {
+		akun.Nama = strings.TrimSpace(input.Nama)
+	}
+	if input.Tipe != "" {
+		akun.Tipe = strings.ToLower(input.Tipe)
+	}
+	if msg := validateAkun(akun); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+	if err := database.DB.Save(&akun).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update akun"})
+		return
+	}
+	c.JSON(http.StatusOK, akun)
+}
+
+// DeleteAkun - Hapus akun yang bukan akun sistem dan belum dipakai jurnal maupun rekening
+func DeleteAkun(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	var akun models.Akun
+	if err := database.DB.First(&akun, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Akun not found"})
+		return
+	}
+	if akun.Sistem {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Akun sistem tidak bisa dihapus"})
+		return
+	}
+	var dipakai int64
+	if err := database.DB.Model(&models.JurnalDetail{}).
+		Joins("JOIN jurnals ON jurnals.id = jurnal_details.jurnal_id AND jurnals.deleted_at IS NULL").
+		Where("jurnal_details.akun_id = ?", akun.ID).Count(&dipakai).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete akun"})
+		return
+	}
+	if dipakai > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Akun masih dipakai jurnal"})
+		return
+	}
+	rekening, err := rekeningAkun(akun.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete akun"})
+		return
+	}
+	if rekening > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Akun masih dipakai rekening"})
+		return
+	}
+	if err := database.DB.Delete(&akun).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete akun"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Akun deleted"})
+}
+
+// rekeningAkun - Jumlah rekening yang memakai akun; transaksi dan transfernya dijurnal ke akun ini
+func rekeningAkun(akunID uint) (int64, error) {
+	var count int64
+	err := database.DB.Model(&models.Rekening{}).Where("akun_id = ?", akunID).Count(&count).Error
+	return count, err
+}
+
+func validateAkun(akun models.Akun) string {
+	if akun.Kode == "" || akun.Nama == "" {
+		return "Kode dan nama akun wajib diisi"
+	}
+	if !tipeAkun[akun.Tipe] {
+		return "Tipe must be aset, kewajiban, ekuitas, pendapatan or beban"
+	}
+	var count int64
+	database.DB.Model(&models.Akun{}).Where("kode = ? AND id <> ?", akun.Kode, akun.ID).Count(&count)
+	if count > 0 {
+		return "Kode akun sudah dipakai"
+	}
+	return ""
+}
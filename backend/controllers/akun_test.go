@@ -0,0 +1,64 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+
+	"kos-muhandis/backend/database"
+	"kos-muhandis/backend/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestAkunRekeningTidakBisaDihapus(t *testing.T) {
+	setupTestDB(t)
+	w := panggilHandler(CreateRekening, http.MethodPost, "/rekening", gin.H{"nama": "Bank Uji", "jenis": "bank"})
+	cekStatus(t, w, http.StatusCreated)
+	var rekening models.Rekening
+	decodeJSON(t, w, &rekening)
+	t.Cleanup(func() {
+		database.DB.Unscoped().Delete(&models.Rekening{}, rekening.ID)
+		database.DB.Unscoped().Delete(&models.Akun{}, rekening.AkunID)
+	})
+	akunParam := gin.Param{Key: "id", Value: strconv.Itoa(int(rekening.AkunID))}
+
+	seedTransaksi(t, "pemasukan", 250000, "2025-01-10")
+	transaksi := seedTransaksi(t, "pemasukan", 100000, "2025-01-11")
+	database.DB.Model(&transaksi).Update("rekening_id", rekening.ID)
+
+	tests := []struct {
+		name    string
+		handler gin.HandlerFunc
+		method  string
+		body    gin.H
+		status  int
+	}{
+		{"hapus", DeleteAkun, http.MethodDelete, nil, http.StatusBadRequest},
+		{"ubah tipe", UpdateAkun, http.MethodPut, gin.H{"tipe": "beban"}, http.StatusBadRequest},
+		{"ubah nama dan kode", UpdateAkun, http.MethodPut, gin.H{"nama": "Bank Uji Baru", "kode": "1199"}, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := panggilHandler(tt.handler, tt.method, "/akun/"+akunParam.Value, tt.body, akunParam)
+			cekStatus(t, w, tt.status)
+		})
+	}
+
+	// Kaki jurnal rekening tetap ada setelah kode akunnya diganti, neraca saldo tetap seimbang
+	w = panggilHandler(GetNeracaSaldo, http.MethodGet, "/report/neraca-saldo?tanggal=2025-12-31", nil)
+	cekStatus(t, w, http.StatusOK)
+	var neraca struct {
+		Akun     []SaldoAkun
+		Seimbang bool
+	}
+	decodeJSON(t, w, &neraca)
+	if !neraca.Seimbang {
+		t.Error("neraca saldo tidak seimbang")
+	}
+	for _, s := range neraca.Akun {
+		if s.AkunID == rekening.AkunID && (s.Kode != "1199" || s.Saldo != 100000) {
+			t.Errorf("akun rekening = %+v, want kode 1199 saldo 100000", s)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"kos-muhandis/backend/database"
+	"kos-muhandis/backend/models"
+)
+
+var zonaWIB = time.FixedZone("WIB", 7*3600)
+
+var (
+	errBerhentiLangganan = errors.New("Penyewa berhenti berlangganan pesan (STOP)")
+	errJamTenang         = errors.New("Jam tenang, pengingat ditunda")
+	errBatasHarian       = errors.New("Batas pesan harian penyewa sudah tercapai")
+)
+
+// jamTenang - Rentang jam tenang WIB dari NOTIF_JAM_TENANG ("21:00-08:00", default),
+// dalam menit sejak tengah malam. "off" mematikan jam tenang.
+func jamTenang() (int, int, bool) {
+	nilai := strings.TrimSpace(os.Getenv("NOTIF_JAM_TENANG"))
+	if nilai == "" {
+		nilai = "21:00-08:00"
+	}
+	if strings.EqualFold(nilai, "off") {
+		return 0, 0, false
+	}
+	bagian := strings.SplitN(nilai, "-", 2)
+	if len(bagian) != 2 {
+		return 0, 0, false
+	}
+	mulai, err1 := menitJam(bagian[0])
+	selesai, err2 := menitJam(bagian[1])
+	if err1 != nil || err2 != nil || mulai == selesai {
+		return 0, 0, false
+	}
+	return mulai, selesai, true
+}
+
+func menitJam(jam string) (int, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(jam))
+	if err != nil {
+		return 0, err
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// dalamJamTenang - Apakah waktu t (dibaca dalam WIB) jatuh di jam tenang. Rentang boleh
+// melewati tengah malam.
+func dalamJamTenang(t time.Time) bool {
+	mulai, selesai, aktif := jamTenang()
+	if !aktif {
+		return false
+	}
+	t = t.In(zonaWIB)
+	menit := t.Hour()*60 + t.Minute()
+	if mulai < selesai {
+		return menit >= mulai && menit < selesai
+	}
+	return menit >= mulai || menit < selesai
+}
+
+// selesaiJamTenang - Waktu WIB berikutnya saat jam tenang berakhir
+func selesaiJamTenang(t time.Time) time.Time {
+	_, selesai, _ := jamTenang()
+	t = t.In(zonaWIB)
+	akhir := time.Date(t.Year(), t.Month(), t.Day(), selesai/60, selesai%60, 0, 0, zonaWIB)
+	if !akhir.After(t) {
+		akhir = akhir.AddDate(0, 0, 1)
+	}
+	return akhir
+}
+
+// batasHarian - Maksimal pengingat per penyewa per hari dari NOTIF_BATAS_HARIAN (default 2, 0 = tanpa batas)
+func batasHarian() int {
+	batas, err := strconv.Atoi(os.Getenv("NOTIF_BATAS_HARIAN"))
+	if err != nil || batas < 0 {
+		return 2
+	}
+	return batas
+}
+
+// kirimanHariIni - Jumlah pengingat yang sudah terkirim ke penyewa sendiri hari ini (WIB). Satu kiriman
+// bisa mencakup beberapa notifikasi dan beberapa kanal/tujuan.
+func kirimanHariIni(penyewaID uint) int64 {
+	now := time.Now().In(zonaWIB)
+	awal := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, zonaWIB)
+	var jumlah int64
+	database.DB.Model(&models.PengirimanNotifikasi{}).
+		Joins("JOIN notifikasis ON notifikasis.id = pengiriman_notifikasis.notifikasi_id").
+		Where("notifikasis.penyewa_id = ? AND notifikasis.penerima = ?", penyewaID, "penyewa").
+		Where("pengiriman_notifikasis.kiriman <> '' AND pengiriman_notifikasis.dikirim_pada >= ?", awal).
+		Distinct("pengiriman_notifikasis.kiriman").
+		Count(&jumlah)
+	return jumlah
+}
+
+// izinPesan - Semua pesan ke penyewa (pengingat, kwitansi, balasan pengelola) tidak dikirim
+// setelah penyewa membalas STOP
+func izinPesan(penyewa models.Penyewa) error {
+	if penyewa.BerhentiPada != nil {
+		return errBerhentiLangganan
+	}
+	return nil
+}
+
+// izinPengingat - Pengingat tagihan juga ditunda saat jam tenang dan dibatasi per hari.
+// paksa melewati jam tenang dan batas harian (kirim manual pengelola), tetapi tidak STOP.
+func izinPengingat(penyewa models.Penyewa, paksa bool) error {
+	if err := izinPesan(penyewa); err != nil {
+		return err
+	}
+	if paksa {
+		return nil
+	}
+	if now := time.Now(); dalamJamTenang(now) {
+		return fmt.Errorf("%w sampai %s WIB", errJamTenang, selesaiJamTenang(now).Format("02/01 15:04"))
+	}
+	if batas := batasHarian(); batas > 0 && kirimanHariIni(penyewa.ID) >= int64(batas) {
+		return errBatasHarian
+	}
+	return nil
+}
+
+// izinNotifikasi - Aturan kiriman sesuai penerima eskalasi: penyewa mengikuti izinPengingat,
+// kontak darurat hanya jam tenang, pemilik selalu boleh
+func izinNotifikasi(penerima string, penyewa models.Penyewa, paksa bool) error {
+	switch {
+	case penerima == "penyewa":
+		return izinPengingat(penyewa, paksa)
+	case penerima == "kontak_darurat" && !paksa && dalamJamTenang(time.Now()):
+		return fmt.Errorf("%w sampai %s WIB", errJamTenang, selesaiJamTenang(time.Now()).Format("02/01 15:04"))
+	}
+	return nil
+}
+
+// idKiriman - Id satu kiriman pengingat, dipakai bersama oleh semua baris pengirimannya
+func idKiriman(penyewaID uint) string {
+	return fmt.Sprintf("%d-%d", penyewaID, time.Now().UnixNano())
+}
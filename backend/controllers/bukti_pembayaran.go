@@ -0,0 +1,334 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"kos-muhandis/backend/database"
+	"kos-muhandis/backend/models"
+	"kos-muhandis/backend/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var errBuktiDiproses = errors.New("Bukti pembayaran sudah diverifikasi")
+
+// GetLinkBuktiPembayaran - Link bertanda tangan untuk penyewa mengunggah bukti transfer tagihan
+func GetLinkBuktiPembayaran(c *gin.Context) {
+	id := c.Param("id")
+	var tagihan models.Tagihan
+	if err := database.DB.First(&tagihan, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Tagihan not found"})
+		return
+	}
+	url := linkBuktiPembayaran(tagihan.ID)
+	if url == "" {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "PUBLIC_BASE_URL and JWT_SECRET must be configured"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"tagihan_id": tagihan.ID, "url": url})
+}
+
+// linkBuktiPembayaran - URL publik unggah bukti transfer, kosong bila PUBLIC_BASE_URL atau JWT_SECRET belum diatur
+func linkBuktiPembayaran(tagihanID uint) string {
+	baseURL := os.Getenv("PUBLIC_BASE_URL")
+	if baseURL == "" {
+		return ""
+	}
+	id := strconv.Itoa(int(tagihanID))
+	token, err := signDokumen("bukti", id)
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("%s/bukti/%s?token=%s", baseURL, id, token)
+}
+
+// UploadBuktiPembayaranPublic - Penyewa mengunggah bukti transfer lewat link bertanda tangan
+// (multipart: file gambar, jumlah, tanggal_transfer, keterangan)
+func UploadBuktiPembayaranPublic(c *gin.Context) {
+	id := c.Param("id")
+	if !cekTokenDokumen("bukti", id, c.Query("token")) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Invalid or expired token"})
+		return
+	}
+	simpanBuktiPembayaran(c, id)
+}
+
+// CreateBuktiPembayaran - Pengelola mengunggah bukti transfer atas nama penyewa (mis. dari chat WhatsApp)
+func CreateBuktiPembayaran(c *gin.Context) {
+	simpanBuktiPembayaran(c, c.Param("id"))
+}
+
+// simpanBuktiPembayaran - Simpan bukti transfer sebagai Menunggu verifikasi
+func simpanBuktiPembayaran(c *gin.Context, tagihanID string) {
+	var tagihan models.Tagihan
+	if err := database.DB.First(&tagihan, tagihanID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Tagihan not found"})
+		return
+	}
+	if tagihan.Jumlah-tagihan.Terbayar <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Tagihan sudah lunas"})
+		return
+	}
+	jumlah, err := strconv.Atoi(strings.TrimSpace(c.PostForm("jumlah")))
+	if err != nil || jumlah <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Jumlah must be greater than zero"})
+		return
+	}
+	tanggal, err := paymentDate(c.PostForm("tanggal_transfer"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format"})
+		return
+	}
+
+	upload, ok := readUpload(c, maxFotoSize, imageTypes)
+	if !ok {
+		return
+	}
+	key, err := storeUpload("bukti", tagihan.ID, upload)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store file"})
+		return
+	}
+
+	bukti := models.BuktiPembayaran{
+		TagihanID:       tagihan.ID,
+		PenyewaID:       tagihan.PenyewaID,
+		Jumlah:          jumlah,
+		TanggalTransfer: tanggal,
+		Keterangan:      strings.TrimSpace(c.PostForm("keterangan")),
+		NamaFile:        upload.NamaFile,
+		ContentType:     upload.ContentType,
+		Ukuran:          len(upload.Data),
+		StorageKey:      key,
+		Status:          "Menunggu",
+	}
+	if err := database.DB.Create(&bukti).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save bukti pembayaran"})
+		return
+	}
+	c.JSON(http.StatusCreated, bukti)
+}
+
+// GetBuktiPembayaran - Antrean verifikasi bukti transfer, terlama di depan
+// (filter: status, tagihan_id, penyewa_id)
+func GetBuktiPembayaran(c *gin.Context) {
+	query := database.DB.Preload("Penyewa").Preload("Tagihan")
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if tagihanID := c.Query("tagihan_id"); tagihanID != "" {
+		query = query.Where("tagihan_id = ?", tagihanID)
+	}
+	if penyewaID := c.Query("penyewa_id"); penyewaID != "" {
+		query = query.Where("penyewa_id = ?", penyewaID)
+	}
+	var bukti []models.BuktiPembayaran
+	if err := query.Order("created_at ASC, id ASC").Find(&bukti).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch bukti pembayaran"})
+		return
+	}
+	c.JSON(http.StatusOK, bukti)
+}
+
+// GetFileBuktiPembayaran - Tampilkan gambar bukti transfer
+func GetFileBuktiPembayaran(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	var bukti models.BuktiPembayaran
+	if err := database.DB.First(&bukti, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Bukti pembayaran not found"})
+		return
+	}
+	serveUpload(c, bukti.StorageKey, bukti.NamaFile, bukti.ContentType)
+}
+
+// SetujuiBuktiPembayaran - Setujui bukti transfer: catat pembayaran tagihan lalu kabari penyewa.
+// Body opsional: jumlah (default nominal di bukti), tanggal (default tanggal transfer),
+// rekening_id (default rekening bank, lalu kas), keterangan.
+func SetujuiBuktiPembayaran(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	var input struct {
+		Jumlah     int    `json:"jumlah"`
+		Tanggal    string `json:"tanggal"`
+		RekeningID *uint  `json:"rekening_id"`
+		Keterangan string `json:"keterangan"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if input.Jumlah < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Jumlah must be greater than zero"})
+		return
+	}
+	var tanggal *time.Time
+	if input.Tanggal != "" {
+		t, err := paymentDate(input.Tanggal)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format"})
+			return
+		}
+		tanggal = &t
+	}
+	rekeningID := input.RekeningID
+	if rekeningID == nil || *rekeningID == 0 {
+		rekeningID = rekeningJenis(database.DB, "bank")
+	}
+	rekeningID, msg := pilihRekening(rekeningID)
+	if msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+	verifikator := namaPengguna(c)
+
+	var bukti models.BuktiPembayaran
+	var tagihan models.Tagihan
+	var pembayaran models.Pembayaran
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&bukti, id).Error; err != nil {
+			return err
+		}
+		if bukti.Status != "Menunggu" {
+			return errBuktiDiproses
+		}
+		jumlah := bukti.Jumlah
+		if input.Jumlah > 0 {
+			jumlah = input.Jumlah
+		}
+		tanggalBayar := bukti.TanggalTransfer
+		if tanggal != nil {
+			tanggalBayar = *tanggal
+		}
+		keterangan := input.Keterangan
+		if keterangan == "" {
+			keterangan = fmt.Sprintf("Bukti transfer #%d", bukti.ID)
+		}
+
+		var err error
+		tagihan, pembayaran, err = bayarTagihan(tx, bukti.TagihanID, models.Pembayaran{
+			Jumlah:       jumlah,
+			Tanggal:      tanggalBayar,
+			DiterimaOleh: verifikator,
+			Keterangan:   keterangan,
+			RekeningID:   rekeningID,
+		}, nil)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		bukti.Status = "Disetujui"
+		bukti.AlasanTolak = ""
+		bukti.DiverifikasiOleh = verifikator
+		bukti.DiverifikasiPada = &now
+		bukti.PembayaranID = &pembayaran.ID
+		return tx.Save(&bukti).Error
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Bukti pembayaran not found"})
+		return
+	}
+	if errors.Is(err, errBuktiDiproses) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, errOverpayment) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to approve bukti pembayaran"})
+		return
+	}
+
+	var penyewa models.Penyewa
+	database.DB.Preload("Kamar").First(&penyewa, bukti.PenyewaID)
+	c.JSON(http.StatusOK, gin.H{"bukti": bukti, "tagihan": tagihan, "whatsapp": kabariPenyewa(penyewa, pesanTemplate("KWITANSI", penyewa, &tagihan))})
+}
+
+// TolakBuktiPembayaran - Tolak bukti transfer dengan alasan, lalu kabari penyewa
+func TolakBuktiPembayaran(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	var input struct {
+		Alasan string `json:"alasan" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil || strings.TrimSpace(input.Alasan) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Alasan is required"})
+		return
+	}
+	verifikator := namaPengguna(c)
+
+	var bukti models.BuktiPembayaran
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&bukti, id).Error; err != nil {
+			return err
+		}
+		if bukti.Status != "Menunggu" {
+			return errBuktiDiproses
+		}
+		now := time.Now()
+		bukti.Status = "Ditolak"
+		bukti.AlasanTolak = strings.TrimSpace(input.Alasan)
+		bukti.DiverifikasiOleh = verifikator
+		bukti.DiverifikasiPada = &now
+		return tx.Save(&bukti).Error
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Bukti pembayaran not found"})
+		return
+	}
+	if errors.Is(err, errBuktiDiproses) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reject bukti pembayaran"})
+		return
+	}
+
+	var tagihan models.Tagihan
+	database.DB.Preload("Penyewa").First(&tagihan, bukti.TagihanID)
+	data := dataTemplate(tagihan.Penyewa, &tagihan)
+	data["jumlah_bukti"] = services.FormatRupiah(bukti.Jumlah)
+	data["alasan"] = bukti.AlasanTolak
+	pesan := renderPesan("BUKTI_DITOLAK", tagihan.Penyewa, data)
+	c.JSON(http.StatusOK, gin.H{"bukti": bukti, "whatsapp": kabariPenyewa(tagihan.Penyewa, pesan)})
+}
+
+// kabariPenyewa - Kirim hasil verifikasi ke WhatsApp penyewa. Gagal kirim tidak membatalkan verifikasi.
+func kabariPenyewa(penyewa models.Penyewa, pesan string) gin.H {
+	if penyewa.NoHP == nil || *penyewa.NoHP == "" {
+		return gin.H{"sent": false, "error": "Penyewa doesn't have phone number"}
+	}
+	if err := izinPesan(penyewa); err != nil {
+		return gin.H{"sent": false, "error": err.Error()}
+	}
+	phoneNumber := formatPhoneNumber(*penyewa.NoHP)
+	result := SendViaWhatsApp(phoneNumber, pesan)
+	if !result.Success {
+		return gin.H{"sent": false, "phone": phoneNumber, "error": result.Error}
+	}
+	return gin.H{"sent": true, "phone": phoneNumber}
+}
+
+// namaPengguna - Nama user yang sedang login (dari klaim JWT), untuk jejak verifikasi
+func namaPengguna(c *gin.Context) string {
+	userID, _ := c.Get("user_id")
+	id, ok := userID.(float64)
+	if !ok {
+		return ""
+	}
+	var user models.User
+	if err := database.DB.First(&user, uint(id)).Error; err != nil {
+		return ""
+	}
+	return user.Name
+}
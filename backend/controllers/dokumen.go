@@ -0,0 +1,226 @@
+package controllers
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"kos-muhandis/backend/database"
+	"kos-muhandis/backend/models"
+	"kos-muhandis/backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetInvoicePDF - Download invoice tagihan dalam format PDF
+func GetInvoicePDF(c *gin.Context) {
+	serveDokumenPDF(c, "invoice", c.Param("id"))
+}
+
+// GetKwitansiPDF - Download kwitansi (bukti pembayaran) dalam format PDF
+func GetKwitansiPDF(c *gin.Context) {
+	serveDokumenPDF(c, "kwitansi", c.Param("id"))
+}
+
+// DownloadDokumenPublic - Download invoice/kwitansi lewat link bertanda tangan (untuk lampiran WhatsApp)
+func DownloadDokumenPublic(c *gin.Context) {
+	jenis := c.Param("jenis")
+	id := c.Param("id")
+	if !cekTokenDokumen(jenis, id, c.Query("token")) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Invalid or expired token"})
+		return
+	}
+	serveDokumenPDF(c, jenis, id)
+}
+
+// SendDokumenWhatsApp - Kirim invoice/kwitansi sebagai lampiran WhatsApp
+func SendDokumenWhatsApp(c *gin.Context) {
+	var input struct {
+		TagihanID uint   `json:"tagihan_id" binding:"required"`
+		Jenis     string `json:"jenis" binding:"required,oneof=invoice kwitansi"`
+		Message   string `json:"message"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		return
+	}
+
+	var tagihan models.Tagihan
+	if err := database.DB.Preload("Penyewa").First(&tagihan, input.TagihanID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Tagihan not found"})
+		return
+	}
+	if input.Jenis == "kwitansi" && tagihan.Terbayar <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Tagihan belum memiliki pembayaran"})
+		return
+	}
+	if tagihan.Penyewa.NoHP == nil || *tagihan.Penyewa.NoHP == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Penyewa doesn't have phone number"})
+		return
+	}
+	if err := izinPesan(tagihan.Penyewa); err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+
+	baseURL := os.Getenv("PUBLIC_BASE_URL")
+	if baseURL == "" {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "PUBLIC_BASE_URL is not configured"})
+		return
+	}
+	id := strconv.Itoa(int(tagihan.ID))
+	token, err := signDokumen(input.Jenis, id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	mediaURL := fmt.Sprintf("%s/dokumen/%s/%s?token=%s", baseURL, input.Jenis, id, token)
+
+	message := input.Message
+	if message == "" {
+		data := dataTemplate(tagihan.Penyewa, &tagihan)
+		data["dokumen"] = namaDokumen(input.Jenis, bahasaPenyewa(tagihan.Penyewa))
+		message = renderPesan("DOKUMEN", tagihan.Penyewa, data)
+	}
+
+	phoneNumber := formatPhoneNumber(*tagihan.Penyewa.NoHP)
+	result := SendViaWhatsAppMedia(phoneNumber, message, mediaURL)
+	if !result.Success {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send WhatsApp: " + result.Error})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":   "Dokumen sent successfully",
+		"phone":     phoneNumber,
+		"media_url": mediaURL,
+	})
+}
+
+func serveDokumenPDF(c *gin.Context, jenis, id string) {
+	var tagihan models.Tagihan
+	if err := database.DB.Preload("Penyewa").Preload("Kamar").First(&tagihan, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Tagihan not found"})
+		return
+	}
+
+	dokumen := buildDokumenTagihan(tagihan)
+	var pdf []byte
+	switch jenis {
+	case "invoice":
+		dokumen.Nomor = fmt.Sprintf("INV/%s/%05d", tagihan.Bulan, tagihan.ID)
+		pdf = services.RenderInvoicePDF(dokumen)
+	case "kwitansi":
+		if tagihan.Terbayar <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Tagihan belum memiliki pembayaran"})
+			return
+		}
+		dokumen.Nomor = fmt.Sprintf("KW/%s/%05d", tagihan.Bulan, tagihan.ID)
+		pdf = services.RenderKwitansiPDF(dokumen)
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Jenis dokumen must be invoice or kwitansi"})
+		return
+	}
+
+	filename := fmt.Sprintf("%s-%s-%d.pdf", jenis, tagihan.Bulan, tagihan.ID)
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	c.Data(http.StatusOK, "application/pdf", pdf)
+}
+
+func buildDokumenTagihan(tagihan models.Tagihan) services.DokumenTagihan {
+	namaKos := os.Getenv("APP_NAME")
+	if namaKos == "" {
+		namaKos = "Kos Muhandis"
+	}
+
+	// Kamar pada tagihan lama bisa kosong (dibuat oleh GenerateMonthlyBills), ambil dari penyewa
+	namaKamar := tagihan.Kamar.Nama
+	if namaKamar == "" {
+		var kamar models.Kamar
+		if err := database.DB.First(&kamar, tagihan.Penyewa.KamarID).Error; err == nil {
+			namaKamar = kamar.Nama
+		}
+	}
+
+	keterangan := "Sewa Kamar " + namaKamar
+	if tagihan.JenisTagihan != "" && tagihan.JenisTagihan != "Penyewa" {
+		keterangan = "Tagihan " + tagihan.JenisTagihan
+	}
+
+	noHP := ""
+	if tagihan.Penyewa.NoHP != nil {
+		noHP = *tagihan.Penyewa.NoHP
+	}
+
+	tanggalBayar := ""
+	if len(tagihan.TanggalBayar) >= 10 {
+		if t, err := time.Parse("2006-01-02", tagihan.TanggalBayar[:10]); err == nil {
+			tanggalBayar = services.FormatTanggal(t)
+		}
+	}
+
+	return services.DokumenTagihan{
+		NamaKos:     namaKos,
+		AlamatKos:   os.Getenv("KOS_ADDRESS"),
+		Tanggal:     services.FormatTanggal(time.Now()),
+		NamaPenyewa: tagihan.Penyewa.Nama,
+		NoHP:        noHP,
+		NamaKamar:   namaKamar,
+		Periode:     tagihan.Bulan,
+		Items: []services.DokumenItem{
+			{Keterangan: keterangan + " - " + services.NamaBulan(tagihan.Bulan), Jumlah: tagihan.Jumlah},
+		},
+		Total:        tagihan.Jumlah,
+		Terbayar:     tagihan.Terbayar,
+		Status:       tagihan.Status,
+		DiterimaOleh: tagihan.DiterimaOleh,
+		TanggalBayar: tanggalBayar,
+	}
+}
+
+// masaBerlakuLink - Masa berlaku token link publik bertanda tangan
+const masaBerlakuLink = 30 * 24 * time.Hour
+
+var errSecretKosong = errors.New("JWT_SECRET is not configured")
+
+// signDokumen - Token link dokumen publik "<exp>.<hmac>", exp dalam detik unix. Tanpa JWT_SECRET
+// token tidak dibuat karena HMAC dengan kunci kosong bisa dibuat siapa saja.
+func signDokumen(jenis, id string) (string, error) {
+	exp := strconv.FormatInt(time.Now().Add(masaBerlakuLink).Unix(), 10)
+	sig, err := hmacDokumen(jenis, id, exp)
+	if err != nil {
+		return "", err
+	}
+	return exp + "." + sig, nil
+}
+
+// cekTokenDokumen - Token cocok dengan jenis dan id serta belum kedaluwarsa
+func cekTokenDokumen(jenis, id, token string) bool {
+	exp, sig, ok := strings.Cut(token, ".")
+	if !ok {
+		return false
+	}
+	detik, err := strconv.ParseInt(exp, 10, 64)
+	if err != nil || time.Now().Unix() > detik {
+		return false
+	}
+	expected, err := hmacDokumen(jenis, id, exp)
+	return err == nil && hmac.Equal([]byte(sig), []byte(expected))
+}
+
+func hmacDokumen(jenis, id, exp string) (string, error) {
+	secret := os.Getenv("JWT_SECRET")
+	if secret == "" {
+		return "", errSecretKosong
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(jenis + ":" + id + ":" + exp))
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
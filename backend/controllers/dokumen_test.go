@@ -0,0 +1,48 @@
+package controllers
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSignDokumen(t *testing.T) {
+	t.Setenv("JWT_SECRET", "rahasia-uji")
+	token, err := signDokumen("kwitansi", "12")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cekTokenDokumen("kwitansi", "12", token) {
+		t.Fatal("token baru ditolak")
+	}
+
+	kedaluwarsa := strconv.FormatInt(time.Now().Add(-time.Minute).Unix(), 10)
+	sig, _ := hmacDokumen("kwitansi", "12", kedaluwarsa)
+	exp, _, _ := strings.Cut(token, ".")
+	tests := []struct {
+		name      string
+		jenis, id string
+		token     string
+	}{
+		{"id lain", "kwitansi", "13", token},
+		{"jenis lain", "invoice", "12", token},
+		{"kedaluwarsa", "kwitansi", "12", kedaluwarsa + "." + sig},
+		{"exp diubah", "kwitansi", "12", exp + "0" + token[len(exp):]},
+		{"tanpa exp", "kwitansi", "12", sig},
+		{"kosong", "kwitansi", "12", ""},
+	}
+	for _, tt := range tests {
+		if cekTokenDokumen(tt.jenis, tt.id, tt.token) {
+			t.Errorf("%s: token diterima", tt.name)
+		}
+	}
+
+	t.Setenv("JWT_SECRET", "")
+	if _, err := signDokumen("kwitansi", "12"); err == nil {
+		t.Error("token dibuat tanpa JWT_SECRET")
+	}
+	if cekTokenDokumen("kwitansi", "12", token) {
+		t.Error("token diterima tanpa JWT_SECRET")
+	}
+}
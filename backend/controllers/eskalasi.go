@@ -0,0 +1,224 @@
+package controllers
+
+import (
+	"net/http"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"kos-muhandis/backend/database"
+	"kos-muhandis/backend/models"
+	"kos-muhandis/backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+var penerimaEskalasi = []string{"penyewa", "kontak_darurat", "pemilik"}
+
+var tipeEskalasiRe = regexp.MustCompile(`^[A-Z0-9_+-]{1,30}$`)
+
+// GetLangkahEskalasi - Urutan eskalasi per penerima, urut hari
+func GetLangkahEskalasi(c *gin.Context) {
+	var langkah []models.LangkahEskalasi
+	if err := database.DB.Order("penerima ASC, hari ASC, id ASC").Find(&langkah).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch eskalasi"})
+		return
+	}
+	c.JSON(http.StatusOK, langkah)
+}
+
+// CreateLangkahEskalasi - Tambah langkah. template default sama dengan tipe; isi opsional
+// mengganti template untuk langkah ini saja.
+func CreateLangkahEskalasi(c *gin.Context) {
+	var input struct {
+		Tipe     string `json:"tipe" binding:"required"`
+		Hari     *int   `json:"hari" binding:"required"`
+		Penerima string `json:"penerima"`
+		Kanal    string `json:"kanal"`
+		Template string `json:"template"`
+		Isi      string `json:"isi"`
+		Aktif    *bool  `json:"aktif"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	langkah := models.LangkahEskalasi{
+		Tipe:     strings.ToUpper(strings.TrimSpace(input.Tipe)),
+		Hari:     *input.Hari,
+		Penerima: input.Penerima,
+		Kanal:    input.Kanal,
+		Template: strings.ToUpper(input.Template),
+		Isi:      input.Isi,
+		Aktif:    input.Aktif == nil || *input.Aktif,
+	}
+	if langkah.Penerima == "" {
+		langkah.Penerima = "penyewa"
+	}
+	if langkah.Template == "" && langkah.Isi == "" {
+		langkah.Template = langkah.Tipe
+	}
+	if msg := validateLangkahEskalasi(&langkah); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+	if err := database.DB.Create(&langkah).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create eskalasi"})
+		return
+	}
+	c.JSON(http.StatusCreated, langkah)
+}
+
+// UpdateLangkahEskalasi - Ubah langkah. Tipe tidak bisa diubah karena dipakai notifikasi yang sudah dibuat.
+func UpdateLangkahEskalasi(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	var langkah models.LangkahEskalasi
+	if err := database.DB.First(&langkah, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Eskalasi not found"})
+		return
+	}
+	var input struct {
+		Hari     *int    `json:"hari"`
+		Penerima string  `json:"penerima"`
+		Kanal    *string `json:"kanal"`
+		Template *string `json:"template"`
+		Isi      *string `json:"isi"`
+		Aktif    *bool   `json:"aktif"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if input.Hari != nil {
+		langkah.Hari = *input.Hari
+	}
+	if input.Penerima != "" {
+		langkah.Penerima = input.Penerima
+	}
+	if input.Kanal != nil {
+		langkah.Kanal = *input.Kanal
+	}
+	if input.Template != nil {
+		langkah.Template = strings.ToUpper(*input.Template)
+	}
+	if input.Isi != nil {
+		langkah.Isi = *input.Isi
+	}
+	if input.Aktif != nil {
+		langkah.Aktif = *input.Aktif
+	}
+	if msg := validateLangkahEskalasi(&langkah); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+	if err := database.DB.Save(&langkah).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update eskalasi"})
+		return
+	}
+	c.JSON(http.StatusOK, langkah)
+}
+
+// DeleteLangkahEskalasi - Hapus langkah; notifikasi yang sudah dibuat tetap ada
+func DeleteLangkahEskalasi(c *gin.Context) {
+	if err := database.DB.Delete(&models.LangkahEskalasi{}, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete eskalasi"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Eskalasi deleted"})
+}
+
+// validateLangkahEskalasi - Cek tipe, penerima, kanal, template/isi, dan tipe unik.
+// Kanal dinormalisasi menjadi daftar dipisah koma tanpa spasi.
+func validateLangkahEskalasi(l *models.LangkahEskalasi) string {
+	if !tipeEskalasiRe.MatchString(l.Tipe) {
+		return "Tipe hanya boleh huruf besar, angka, +, - dan _ (maks 30 karakter)"
+	}
+	dikenal := false
+	for _, p := range penerimaEskalasi {
+		dikenal = dikenal || p == l.Penerima
+	}
+	if !dikenal {
+		return "Penerima harus penyewa, kontak_darurat, atau pemilik"
+	}
+	var kanal []string
+	for _, k := range strings.Split(l.Kanal, ",") {
+		if k = strings.TrimSpace(k); k == "" {
+			continue
+		}
+		if !dikenalKanal(k) {
+			return "Kanal tidak dikenal: " + k
+		}
+		if k == "push" && l.Penerima != "penyewa" {
+			return "Kanal push hanya untuk penerima penyewa"
+		}
+		kanal = append(kanal, k)
+	}
+	l.Kanal = strings.Join(kanal, ",")
+	if l.Isi != "" {
+		if asing := services.VariabelTidakDikenal(l.Isi); len(asing) > 0 {
+			return "Variabel tidak dikenal: " + strings.Join(asing, ", ")
+		}
+	} else if templateBawaan["id"][l.Template] == "" {
+		return "Template tidak dikenal: " + l.Template
+	}
+	var count int64
+	database.DB.Model(&models.LangkahEskalasi{}).Where("tipe = ? AND id <> ?", l.Tipe, l.ID).Count(&count)
+	if count > 0 {
+		return "Tipe eskalasi sudah dipakai"
+	}
+	return ""
+}
+
+// pesanLangkah - Pesan satu langkah untuk tagihan; pesan ke pemilik selalu berbahasa Indonesia
+func pesanLangkah(l models.LangkahEskalasi, penyewa models.Penyewa, tagihan *models.Tagihan) string {
+	if l.Penerima == "pemilik" {
+		penyewa.Bahasa = "id"
+	}
+	if l.Isi != "" {
+		return services.RenderTemplate(l.Isi, dataTemplate(penyewa, tagihan))
+	}
+	return pesanTemplate(l.Template, penyewa, tagihan)
+}
+
+// tujuanPenerima - Alamat penerima notifikasi untuk satu kanal. Kontak darurat dari data penyewa,
+// pemilik dari PEMILIK_NO_HP / PEMILIK_EMAIL.
+func tujuanPenerima(penerima string, penyewa models.Penyewa, kanal string) []tujuanNotifikasi {
+	var noHP, email *string
+	switch penerima {
+	case "kontak_darurat":
+		noHP, email = penyewa.KontakDaruratNoHP, penyewa.KontakDaruratEmail
+	case "pemilik":
+		hp, mail := os.Getenv("PEMILIK_NO_HP"), os.Getenv("PEMILIK_EMAIL")
+		noHP, email = &hp, &mail
+	default:
+		return tujuanKanal(penyewa, kanal)
+	}
+	switch kanal {
+	case "whatsapp", "sms":
+		if noHP != nil && *noHP != "" {
+			nomor := nomorWhatsApp(*noHP)
+			return []tujuanNotifikasi{{Alamat: nomor, Label: nomor}}
+		}
+	case "email":
+		if email != nil && *email != "" {
+			return []tujuanNotifikasi{{Alamat: *email, Label: *email}}
+		}
+	}
+	return nil
+}
+
+// adaPenerima - Kontak darurat dan pemilik hanya dieskalasi bila alamatnya sudah diisi
+func adaPenerima(penerima string, penyewa models.Penyewa) bool {
+	for _, kanal := range []string{"whatsapp", "email"} {
+		if penerima == "penyewa" || len(tujuanPenerima(penerima, penyewa, kanal)) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// tagihanLunas - Eskalasi berhenti begitu tagihan lunas
+func tagihanLunas(t models.Tagihan) bool {
+	return t.Status == "Lunas" || (t.Jumlah > 0 && t.Terbayar >= t.Jumlah)
+}
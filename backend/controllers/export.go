@@ -0,0 +1,123 @@
+package controllers
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"time"
+
+	"kos-muhandis/backend/database"
+	"kos-muhandis/backend/models"
+	"kos-muhandis/backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// respondExport - Kirim tabel sebagai file jika query ?format=csv|xlsx diisi.
+// Mengembalikan false jika format kosong sehingga handler lanjut mengirim JSON.
+func respondExport(c *gin.Context, filename string, table services.ExportTable) bool {
+	return writeExport(c, c.Query("format"), filename, table)
+}
+
+func writeExport(c *gin.Context, format, filename string, table services.ExportTable) bool {
+	var buf bytes.Buffer
+	var contentType string
+
+	switch format {
+	case "":
+		return false
+	case "csv":
+		if err := services.WriteCSV(&buf, table); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate CSV"})
+			return true
+		}
+		contentType = "text/csv; charset=utf-8"
+	case "xlsx":
+		if err := services.WriteXLSX(&buf, table); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate XLSX"})
+			return true
+		}
+		contentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be csv or xlsx"})
+		return true
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.%s"`, filename, format))
+	c.Data(http.StatusOK, contentType, buf.Bytes())
+	return true
+}
+
+// ExportTunggakan - Export daftar tunggakan (tagihan belum lunas) ke CSV/XLSX
+func ExportTunggakan(c *gin.Context) {
+	var tagihanList []models.Tagihan
+	if err := database.DB.Preload("Penyewa.Kamar").Where("status != ?", "Lunas").
+		Order("bulan ASC").Find(&tagihanList).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tagihan"})
+		return
+	}
+
+	table := services.ExportTable{
+		Title: "Tunggakan",
+		Columns: []services.ExportColumn{
+			{Header: "Penyewa"}, {Header: "Kamar"}, {Header: "No HP"}, {Header: "Bulan"}, {Header: "Jenis Tagihan"},
+			{Header: "Jumlah", Rupiah: true}, {Header: "Terbayar", Rupiah: true}, {Header: "Sisa", Rupiah: true}, {Header: "Status"},
+		},
+	}
+	for _, t := range tagihanList {
+		kamar := ""
+		if t.Penyewa.Kamar != nil {
+			kamar = t.Penyewa.Kamar.Nama
+		}
+		table.AddRow(t.Penyewa.Nama, kamar, stringValue(t.Penyewa.NoHP), t.Bulan, t.JenisTagihan,
+			t.Jumlah, t.Terbayar, t.Jumlah-t.Terbayar, t.Status)
+	}
+
+	writeExport(c, exportFormat(c), "tunggakan-"+time.Now().Format("20060102"), table)
+}
+
+// ExportPenyewa - Export daftar penyewa ke CSV/XLSX
+func ExportPenyewa(c *gin.Context) {
+	var penyewaList []models.Penyewa
+	if err := database.DB.Preload("Kamar").Order("nama ASC").Find(&penyewaList).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch penyewa"})
+		return
+	}
+
+	table := services.ExportTable{
+		Title: "Penyewa",
+		Columns: []services.ExportColumn{
+			{Header: "Nama"}, {Header: "Email"}, {Header: "No HP"}, {Header: "Alamat"},
+			{Header: "Kamar"}, {Header: "Harga Sewa", Rupiah: true}, {Header: "Tanggal Masuk"},
+		},
+	}
+	for _, p := range penyewaList {
+		kamar, harga := "", 0
+		if p.Kamar != nil {
+			kamar, harga = p.Kamar.Nama, p.Kamar.Harga
+		}
+		tanggalMasuk := ""
+		if p.TanggalMasuk != nil {
+			tanggalMasuk = p.TanggalMasuk.Format("2006-01-02")
+		}
+		table.AddRow(p.Nama, stringValue(p.Email), stringValue(p.NoHP), stringValue(p.Alamat), kamar, harga, tanggalMasuk)
+	}
+
+	writeExport(c, exportFormat(c), "penyewa-"+time.Now().Format("20060102"), table)
+}
+
+// exportFormat - Endpoint export khusus default ke xlsx
+func exportFormat(c *gin.Context) string {
+	format := c.Query("format")
+	if format == "" {
+		format = "xlsx"
+	}
+	return format
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,613 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"kos-muhandis/backend/database"
+	"kos-muhandis/backend/models"
+	"kos-muhandis/backend/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const maxImportFileSize = 10 << 20 // 10 MB
+
+type importField struct {
+	Name        string `json:"name"`
+	Required    bool   `json:"required"`
+	Description string `json:"description"`
+}
+
+var importFields = map[string][]importField{
+	"kamar": {
+		{Name: "nama", Required: true, Description: "Nama/nomor kamar, harus unik"},
+		{Name: "harga", Required: true, Description: "Harga sewa per bulan"},
+		{Name: "status", Description: "Tersedia, Terisi atau Perbaikan (default Tersedia)"},
+	},
+	"penyewa": {
+		{Name: "nama", Required: true, Description: "Nama penyewa"},
+		{Name: "kamar", Required: true, Description: "Nama kamar yang sudah terdaftar"},
+		{Name: "email", Description: "Email"},
+		{Name: "no_hp", Description: "Nomor HP"},
+		{Name: "alamat", Description: "Alamat asal"},
+		{Name: "tanggal_masuk", Description: "Tanggal masuk (YYYY-MM-DD atau DD/MM/YYYY)"},
+		{Name: "tanggal_keluar", Description: "Tanggal akhir kontrak (opsional)"},
+	},
+	"tagihan": {
+		{Name: "penyewa", Required: true, Description: "Nama penyewa yang sudah terdaftar"},
+		{Name: "bulan", Required: true, Description: "Periode tagihan (YYYY-MM atau MM/YYYY)"},
+		{Name: "jumlah", Required: true, Description: "Jumlah tagihan"},
+		{Name: "terbayar", Description: "Jumlah yang sudah dibayar"},
+		{Name: "status", Description: "Lunas, Belum Lunas atau Cicil (default dihitung dari terbayar)"},
+		{Name: "jenis_tagihan", Description: "Penyewa, Listrik, WiFi, Air, dll (default Penyewa)"},
+		{Name: "diterima_oleh", Description: "Penerima pembayaran"},
+		{Name: "tanggal_bayar", Description: "Tanggal pembayaran"},
+	},
+}
+
+// ImportRowError - Kesalahan validasi pada satu baris file import
+type ImportRowError struct {
+	Row     int    `json:"row"`
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+// ImportReport - Hasil validasi (dry-run) atau commit import
+type ImportReport struct {
+	Entitas   string            `json:"entitas"`
+	DryRun    bool              `json:"dry_run"`
+	TotalRows int               `json:"total_rows"`
+	ValidRows int               `json:"valid_rows"`
+	Errors    []ImportRowError  `json:"errors"`
+	Committed bool              `json:"committed"`
+	Created   int               `json:"created"`
+	Mapping   map[string]string `json:"mapping"`
+}
+
+// GetImportFields - Daftar kolom yang bisa dipetakan untuk import
+func GetImportFields(c *gin.Context) {
+	fields, ok := importFields[c.Param("entitas")]
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Entitas must be kamar, penyewa or tagihan"})
+		return
+	}
+	c.JSON(http.StatusOK, fields)
+}
+
+// ImportData - Import kamar/penyewa/tagihan dari file CSV/XLSX.
+// Form field: file, mapping (JSON {"field": "Header Kolom"}), dry_run (default true).
+// Commit hanya dilakukan jika semua baris valid, dalam satu transaksi.
+func ImportData(c *gin.Context) {
+	entitas := c.Param("entitas")
+	fields, ok := importFields[entitas]
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Entitas must be kamar, penyewa or tagihan"})
+		return
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "File is required"})
+		return
+	}
+	if fileHeader.Size > maxImportFileSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "File too large (max 10 MB)"})
+		return
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read file"})
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read file"})
+		return
+	}
+
+	rows, err := services.ReadSpreadsheet(fileHeader.Filename, data)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(rows) < 2 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "File has no data rows"})
+		return
+	}
+
+	userMapping := map[string]string{}
+	if raw := c.PostForm("mapping"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &userMapping); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid mapping: " + err.Error()})
+			return
+		}
+	}
+
+	columns, mapping, mappingErrors := resolveImportColumns(fields, rows[0], userMapping)
+	report := ImportReport{
+		Entitas: entitas,
+		DryRun:  c.DefaultPostForm("dry_run", "true") != "false",
+		Mapping: mapping,
+		Errors:  mappingErrors,
+	}
+	if len(mappingErrors) > 0 {
+		c.JSON(http.StatusBadRequest, report)
+		return
+	}
+
+	var records []interface{}
+	var rowErrors []ImportRowError
+	switch entitas {
+	case "kamar":
+		records, rowErrors, err = validateImportKamar(rows, columns)
+	case "penyewa":
+		records, rowErrors, err = validateImportPenyewa(rows, columns)
+	case "tagihan":
+		records, rowErrors, err = validateImportTagihan(rows, columns)
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to validate import: " + err.Error()})
+		return
+	}
+
+	report.Errors = rowErrors
+	report.TotalRows = len(records) + countErrorRows(rowErrors)
+	report.ValidRows = len(records)
+
+	if report.DryRun {
+		c.JSON(http.StatusOK, report)
+		return
+	}
+	if len(rowErrors) > 0 {
+		c.JSON(http.StatusUnprocessableEntity, report)
+		return
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		for _, record := range records {
+			if tagihan, ok := record.(*models.Tagihan); ok {
+				// tanggal_bayar bertipe DATE, jangan kirim string kosong
+				create := tx
+				if tagihan.TanggalBayar == "" {
+					create = tx.Omit("TanggalBayar")
+				}
+				if err := create.Create(tagihan).Error; err != nil {
+					return err
+				}
+				if err := syncPembayaran(tx, *tagihan, 0); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := tx.Create(record).Error; err != nil {
+				return err
+			}
+			// Sama seperti CreatePenyewa, kamar yang ditempati menjadi Terisi
+			if penyewa, ok := record.(*models.Penyewa); ok {
+				if err := tx.Model(&models.Kamar{}).Where("id = ?", penyewa.KamarID).Update("status", "Terisi").Error; err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if errors.Is(err, errTanpaRekening) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Import failed, no data was saved: " + err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Import failed, no data was saved: " + err.Error()})
+		return
+	}
+
+	report.Committed = true
+	report.Created = len(records)
+	c.JSON(http.StatusCreated, report)
+}
+
+// resolveImportColumns - Cari index kolom untuk setiap field berdasarkan mapping user
+// atau nama header yang sama (tidak case-sensitive).
+func resolveImportColumns(fields []importField, header []string, userMapping map[string]string) (map[string]int, map[string]string, []ImportRowError) {
+	headerIndex := make(map[string]int)
+	for i, h := range header {
+		headerIndex[normalizeHeader(h)] = i
+	}
+
+	columns := make(map[string]int)
+	mapping := make(map[string]string)
+	var errs []ImportRowError
+	for _, field := range fields {
+		source := field.Name
+		if mapped, ok := userMapping[field.Name]; ok && mapped != "" {
+			source = mapped
+		}
+		idx, found := headerIndex[normalizeHeader(source)]
+		if !found {
+			if field.Required {
+				errs = append(errs, ImportRowError{Row: 1, Field: field.Name, Message: fmt.Sprintf("Kolom %q tidak ditemukan", source)})
+			}
+			continue
+		}
+		columns[field.Name] = idx
+		mapping[field.Name] = header[idx]
+	}
+	return columns, mapping, errs
+}
+
+func normalizeHeader(h string) string {
+	return strings.ReplaceAll(strings.ToLower(strings.TrimSpace(h)), " ", "_")
+}
+
+// importRow - Akses nilai sel per field untuk satu baris
+type importRow struct {
+	number  int
+	values  []string
+	columns map[string]int
+	errors  []ImportRowError
+}
+
+func (r *importRow) get(field string) string {
+	idx, ok := r.columns[field]
+	if !ok || idx >= len(r.values) {
+		return ""
+	}
+	return strings.TrimSpace(r.values[idx])
+}
+
+func (r *importRow) fail(field, message string) {
+	r.errors = append(r.errors, ImportRowError{Row: r.number, Field: field, Message: message})
+}
+
+func (r *importRow) hasError(field string) bool {
+	for _, e := range r.errors {
+		if e.Field == field {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *importRow) required(field string) string {
+	value := r.get(field)
+	if value == "" {
+		r.fail(field, "Wajib diisi")
+	}
+	return value
+}
+
+func (r *importRow) amount(field string, required bool) int {
+	value := r.get(field)
+	if value == "" {
+		if required {
+			r.fail(field, "Wajib diisi")
+		}
+		return 0
+	}
+	n, err := parseImportAmount(value)
+	if err != nil {
+		r.fail(field, fmt.Sprintf("Jumlah tidak valid: %q (%s)", value, err))
+	} else if n < 0 {
+		r.fail(field, fmt.Sprintf("Jumlah tidak valid: %q", value))
+	}
+	return n
+}
+
+func (r *importRow) date(field string) *time.Time {
+	value := r.get(field)
+	if value == "" {
+		return nil
+	}
+	t, err := parseImportDate(value)
+	if err != nil {
+		r.fail(field, fmt.Sprintf("Tanggal tidak valid: %q (gunakan YYYY-MM-DD atau DD/MM/YYYY)", value))
+		return nil
+	}
+	return &t
+}
+
+func eachImportRow(rows [][]string, columns map[string]int, fn func(r *importRow)) []ImportRowError {
+	var errs []ImportRowError
+	for i, values := range rows[1:] {
+		if strings.TrimSpace(strings.Join(values, "")) == "" {
+			continue
+		}
+		r := &importRow{number: i + 2, values: values, columns: columns}
+		fn(r)
+		errs = append(errs, r.errors...)
+	}
+	return errs
+}
+
+func validateImportKamar(rows [][]string, columns map[string]int) ([]interface{}, []ImportRowError, error) {
+	existing := make(map[string]bool)
+	var kamarList []models.Kamar
+	if err := database.DB.Find(&kamarList).Error; err != nil {
+		return nil, nil, err
+	}
+	for _, k := range kamarList {
+		existing[strings.ToLower(k.Nama)] = true
+	}
+
+	var records []interface{}
+	errs := eachImportRow(rows, columns, func(r *importRow) {
+		nama := r.required("nama")
+		harga := r.amount("harga", true)
+		status := r.get("status")
+		if status == "" {
+			status = "Tersedia"
+		} else if status != "Tersedia" && status != "Terisi" && status != "Perbaikan" {
+			r.fail("status", "Status harus Tersedia, Terisi atau Perbaikan")
+		}
+		if nama != "" && existing[strings.ToLower(nama)] {
+			r.fail("nama", fmt.Sprintf("Kamar %q sudah ada", nama))
+		}
+		if len(r.errors) == 0 {
+			existing[strings.ToLower(nama)] = true
+			records = append(records, &models.Kamar{Nama: nama, Harga: harga, Status: status})
+		}
+	})
+	return records, errs, nil
+}
+
+func validateImportPenyewa(rows [][]string, columns map[string]int) ([]interface{}, []ImportRowError, error) {
+	kamarByNama := make(map[string]models.Kamar)
+	var kamarList []models.Kamar
+	if err := database.DB.Find(&kamarList).Error; err != nil {
+		return nil, nil, err
+	}
+	for _, k := range kamarList {
+		kamarByNama[strings.ToLower(k.Nama)] = k
+	}
+
+	// Kamar terisi bila masih ada penyewa yang kontraknya belum berakhir. Status kamar tidak dipakai:
+	// kamar yang baru diimpor berstatus Terisi sebelum penyewanya diimpor.
+	terisi := make(map[uint]bool)
+	var dihuni []uint
+	if err := database.DB.Model(&models.Penyewa{}).
+		Where("tanggal_keluar IS NULL OR tanggal_keluar >= ?", today()).
+		Distinct().Pluck("kamar_id", &dihuni).Error; err != nil {
+		return nil, nil, err
+	}
+	for _, id := range dihuni {
+		terisi[id] = true
+	}
+	dipakaiBaris := make(map[uint]int)
+
+	var records []interface{}
+	errs := eachImportRow(rows, columns, func(r *importRow) {
+		nama := r.required("nama")
+		namaKamar := r.required("kamar")
+		kamar, found := kamarByNama[strings.ToLower(namaKamar)]
+		switch {
+		case namaKamar != "" && !found:
+			r.fail("kamar", fmt.Sprintf("Kamar %q tidak ditemukan", namaKamar))
+		case found && terisi[kamar.ID]:
+			r.fail("kamar", fmt.Sprintf("Kamar %q sudah terisi", namaKamar))
+		case found && dipakaiBaris[kamar.ID] > 0:
+			r.fail("kamar", fmt.Sprintf("Kamar %q sudah dipakai di baris %d", namaKamar, dipakaiBaris[kamar.ID]))
+		case found:
+			dipakaiBaris[kamar.ID] = r.number
+		}
+		tanggalMasuk := r.date("tanggal_masuk")
+		tanggalKeluar := r.date("tanggal_keluar")
+		if len(r.errors) > 0 {
+			return
+		}
+		records = append(records, &models.Penyewa{
+			Nama:          nama,
+			Email:         optionalString(r.get("email")),
+			NoHP:          optionalString(r.get("no_hp")),
+			Alamat:        optionalString(r.get("alamat")),
+			KamarID:       kamar.ID,
+			TanggalMasuk:  tanggalMasuk,
+			TanggalKeluar: tanggalKeluar,
+		})
+	})
+	return records, errs, nil
+}
+
+func validateImportTagihan(rows [][]string, columns map[string]int) ([]interface{}, []ImportRowError, error) {
+	penyewaByNama := make(map[string][]models.Penyewa)
+	var penyewaList []models.Penyewa
+	if err := database.DB.Find(&penyewaList).Error; err != nil {
+		return nil, nil, err
+	}
+	for _, p := range penyewaList {
+		key := strings.ToLower(p.Nama)
+		penyewaByNama[key] = append(penyewaByNama[key], p)
+	}
+
+	existing := make(map[string]bool)
+	var tagihanList []models.Tagihan
+	if err := database.DB.Select("penyewa_id", "bulan", "jenis_tagihan").Find(&tagihanList).Error; err != nil {
+		return nil, nil, err
+	}
+	for _, t := range tagihanList {
+		existing[fmt.Sprintf("%d|%s|%s", t.PenyewaID, t.Bulan, t.JenisTagihan)] = true
+	}
+
+	var records []interface{}
+	errs := eachImportRow(rows, columns, func(r *importRow) {
+		namaPenyewa := r.required("penyewa")
+		var penyewa models.Penyewa
+		if namaPenyewa != "" {
+			matches := penyewaByNama[strings.ToLower(namaPenyewa)]
+			switch len(matches) {
+			case 0:
+				r.fail("penyewa", fmt.Sprintf("Penyewa %q tidak ditemukan", namaPenyewa))
+			case 1:
+				penyewa = matches[0]
+			default:
+				r.fail("penyewa", fmt.Sprintf("Nama penyewa %q tidak unik", namaPenyewa))
+			}
+		}
+
+		bulan := ""
+		if raw := r.required("bulan"); raw != "" {
+			parsed, err := parseImportBulan(raw)
+			if err != nil {
+				r.fail("bulan", fmt.Sprintf("Bulan tidak valid: %q", raw))
+			}
+			bulan = parsed
+		}
+
+		jumlah := r.amount("jumlah", true)
+		if jumlah == 0 && !r.hasError("jumlah") {
+			r.fail("jumlah", "Jumlah must be greater than zero")
+		}
+		terbayar := r.amount("terbayar", false)
+
+		status := r.get("status")
+		switch status {
+		case "":
+			status = "Belum Lunas"
+			if terbayar >= jumlah && jumlah > 0 {
+				status = "Lunas"
+			} else if terbayar > 0 {
+				status = "Cicil"
+			}
+		case "Lunas":
+			terbayar = jumlah
+		case "Belum Lunas":
+			terbayar = 0
+		case "Cicil":
+		default:
+			r.fail("status", "Status harus Lunas, Belum Lunas atau Cicil")
+		}
+
+		jenisTagihan := r.get("jenis_tagihan")
+		if jenisTagihan == "" {
+			jenisTagihan = "Penyewa"
+		}
+
+		tanggalBayar := ""
+		if t := r.date("tanggal_bayar"); t != nil {
+			tanggalBayar = t.Format("2006-01-02")
+		}
+
+		key := fmt.Sprintf("%d|%s|%s", penyewa.ID, bulan, jenisTagihan)
+		if len(r.errors) == 0 && existing[key] {
+			r.fail("bulan", fmt.Sprintf("Tagihan %s bulan %s untuk %s sudah ada", jenisTagihan, bulan, namaPenyewa))
+		}
+		if len(r.errors) > 0 {
+			return
+		}
+
+		existing[key] = true
+		records = append(records, &models.Tagihan{
+			PenyewaID:    penyewa.ID,
+			KamarID:      penyewa.KamarID,
+			Bulan:        bulan,
+			Jumlah:       jumlah,
+			Terbayar:     terbayar,
+			Status:       status,
+			JenisTagihan: jenisTagihan,
+			DiterimaOleh: r.get("diterima_oleh"),
+			TanggalBayar: tanggalBayar,
+		})
+	})
+	return records, errs, nil
+}
+
+func countErrorRows(errs []ImportRowError) int {
+	rows := make(map[int]bool)
+	for _, e := range errs {
+		rows[e.Row] = true
+	}
+	return len(rows)
+}
+
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+var (
+	amountRe        = regexp.MustCompile(`^[0-9.,]+$`)
+	amountGroupedRe = regexp.MustCompile(`^[0-9]{1,3}([.,][0-9]{3})*$`)
+)
+
+// parseImportAmount - "Rp 1.500.000", "1,500,000", "1500000.00" dan "1.500.000,00" -> 1500000.
+// Pemisah desimal adalah tanda terakhir bila diikuti 1-2 digit (atau bila kedua tanda dipakai);
+// jumlah dalam rupiah sehingga pecahan selain nol ditolak.
+func parseImportAmount(value string) (int, error) {
+	value = strings.TrimSpace(value)
+	negative := strings.HasPrefix(value, "-")
+	value = strings.TrimPrefix(value, "-")
+	value = strings.TrimSpace(strings.TrimPrefix(strings.TrimPrefix(value, "Rp"), "."))
+	value = strings.ReplaceAll(value, " ", "")
+	if !amountRe.MatchString(value) {
+		return 0, fmt.Errorf("hanya boleh angka dengan pemisah . atau ,")
+	}
+
+	whole, fraction := value, ""
+	if i := strings.LastIndexAny(value, ".,"); i >= 0 {
+		sep := value[i]
+		other := byte(',')
+		if sep == ',' {
+			other = '.'
+		}
+		tail := value[i+1:]
+		if strings.IndexByte(value, other) >= 0 || (strings.Count(value, string(sep)) == 1 && len(tail) != 3) {
+			whole, fraction = value[:i], tail
+			if strings.IndexByte(whole, sep) >= 0 || strings.ContainsAny(fraction, ".,") {
+				return 0, fmt.Errorf("pemisah desimal tidak jelas")
+			}
+		}
+	}
+	if strings.Trim(fraction, "0") != "" {
+		return 0, fmt.Errorf("jumlah rupiah tidak boleh berisi pecahan")
+	}
+	if strings.ContainsAny(whole, ".,") && (!amountGroupedRe.MatchString(whole) || strings.Contains(whole, ".") == strings.Contains(whole, ",")) {
+		return 0, fmt.Errorf("pemisah ribuan tidak valid")
+	}
+	digits := strings.NewReplacer(".", "", ",", "").Replace(whole)
+	if digits == "" {
+		return 0, fmt.Errorf("kosong")
+	}
+	n, err := strconv.Atoi(digits)
+	if err != nil {
+		return 0, fmt.Errorf("terlalu besar")
+	}
+	if negative {
+		n = -n
+	}
+	return n, nil
+}
+
+// parseImportDate - Terima YYYY-MM-DD, DD/MM/YYYY atau DD-MM-YYYY. Sel tanggal XLSX (serial date)
+// sudah diubah ke YYYY-MM-DD oleh services.ReadSpreadsheet; angka polos seperti "2025" ditolak.
+func parseImportDate(value string) (time.Time, error) {
+	for _, layout := range []string{"2006-01-02", "02/01/2006", "2/1/2006", "02-01-2006", "2006-01-02 15:04:05"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date")
+}
+
+// parseImportBulan - Terima YYYY-MM, MM/YYYY atau tanggal lengkap -> "2006-01"
+func parseImportBulan(value string) (string, error) {
+	for _, layout := range []string{"2006-01", "01/2006", "1/2006", "01-2006"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t.Format("2006-01"), nil
+		}
+	}
+	t, err := parseImportDate(value)
+	if err != nil {
+		return "", err
+	}
+	return t.Format("2006-01"), nil
+}
@@ -0,0 +1,100 @@
+package controllers
+
+import (
+	"testing"
+
+	"kos-muhandis/backend/database"
+	"kos-muhandis/backend/models"
+)
+
+func TestParseImportAmount(t *testing.T) {
+	cases := []struct {
+		value string
+		want  int
+		ok    bool
+	}{
+		{"Rp 1.500.000", 1500000, true},
+		{"Rp. 1.500.000", 1500000, true},
+		{"1,500,000", 1500000, true},
+		{"1500000.00", 1500000, true},
+		{"1.500.000,00", 1500000, true},
+		{"1,500,000.00", 1500000, true},
+		{"1.500", 1500, true},
+		{"-250.000", -250000, true},
+		{"1.500.000,50", 0, false},
+		{"12,345.67", 0, false},
+		{"1,5,0", 0, false},
+		{"abc", 0, false},
+	}
+	for _, tc := range cases {
+		got, err := parseImportAmount(tc.value)
+		if (err == nil) != tc.ok || got != tc.want {
+			t.Errorf("parseImportAmount(%q) = %d, %v; want %d, ok=%v", tc.value, got, err, tc.want, tc.ok)
+		}
+	}
+}
+
+func TestParseImportDate(t *testing.T) {
+	cases := []struct {
+		value string
+		want  string
+	}{
+		{"2025-01-02", "2025-01-02"},
+		{"02/01/2025", "2025-01-02"},
+		{"2025", ""},
+		{"45658", ""},
+	}
+	for _, tc := range cases {
+		got, err := parseImportDate(tc.value)
+		switch {
+		case tc.want == "" && err == nil:
+			t.Errorf("parseImportDate(%q) = %s, want error", tc.value, got.Format("2006-01-02"))
+		case tc.want != "" && (err != nil || got.Format("2006-01-02") != tc.want):
+			t.Errorf("parseImportDate(%q) = %s, %v; want %s", tc.value, got.Format("2006-01-02"), err, tc.want)
+		}
+	}
+}
+
+func TestValidateImportPenyewaKamar(t *testing.T) {
+	setupTestDB(t)
+	kamar := func(nama, status string) {
+		if err := database.DB.Create(&models.Kamar{Nama: nama, Harga: 1000000, Status: status}).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Kamar baru diimpor berstatus Terisi tetapi belum ada penyewanya
+	kamar("A1", "Terisi")
+	kamar("A2", "Tersedia")
+	dihuni := seedPenyewa(t, "Lama")
+	keluar := seedPenyewa(t, "Sudah Keluar")
+	database.DB.Model(&keluar).Update("tanggal_keluar", today().AddDate(0, 0, -1))
+	var kamarDihuni, kamarKeluar models.Kamar
+	database.DB.First(&kamarDihuni, dihuni.KamarID)
+	database.DB.First(&kamarKeluar, keluar.KamarID)
+
+	rows := [][]string{
+		{"nama", "kamar"},
+		{"Ani", "A1"},
+		{"Bima", "A2"},
+		{"Cici", kamarDihuni.Nama},
+		{"Dodi", kamarKeluar.Nama},
+		{"Euis", "A2"},
+		{"Fani", "Z9"},
+	}
+	records, errs, err := validateImportPenyewa(rows, map[string]int{"nama": 0, "kamar": 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 3 {
+		t.Errorf("records = %d, want 3 (A1, A2, kamar yang penyewanya sudah keluar)", len(records))
+	}
+	gagal := map[int]bool{}
+	for _, e := range errs {
+		gagal[e.Row] = true
+	}
+	for row, want := range map[int]bool{2: false, 3: false, 4: true, 5: false, 6: true, 7: true} {
+		if gagal[row] != want {
+			t.Errorf("row %d failed = %v, want %v (%v)", row, gagal[row], want, errs)
+		}
+	}
+}
@@ -0,0 +1,515 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"kos-muhandis/backend/database"
+	"kos-muhandis/backend/models"
+	"kos-muhandis/backend/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// JurnalBaris - Satu baris debit/kredit jurnal umum
+type JurnalBaris struct {
+	Sumber     string    `json:"-"`
+	RefID      uint      `json:"-"`
+	Tanggal    time.Time `json:"-"`
+	Keterangan string    `json:"-"`
+	Kategori   string    `json:"kategori"`
+	AkunID     uint      `json:"akun_id"`
+	AkunKode   string    `json:"akun_kode"`
+	AkunNama   string    `json:"akun_nama"`
+	Debit      int       `json:"debit"`
+	Kredit     int       `json:"kredit"`
+}
+
+// JurnalEntri - Satu jurnal seimbang dari tagihan, pembayaran, transaksi atau jurnal manual
+type JurnalEntri struct {
+	Sumber     string        `json:"sumber"` // tagihan, pembayaran, transaksi, transfer, manual
+	RefID      uint          `json:"ref_id"` // id tagihan/transaksi/transfer/jurnal
+	Tanggal    time.Time     `json:"tanggal"`
+	Keterangan string        `json:"keterangan"`
+	Baris      []JurnalBaris `json:"baris"`
+	Debit      int           `json:"debit"`
+	Kredit     int           `json:"kredit"`
+}
+
+// SaldoAkun - Saldo satu akun (neraca saldo, laba rugi, neraca)
+type SaldoAkun struct {
+	AkunID  uint        `json:"akun_id"`
+	Kode    string      `json:"kode"`
+	Nama    string      `json:"nama"`
+	Tipe    string      `json:"tipe"`
+	Debit   int         `json:"debit"`
+	Kredit  int         `json:"kredit"`
+	Saldo   int         `json:"saldo"` // mengikuti saldo normal akun
+	Rincian []SaldoAkun `json:"rincian,omitempty"`
+}
+
+// jurnalQuery - Jurnal umum yang diturunkan dari data operasional, ditambah jurnal manual:
+//   - tagihan (tanggal 1 periode): Piutang Sewa / Pendapatan Sewa, Pendapatan Lain-lain, atau
+//     Deposit Penyewa untuk tagihan deposit
+//   - pembayaran tagihan (transaksi tertaut): Kas / Piutang Sewa
+//   - pemasukan lain: Kas / Pendapatan Lain-lain (kategori deposit: Deposit Penyewa)
+//   - pengeluaran: Beban Operasional / Kas (kategori deposit: pengembalian Deposit Penyewa)
+//   - transfer: rekening tujuan / rekening asal, biaya admin: Beban Operasional / rekening asal
+//
+// "Kas" adalah akun milik rekening transaksi (tanpa rekening: akun Kas 1101). Baris yang akunnya
+// diketahui (rekening, jurnal manual) disambung lewat akun_id; akun sistem lewat kodenya.
+//
+// Nilai bertanda (positif = debit) sehingga jumlah negatif (potongan, koreksi) otomatis dibalik.
+const jurnalQuery = `
+	SELECT b.sumber, b.ref_id, b.tanggal, b.keterangan, b.kategori,
+		a.id AS akun_id, a.kode AS akun_kode, a.nama AS akun_nama, a.tipe AS akun_tipe,
+		GREATEST(b.nilai, 0) AS debit, GREATEST(-b.nilai, 0) AS kredit
+	FROM (
+		SELECT 'tagihan' AS sumber, t.id AS ref_id, TO_DATE(LEFT(t.bulan, 7), 'YYYY-MM') AS tanggal,
+			'Tagihan ' || COALESCE(NULLIF(t.jenis_tagihan, ''), 'Penyewa') || ' ' || LEFT(t.bulan, 7) || ' - ' || COALESCE(py.nama, '') AS keterangan,
+			COALESCE(NULLIF(NULLIF(t.jenis_tagihan, ''), 'Penyewa'), 'Sewa Kamar') AS kategori, x.akun_id, x.kode, x.nilai
+		FROM tagihans t
+		LEFT JOIN penyewas py ON py.id = t.penyewa_id
+		CROSS JOIN LATERAL (VALUES
+			(CAST(NULL AS bigint), '1201', t.jumlah),
+			(NULL, CASE
+				WHEN LOWER(t.jenis_tagihan) LIKE '%deposit%' THEN '2101'
+				WHEN COALESCE(NULLIF(t.jenis_tagihan, ''), 'Penyewa') = 'Penyewa' THEN '4101'
+				ELSE '4102' END, -t.jumlah)
+		) AS x(akun_id, kode, nilai)
+		WHERE t.deleted_at IS NULL AND t.jumlah <> 0
+		UNION ALL
+		SELECT CASE WHEN tr.pembayaran_id IS NULL THEN 'transaksi' ELSE 'pembayaran' END, tr.id, tr.tanggal,
+			COALESCE(NULLIF(tr.keterangan, ''), tr.kategori), tr.kategori, x.akun_id, x.kode, x.nilai
+		FROM transaksis tr
+		LEFT JOIN rekenings rk ON rk.id = tr.rekening_id
+		CROSS JOIN LATERAL (VALUES
+			(CAST(rk.akun_id AS bigint), '1101', CASE WHEN LOWER(tr.jenis) = 'pengeluaran' THEN -tr.jumlah ELSE tr.jumlah END),
+			(NULL, CASE
+				WHEN tr.pembayaran_id IS NOT NULL THEN '1201'
+				WHEN LOWER(tr.kategori) LIKE '%deposit%' THEN '2101'
+				WHEN LOWER(tr.jenis) = 'pengeluaran' THEN '5101'
+				ELSE '4102' END,
+			CASE WHEN LOWER(tr.jenis) = 'pengeluaran' THEN tr.jumlah ELSE -tr.jumlah END)
+		) AS x(akun_id, kode, nilai)
+		WHERE tr.deleted_at IS NULL AND tr.jumlah <> 0
+		UNION ALL
+		SELECT 'transfer', tf.id, tf.tanggal,
+			COALESCE(NULLIF(tf.keterangan, ''), 'Transfer ' || dr.nama || ' ke ' || kr.nama), 'Transfer', x.akun_id, x.kode, x.nilai
+		FROM transfers tf
+		JOIN rekenings dr ON dr.id = tf.dari_rekening_id
+		JOIN rekenings kr ON kr.id = tf.ke_rekening_id
+		CROSS JOIN LATERAL (VALUES
+			(CAST(kr.akun_id AS bigint), CAST(NULL AS text), tf.jumlah),
+			(dr.akun_id, NULL, -tf.jumlah - tf.biaya),
+			(NULL, '5101', tf.biaya)
+		) AS x(akun_id, kode, nilai)
+		WHERE tf.deleted_at IS NULL AND x.nilai <> 0
+		UNION ALL
+		SELECT 'manual', j.id, j.tanggal, COALESCE(j.keterangan, ''), '', d.akun_id, NULL, d.debit - d.kredit
+		FROM jurnal_details d
+		JOIN jurnals j ON j.id = d.jurnal_id
+		WHERE j.deleted_at IS NULL
+	) b
+	JOIN akuns a ON a.id = COALESCE(b.akun_id, (SELECT s.id FROM akuns s WHERE s.kode = b.kode AND s.deleted_at IS NULL))`
+
+// GetJurnal - Jurnal umum per entri dalam rentang tanggal.
+// Query: start_date, end_date (default awal tahun s/d hari ini), akun_id, sumber, page, per_page.
+func GetJurnal(c *gin.Context) {
+	start, end, ok := periodeLaporan(c)
+	if !ok {
+		return
+	}
+	page, perPage := pagination(c)
+	akunID, _ := strconv.Atoi(c.Query("akun_id"))
+	args := map[string]interface{}{
+		"start":  start.Format("2006-01-02"),
+		"end":    end.Format("2006-01-02"),
+		"akun":   akunID,
+		"sumber": c.Query("sumber"),
+		"limit":  perPage,
+		"offset": (page - 1) * perPage,
+	}
+	base := `
+		WITH jurnal AS (` + jurnalQuery + `
+		),
+		entri AS (
+			SELECT sumber, ref_id, MIN(tanggal) AS tanggal
+			FROM jurnal
+			WHERE tanggal BETWEEN CAST(@start AS date) AND CAST(@end AS date) AND (@sumber = '' OR sumber = @sumber)
+			GROUP BY sumber, ref_id
+			HAVING @akun = 0 OR BOOL_OR(akun_id = @akun)
+		)`
+
+	var total int64
+	if err := database.DB.Raw(base+` SELECT COUNT(*) FROM entri`, args).Scan(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build jurnal"})
+		return
+	}
+
+	var baris []JurnalBaris
+	if err := database.DB.Raw(base+`,
+		halaman AS (
+			SELECT * FROM entri ORDER BY tanggal, sumber, ref_id LIMIT @limit OFFSET @offset
+		)
+		SELECT j.* FROM jurnal j
+		JOIN halaman h ON h.sumber = j.sumber AND h.ref_id = j.ref_id
+		ORDER BY h.tanggal, j.sumber, j.ref_id, j.kredit, j.akun_kode`, args).Scan(&baris).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build jurnal"})
+		return
+	}
+
+	entries := []JurnalEntri{}
+	for _, b := range baris {
+		n := len(entries)
+		if n == 0 || entries[n-1].Sumber != b.Sumber || entries[n-1].RefID != b.RefID {
+			entries = append(entries, JurnalEntri{Sumber: b.Sumber, RefID: b.RefID, Tanggal: b.Tanggal, Keterangan: b.Keterangan})
+			n++
+		}
+		entries[n-1].Baris = append(entries[n-1].Baris, b)
+		entries[n-1].Debit += b.Debit
+		entries[n-1].Kredit += b.Kredit
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"start_date": args["start"],
+		"end_date":   args["end"],
+		"entries":    entries,
+		"page":       page,
+		"per_page":   perPage,
+		"total":      total,
+	})
+}
+
+// CreateJurnal - Jurnal manual. Body: tanggal, keterangan, detail [{akun_id, debit, kredit}];
+// total debit harus sama dengan total kredit.
+func CreateJurnal(c *gin.Context) {
+	var input struct {
+		Tanggal    string `json:"tanggal" binding:"required"`
+		Keterangan string `json:"keterangan"`
+		Detail     []struct {
+			AkunID uint `json:"akun_id" binding:"required"`
+			Debit  int  `json:"debit"`
+			Kredit int  `json:"kredit"`
+		} `json:"detail" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	tanggal, err := time.Parse("2006-01-02", input.Tanggal)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format"})
+		return
+	}
+	if len(input.Detail) < 2 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Jurnal minimal terdiri dari dua baris"})
+		return
+	}
+
+	jurnal := models.Jurnal{Tanggal: tanggal, Keterangan: strings.TrimSpace(input.Keterangan)}
+	debit, kredit := 0, 0
+	for i, d := range input.Detail {
+		if d.Debit < 0 || d.Kredit < 0 || (d.Debit > 0) == (d.Kredit > 0) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Baris " + strconv.Itoa(i+1) + ": isi salah satu dari debit atau kredit"})
+			return
+		}
+		if err := database.DB.First(&models.Akun{}, d.AkunID).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Baris " + strconv.Itoa(i+1) + ": akun not found"})
+			return
+		}
+		debit += d.Debit
+		kredit += d.Kredit
+		jurnal.Detail = append(jurnal.Detail, models.JurnalDetail{AkunID: d.AkunID, Debit: d.Debit, Kredit: d.Kredit})
+	}
+	if debit != kredit {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Total debit dan kredit tidak seimbang", "debit": debit, "kredit": kredit})
+		return
+	}
+
+	if err := database.DB.Create(&jurnal).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create jurnal"})
+		return
+	}
+	database.DB.Preload("Detail.Akun").First(&jurnal, jurnal.ID)
+	c.JSON(http.StatusCreated, jurnal)
+}
+
+// DeleteJurnal - Hapus jurnal manual. Jurnal otomatis mengikuti data sumbernya.
+func DeleteJurnal(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&models.Jurnal{}, id).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("jurnal_id = ?", id).Delete(&models.JurnalDetail{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.Jurnal{}, id).Error
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Jurnal not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete jurnal"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Jurnal deleted"})
+}
+
+// saldoAkun - Total debit/kredit per akun dalam rentang tanggal (start kosong = sejak awal)
+func saldoAkun(start, end string) ([]SaldoAkun, error) {
+	args := map[string]interface{}{"start": start, "end": end}
+	var saldo []SaldoAkun
+	err := database.DB.Raw(`
+		WITH jurnal AS (`+jurnalQuery+`
+		)
+		SELECT a.id AS akun_id, a.kode, a.nama, a.tipe,
+			COALESCE(SUM(j.debit), 0) AS debit, COALESCE(SUM(j.kredit), 0) AS kredit
+		FROM akuns a
+		LEFT JOIN jurnal j ON j.akun_id = a.id
+			AND (@start = '' OR j.tanggal >= CAST(NULLIF(@start, '') AS date)) AND j.tanggal <= CAST(@end AS date)
+		WHERE a.deleted_at IS NULL
+		GROUP BY a.id, a.kode, a.nama, a.tipe
+		ORDER BY a.kode`, args).Scan(&saldo).Error
+	for i := range saldo {
+		saldo[i].Saldo = saldoNormal(saldo[i].Tipe, saldo[i].Debit, saldo[i].Kredit)
+	}
+	return saldo, err
+}
+
+// saldoNormal - Aset & beban bersaldo normal debit, selainnya kredit
+func saldoNormal(tipe string, debit, kredit int) int {
+	if tipe == "aset" || tipe == "beban" {
+		return debit - kredit
+	}
+	return kredit - debit
+}
+
+// GetNeracaSaldo - Neraca saldo (trial balance) per tanggal. Query: tanggal, format (csv|xlsx)
+func GetNeracaSaldo(c *gin.Context) {
+	asOf, ok := asOfDate(c)
+	if !ok {
+		return
+	}
+	saldo, err := saldoAkun("", asOf.Format("2006-01-02"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build neraca saldo"})
+		return
+	}
+
+	table := services.ExportTable{
+		Title: "Neraca Saldo",
+		Columns: []services.ExportColumn{
+			{Header: "Kode"}, {Header: "Akun"}, {Header: "Debit", Rupiah: true}, {Header: "Kredit", Rupiah: true},
+		},
+	}
+	totalDebit, totalKredit := 0, 0
+	for _, s := range saldo {
+		// Neraca saldo menampilkan saldo bersih di sisi normalnya
+		debit, kredit := 0, 0
+		if s.Debit >= s.Kredit {
+			debit = s.Debit - s.Kredit
+		} else {
+			kredit = s.Kredit - s.Debit
+		}
+		totalDebit += debit
+		totalKredit += kredit
+		table.AddRow(s.Kode, s.Nama, debit, kredit)
+	}
+	table.AddRow("", "TOTAL", totalDebit, totalKredit)
+	if respondExport(c, "neraca-saldo-"+asOf.Format("20060102"), table) {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"tanggal":      asOf.Format("2006-01-02"),
+		"akun":         saldo,
+		"total_debit":  totalDebit,
+		"total_kredit": totalKredit,
+		"seimbang":     totalDebit == totalKredit,
+	})
+}
+
+// GetLabaRugi - Laporan laba rugi per akun dengan rincian kategori.
+// Query: start_date, end_date (default awal tahun s/d hari ini), format (csv|xlsx)
+func GetLabaRugi(c *gin.Context) {
+	start, end, ok := periodeLaporan(c)
+	if !ok {
+		return
+	}
+	args := map[string]interface{}{"start": start.Format("2006-01-02"), "end": end.Format("2006-01-02")}
+
+	var rows []struct {
+		AkunID   uint
+		Kode     string
+		Nama     string
+		Tipe     string
+		Kategori string
+		Debit    int
+		Kredit   int
+	}
+	if err := database.DB.Raw(`
+		WITH jurnal AS (`+jurnalQuery+`
+		)
+		SELECT akun_id, akun_kode AS kode, akun_nama AS nama, akun_tipe AS tipe, kategori,
+			SUM(debit) AS debit, SUM(kredit) AS kredit
+		FROM jurnal
+		WHERE akun_tipe IN ('pendapatan', 'beban') AND tanggal BETWEEN CAST(@start AS date) AND CAST(@end AS date)
+		GROUP BY akun_id, akun_kode, akun_nama, akun_tipe, kategori
+		ORDER BY akun_kode, kategori`, args).Scan(&rows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build laba rugi"})
+		return
+	}
+
+	pendapatan, beban := []SaldoAkun{}, []SaldoAkun{}
+	for _, r := range rows {
+		list := &pendapatan
+		if r.Tipe == "beban" {
+			list = &beban
+		}
+		n := len(*list)
+		if n == 0 || (*list)[n-1].AkunID != r.AkunID {
+			*list = append(*list, SaldoAkun{AkunID: r.AkunID, Kode: r.Kode, Nama: r.Nama, Tipe: r.Tipe})
+			n++
+		}
+		akun := &(*list)[n-1]
+		akun.Debit += r.Debit
+		akun.Kredit += r.Kredit
+		akun.Saldo = saldoNormal(r.Tipe, akun.Debit, akun.Kredit)
+		nama := r.Kategori
+		if nama == "" {
+			nama = "Jurnal manual"
+		}
+		akun.Rincian = append(akun.Rincian, SaldoAkun{Nama: nama, Tipe: r.Tipe, Debit: r.Debit, Kredit: r.Kredit, Saldo: saldoNormal(r.Tipe, r.Debit, r.Kredit)})
+	}
+
+	totalPendapatan, totalBeban := 0, 0
+	table := services.ExportTable{
+		Title:   "Laba Rugi",
+		Columns: []services.ExportColumn{{Header: "Kode"}, {Header: "Akun"}, {Header: "Rincian"}, {Header: "Jumlah", Rupiah: true}},
+	}
+	for _, a := range pendapatan {
+		totalPendapatan += a.Saldo
+		for _, r := range a.Rincian {
+			table.AddRow(a.Kode, a.Nama, r.Nama, r.Saldo)
+		}
+	}
+	table.AddRow("", "TOTAL PENDAPATAN", "", totalPendapatan)
+	for _, a := range beban {
+		totalBeban += a.Saldo
+		for _, r := range a.Rincian {
+			table.AddRow(a.Kode, a.Nama, r.Nama, r.Saldo)
+		}
+	}
+	table.AddRow("", "TOTAL BEBAN", "", totalBeban)
+	table.AddRow("", "LABA BERSIH", "", totalPendapatan-totalBeban)
+	if respondExport(c, "laba-rugi-"+start.Format("20060102")+"-"+end.Format("20060102"), table) {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"start_date":       args["start"],
+		"end_date":         args["end"],
+		"pendapatan":       pendapatan,
+		"beban":            beban,
+		"total_pendapatan": totalPendapatan,
+		"total_beban":      totalBeban,
+		"laba_bersih":      totalPendapatan - totalBeban,
+	})
+}
+
+// GetNeraca - Neraca (balance sheet) per tanggal. Laba yang belum ditutup ke modal
+// ditampilkan sebagai Laba Ditahan pada ekuitas. Query: tanggal, format (csv|xlsx)
+func GetNeraca(c *gin.Context) {
+	asOf, ok := asOfDate(c)
+	if !ok {
+		return
+	}
+	saldo, err := saldoAkun("", asOf.Format("2006-01-02"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build neraca"})
+		return
+	}
+
+	aset, kewajiban, ekuitas := []SaldoAkun{}, []SaldoAkun{}, []SaldoAkun{}
+	totalAset, totalKewajiban, totalEkuitas, laba := 0, 0, 0, 0
+	for _, s := range saldo {
+		switch s.Tipe {
+		case "aset":
+			aset = append(aset, s)
+			totalAset += s.Saldo
+		case "kewajiban":
+			kewajiban = append(kewajiban, s)
+			totalKewajiban += s.Saldo
+		case "ekuitas":
+			ekuitas = append(ekuitas, s)
+			totalEkuitas += s.Saldo
+		case "pendapatan":
+			laba += s.Saldo
+		case "beban":
+			laba -= s.Saldo
+		}
+	}
+	ekuitas = append(ekuitas, SaldoAkun{Nama: "Laba Ditahan", Tipe: "ekuitas", Saldo: laba})
+	totalEkuitas += laba
+
+	table := services.ExportTable{
+		Title:   "Neraca",
+		Columns: []services.ExportColumn{{Header: "Kelompok"}, {Header: "Kode"}, {Header: "Akun"}, {Header: "Saldo", Rupiah: true}},
+	}
+	for _, group := range []struct {
+		nama  string
+		akun  []SaldoAkun
+		total int
+	}{{"Aset", aset, totalAset}, {"Kewajiban", kewajiban, totalKewajiban}, {"Ekuitas", ekuitas, totalEkuitas}} {
+		for _, a := range group.akun {
+			table.AddRow(group.nama, a.Kode, a.Nama, a.Saldo)
+		}
+		table.AddRow(group.nama, "", "TOTAL "+strings.ToUpper(group.nama), group.total)
+	}
+	if respondExport(c, "neraca-"+asOf.Format("20060102"), table) {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"tanggal":                 asOf.Format("2006-01-02"),
+		"aset":                    aset,
+		"kewajiban":               kewajiban,
+		"ekuitas":                 ekuitas,
+		"total_aset":              totalAset,
+		"total_kewajiban":         totalKewajiban,
+		"total_ekuitas":           totalEkuitas,
+		"total_kewajiban_ekuitas": totalKewajiban + totalEkuitas,
+		"seimbang":                totalAset == totalKewajiban+totalEkuitas,
+	})
+}
+
+// periodeLaporan - Baca start_date & end_date (YYYY-MM-DD), default awal tahun s/d hari ini
+func periodeLaporan(c *gin.Context) (time.Time, time.Time, bool) {
+	end := today()
+	var err error
+	if value := c.Query("end_date"); value != "" {
+		if end, err = time.Parse("2006-01-02", value); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid end_date format"})
+			return end, end, false
+		}
+	}
+	start := time.Date(end.Year(), 1, 1, 0, 0, 0, 0, time.UTC)
+	if value := c.Query("start_date"); value != "" {
+		if start, err = time.Parse("2006-01-02", value); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid start_date format"})
+			return start, end, false
+		}
+	}
+	if end.Before(start) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "end_date must not be before start_date"})
+		return start, end, false
+	}
+	return start, end, true
+}
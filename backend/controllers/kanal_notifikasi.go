@@ -0,0 +1,432 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"kos-muhandis/backend/database"
+	"kos-muhandis/backend/models"
+	"kos-muhandis/backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+var kanalTersedia = []string{"whatsapp", "email", "sms", "push"}
+
+// whatsAppChannel - Kanal WhatsApp lewat SendViaWhatsApp
+type whatsAppChannel struct{}
+
+func (whatsAppChannel) Name() string { return "whatsapp" }
+
+func (whatsAppChannel) Send(to string, msg services.NotificationMessage) (string, error) {
+	result := SendViaWhatsApp(to, msg.Body)
+	if !result.Success {
+		return "", errors.New(result.Error)
+	}
+	return result.MessageID, nil
+}
+
+// kanalNotifikasi - Implementasi kanal sesuai nama
+func kanalNotifikasi(nama string) (services.Channel, error) {
+	if nama == "whatsapp" {
+		return whatsAppChannel{}, nil
+	}
+	return services.NewChannel(nama)
+}
+
+// tujuanNotifikasi - Alamat tujuan satu kanal. Alamat dikirim ke kanal, Label disimpan
+// di riwayat pengiriman (untuk push: endpoint, bukan kuncinya).
+type tujuanNotifikasi struct {
+	Alamat string
+	Label  string
+	PushID uint
+}
+
+// GetKanalNotifikasi - Urutan kanal notifikasi penyewa dan status tiap kanal
+// (aktif = sudah dikonfigurasi di server, punya_tujuan = penyewa punya alamatnya)
+func GetKanalNotifikasi(c *gin.Context) {
+	var penyewa models.Penyewa
+	if err := database.DB.First(&penyewa, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Penyewa not found"})
+		return
+	}
+	var tersedia []gin.H
+	for _, kanal := range kanalTersedia {
+		_, err := kanalNotifikasi(kanal)
+		tersedia = append(tersedia, gin.H{
+			"kanal":        kanal,
+			"aktif":        err == nil,
+			"punya_tujuan": len(tujuanKanal(penyewa, kanal)) > 0,
+		})
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"penyewa_id":       penyewa.ID,
+		"kanal":            urutanKanal(penyewa.KanalNotifikasi),
+		"tersedia":         tersedia,
+		"berhenti_pada":    penyewa.BerhentiPada,
+		"kiriman_hari_ini": kirimanHariIni(penyewa.ID),
+		"batas_harian":     batasHarian(),
+	})
+}
+
+// UpdateKanalNotifikasi - Atur urutan kanal notifikasi penyewa. Body: kanal (e.g. ["email", "whatsapp"]);
+// kanal berikutnya dipakai bila kanal sebelumnya gagal atau penyewa tidak punya alamatnya.
+func UpdateKanalNotifikasi(c *gin.Context) {
+	var input struct {
+		Kanal []string `json:"kanal" binding:"required,min=1"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	seen := map[string]bool{}
+	for _, kanal := range input.Kanal {
+		if !dikenalKanal(kanal) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Kanal tidak dikenal: " + kanal})
+			return
+		}
+		if seen[kanal] {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Kanal ganda: " + kanal})
+			return
+		}
+		seen[kanal] = true
+	}
+
+	var penyewa models.Penyewa
+	if err := database.DB.First(&penyewa, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Penyewa not found"})
+		return
+	}
+	if err := database.DB.Model(&penyewa).Update("kanal_notifikasi", strings.Join(input.Kanal, ",")).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update kanal notifikasi"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"penyewa_id": penyewa.ID, "kanal": input.Kanal})
+}
+
+// KirimNotifikasi - Kirim satu notifikasi lewat kanal penyewa sesuai urutan fallback.
+// ?paksa=true melewati jam tenang dan batas harian.
+func KirimNotifikasi(c *gin.Context) {
+	var notif models.Notifikasi
+	if err := database.DB.Preload("Penyewa").Preload("Tagihan").First(&notif, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Notifikasi not found"})
+		return
+	}
+	pengiriman, err := kirimNotifikasi([]*models.Notifikasi{&notif}, c.Query("paksa") == "true")
+	switch {
+	case errors.Is(err, errTidakTerkirim):
+		c.JSON(http.StatusBadGateway, gin.H{"terkirim": false, "notifikasi": notif, "pengiriman": pengiriman})
+	case err != nil:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "terkirim": false})
+	default:
+		c.JSON(http.StatusOK, gin.H{"terkirim": true, "notifikasi": notif, "pengiriman": pengiriman})
+	}
+}
+
+// GetPengirimanNotifikasi - Riwayat pengiriman notifikasi per kanal
+func GetPengirimanNotifikasi(c *gin.Context) {
+	var pengiriman []models.PengirimanNotifikasi
+	if err := database.DB.Where("notifikasi_id = ?", c.Param("id")).Order("created_at ASC, id ASC").Find(&pengiriman).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch pengiriman"})
+		return
+	}
+	c.JSON(http.StatusOK, pengiriman)
+}
+
+// GetLinkPush - Token bertanda tangan untuk halaman penyewa mendaftarkan web push
+func GetLinkPush(c *gin.Context) {
+	var penyewa models.Penyewa
+	if err := database.DB.First(&penyewa, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Penyewa not found"})
+		return
+	}
+	token, err := signDokumen("push", strconv.Itoa(int(penyewa.ID)))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"penyewa_id":       penyewa.ID,
+		"token":            token,
+		"vapid_public_key": os.Getenv("VAPID_PUBLIC_KEY"),
+	})
+}
+
+// GetVAPIDPublicKey - Kunci publik VAPID untuk pushManager.subscribe di browser (publik)
+func GetVAPIDPublicKey(c *gin.Context) {
+	key := os.Getenv("VAPID_PUBLIC_KEY")
+	if key == "" {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Web push is not configured"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"public_key": key})
+}
+
+// SubscribePush - Simpan PushSubscription browser penyewa lewat link bertanda tangan (publik).
+// Body: hasil PushSubscription.toJSON(). Endpoint yang sama diperbarui.
+func SubscribePush(c *gin.Context) {
+	penyewaID, ok := penyewaPush(c)
+	if !ok {
+		return
+	}
+	var input services.PushSubscription
+	if err := c.ShouldBindJSON(&input); err != nil || !strings.HasPrefix(input.Endpoint, "https://") ||
+		input.Keys.P256dh == "" || input.Keys.Auth == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid push subscription"})
+		return
+	}
+
+	var sub models.PushSubscription
+	database.DB.Where("endpoint = ?", input.Endpoint).First(&sub)
+	sub.PenyewaID = penyewaID
+	sub.Endpoint = input.Endpoint
+	sub.P256dh = input.Keys.P256dh
+	sub.Auth = input.Keys.Auth
+	sub.UserAgent = c.Request.UserAgent()
+	if err := database.DB.Save(&sub).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save push subscription"})
+		return
+	}
+	c.JSON(http.StatusCreated, sub)
+}
+
+// UnsubscribePush - Hapus langganan web push penyewa (publik, link bertanda tangan). Body: endpoint.
+func UnsubscribePush(c *gin.Context) {
+	penyewaID, ok := penyewaPush(c)
+	if !ok {
+		return
+	}
+	var input struct {
+		Endpoint string `json:"endpoint" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	database.DB.Where("penyewa_id = ? AND endpoint = ?", penyewaID, input.Endpoint).Delete(&models.PushSubscription{})
+	c.JSON(http.StatusOK, gin.H{"message": "Push subscription deleted"})
+}
+
+// penyewaPush - Validasi token link push dan keberadaan penyewa
+func penyewaPush(c *gin.Context) (uint, bool) {
+	id := c.Param("id")
+	if !cekTokenDokumen("push", id, c.Query("token")) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Invalid or expired token"})
+		return 0, false
+	}
+	var penyewa models.Penyewa
+	if err := database.DB.First(&penyewa, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Penyewa not found"})
+		return 0, false
+	}
+	return penyewa.ID, true
+}
+
+var (
+	errTidakTerkirim = errors.New("Notifikasi gagal dikirim lewat semua kanal")
+	errTagihanLunas  = errors.New("Tagihan sudah lunas, eskalasi dihentikan")
+)
+
+// kirimNotifikasi - Coba kanal penyewa berurutan sampai satu berhasil; setiap percobaan dicatat.
+// daftar berisi notifikasi satu penyewa dan satu penerima; lebih dari satu digabung menjadi satu
+// pesan (template GABUNGAN) dengan id kiriman yang sama. Penerima dan urutan kanal mengikuti
+// langkah eskalasi yang membuat notifikasi; notifikasi yang tagihannya sudah lunas dilewati. Pengiriman dicatat queued sebelum dikirim lalu
+// sent/failed; status selanjutnya (delivered, read) datang dari callback provider lewat id
+// pesan yang disimpan. paksa melewati jam tenang dan batas harian, tetapi tidak STOP.
+// Penyewa (beserta Kamar) dan Tagihan setiap notifikasi harus sudah di-preload.
+func kirimNotifikasi(daftar []*models.Notifikasi, paksa bool) ([]models.PengirimanNotifikasi, error) {
+	// Eskalasi berhenti begitu tagihan lunas
+	belumLunas := daftar[:0:0]
+	for _, notif := range daftar {
+		if !tagihanLunas(notif.Tagihan) {
+			belumLunas = append(belumLunas, notif)
+		}
+	}
+	if len(belumLunas) == 0 {
+		return nil, errTagihanLunas
+	}
+	daftar = belumLunas
+
+	utama := daftar[0]
+	penyewa := utama.Penyewa
+	penerima := utama.Penerima
+	if penerima == "" {
+		penerima = "penyewa"
+	}
+	if err := izinNotifikasi(penerima, penyewa, paksa); err != nil {
+		return nil, err
+	}
+	kolomKanal := utama.Kanal
+	switch {
+	case kolomKanal != "":
+	case penerima == "penyewa":
+		kolomKanal = penyewa.KanalNotifikasi
+	default:
+		kolomKanal = "whatsapp,email"
+	}
+	if penerima == "pemilik" {
+		penyewa.Bahasa = "id"
+	}
+
+	msg := services.NotificationMessage{
+		Subject: subjekNotifikasi(penyewa, utama.Tipe, utama.Tagihan.Bulan),
+		Body:    utama.Message,
+		URL:     linkBuktiPembayaran(utama.TagihanID),
+	}
+	if len(daftar) > 1 {
+		tagihan := make([]models.Tagihan, 0, len(daftar))
+		tipe := ""
+		for _, notif := range daftar {
+			tagihan = append(tagihan, notif.Tagihan)
+			if notif.Tipe == "OVERDUE" {
+				tipe = notif.Tipe
+			}
+		}
+		msg.Subject = subjekNotifikasi(penyewa, tipe, "")
+		msg.Body = pesanGabungan(penyewa, tagihan)
+	}
+
+	kiriman := idKiriman(penyewa.ID)
+	var riwayat []models.PengirimanNotifikasi
+	catat := func(kanal, tujuan, status, pesanError string) []models.PengirimanNotifikasi {
+		baris := make([]models.PengirimanNotifikasi, 0, len(daftar))
+		for _, notif := range daftar {
+			p := models.PengirimanNotifikasi{NotifikasiID: notif.ID, Kiriman: kiriman, Kanal: kanal, Tujuan: tujuan, Status: status, Error: pesanError}
+			database.DB.Create(&p)
+			baris = append(baris, p)
+		}
+		return baris
+	}
+
+	for _, kanal := range urutanKanal(kolomKanal) {
+		ch, err := kanalNotifikasi(kanal)
+		if err != nil {
+			riwayat = append(riwayat, catat(kanal, "", "skipped", err.Error())...)
+			continue
+		}
+		tujuanList := tujuanPenerima(penerima, penyewa, kanal)
+		if len(tujuanList) == 0 {
+			riwayat = append(riwayat, catat(kanal, "", "skipped", "Penerima tidak punya tujuan untuk kanal ini")...)
+			continue
+		}
+		berhasil := false
+		for _, tujuan := range tujuanList {
+			baris := catat(kanal, tujuan.Label, services.DeliveryQueued, "")
+			msg.ReceiptURL = linkStatusPengiriman(baris[0].ID)
+
+			providerID, err := ch.Send(tujuan.Alamat, msg)
+			if errors.Is(err, services.ErrSubscriptionGone) {
+				database.DB.Delete(&models.PushSubscription{}, tujuan.PushID)
+			}
+			now := time.Now()
+			for i := range baris {
+				p := &baris[i]
+				if err != nil {
+					p.Status = services.DeliveryFailed
+					p.Error = err.Error()
+				} else {
+					p.Status = services.DeliverySent
+					p.ProviderID = providerID
+					p.DikirimPada = &now
+				}
+				database.DB.Model(p).Updates(map[string]interface{}{
+					"status":       p.Status,
+					"provider_id":  p.ProviderID,
+					"error":        p.Error,
+					"dikirim_pada": p.DikirimPada,
+				})
+			}
+			berhasil = berhasil || err == nil
+			riwayat = append(riwayat, baris...)
+		}
+		if berhasil {
+			now := time.Now()
+			for _, notif := range daftar {
+				notif.Status = services.DeliverySent
+				notif.SentAt = &now
+				database.DB.Model(notif).Updates(map[string]interface{}{"status": notif.Status, "sent_at": now})
+			}
+			return riwayat, nil
+		}
+	}
+	return riwayat, errTidakTerkirim
+}
+
+// tujuanKanal - Alamat penyewa untuk satu kanal; push bisa lebih dari satu browser
+func tujuanKanal(penyewa models.Penyewa, kanal string) []tujuanNotifikasi {
+	switch kanal {
+	case "whatsapp", "sms":
+		if penyewa.NoHP != nil && *penyewa.NoHP != "" {
+			noHP := nomorWhatsApp(*penyewa.NoHP)
+			return []tujuanNotifikasi{{Alamat: noHP, Label: noHP}}
+		}
+	case "email":
+		if penyewa.Email != nil && *penyewa.Email != "" {
+			return []tujuanNotifikasi{{Alamat: *penyewa.Email, Label: *penyewa.Email}}
+		}
+	case "push":
+		var subs []models.PushSubscription
+		database.DB.Where("penyewa_id = ?", penyewa.ID).Order("id ASC").Find(&subs)
+		var daftar []tujuanNotifikasi
+		for _, s := range subs {
+			var sub services.PushSubscription
+			sub.Endpoint = s.Endpoint
+			sub.Keys.P256dh = s.P256dh
+			sub.Keys.Auth = s.Auth
+			alamat, _ := json.Marshal(sub)
+			daftar = append(daftar, tujuanNotifikasi{Alamat: string(alamat), Label: s.Endpoint, PushID: s.ID})
+		}
+		return daftar
+	}
+	return nil
+}
+
+// urutanKanal - Kanal dari kolom kanal_notifikasi ("whatsapp,email"), default whatsapp
+func urutanKanal(kolom string) []string {
+	var urutan []string
+	for _, kanal := range strings.Split(kolom, ",") {
+		if kanal = strings.TrimSpace(kanal); dikenalKanal(kanal) {
+			urutan = append(urutan, kanal)
+		}
+	}
+	if len(urutan) == 0 {
+		return []string{"whatsapp"}
+	}
+	return urutan
+}
+
+func dikenalKanal(kanal string) bool {
+	for _, k := range kanalTersedia {
+		if k == kanal {
+			return true
+		}
+	}
+	return false
+}
+
+// subjekNotifikasi - Judul email / push dalam bahasa penyewa; bulan kosong untuk pesan gabungan
+func subjekNotifikasi(penyewa models.Penyewa, tipe, bulan string) string {
+	namaKos := os.Getenv("APP_NAME")
+	if namaKos == "" {
+		namaKos = "Kos Muhandis"
+	}
+	bahasa := bahasaPenyewa(penyewa)
+	judul := "Pengingat tagihan"
+	switch {
+	case bahasa == "en" && tipe == "OVERDUE":
+		judul = "Overdue bill"
+	case bahasa == "en":
+		judul = "Bill reminder"
+	case tipe == "OVERDUE":
+		judul = "Tagihan tertunggak"
+	}
+	if bulan == "" {
+		return namaKos + " - " + judul
+	}
+	return namaKos + " - " + judul + " " + services.NamaBulanBahasa(bulan, bahasa)
+}
@@ -0,0 +1,337 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"kos-muhandis/backend/database"
+	"kos-muhandis/backend/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+var errKategoriJenis = errors.New("Jenis kategori tidak sesuai dengan jenis transaksi")
+
+// GetKategori - Pohon kategori pemasukan/pengeluaran. Query: jenis, flat=true untuk daftar datar
+func GetKategori(c *gin.Context) {
+	query := database.DB.Order("nama ASC")
+	if jenis := strings.ToLower(c.Query("jenis")); jenis != "" {
+		query = query.Where("jenis = ?", jenis)
+	}
+	var kategori []models.Kategori
+	if err := query.Find(&kategori).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch kategori"})
+		return
+	}
+	if c.Query("flat") == "true" {
+		c.JSON(http.StatusOK, kategori)
+		return
+	}
+	c.JSON(http.StatusOK, kategoriTree(kategori))
+}
+
+// kategoriTree - Susun daftar kategori menjadi pohon berdasarkan parent_id
+func kategoriTree(list []models.Kategori) []models.Kategori {
+	children := make(map[uint][]models.Kategori)
+	ids := make(map[uint]bool)
+	for _, k := range list {
+		ids[k.ID] = true
+	}
+	var roots []models.Kategori
+	for _, k := range list {
+		if k.ParentID != nil && ids[*k.ParentID] {
+			children[*k.ParentID] = append(children[*k.ParentID], k)
+		} else {
+			roots = append(roots, k)
+		}
+	}
+	var build func(k models.Kategori) models.Kategori
+	build = func(k models.Kategori) models.Kategori {
+		for _, child := range children[k.ID] {
+			k.Children = append(k.Children, build(child))
+		}
+		return k
+	}
+	result := make([]models.Kategori, 0, len(roots))
+	for _, k := range roots {
+		result = append(result, build(k))
+	}
+	return result
+}
+
+// CreateKategori - Tambah kategori, transaksi lama dengan nama/alias yang sama ikut ditautkan
+func CreateKategori(c *gin.Context) {
+	var input struct {
+		Nama     string `json:"nama" binding:"required"`
+		Jenis    string `json:"jenis" binding:"required"`
+		ParentID *uint  `json:"parent_id"`
+		Alias    string `json:"alias"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	kategori := models.Kategori{
+		Nama:     strings.TrimSpace(input.Nama),
+		Jenis:    strings.ToLower(input.Jenis),
+		ParentID: input.ParentID,
+		Alias:    input.Alias,
+	}
+	if msg := validateKategori(kategori); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&kategori).Error; err != nil {
+			return err
+		}
+		return normalisasiTransaksi(tx, kategori)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create kategori"})
+		return
+	}
+	c.JSON(http.StatusCreated, kategori)
+}
+
+// UpdateKategori - Ubah nama, parent atau alias kategori
+func UpdateKategori(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	var kategori models.Kategori
+	if err := database.DB.First(&kategori, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Kategori not found"})
+		return
+	}
+	var input struct {
+		Nama     string  `json:"nama"`
+		ParentID *uint   `json:"parent_id"`
+		Alias    *string `json:"alias"`
+		Root     bool    `json:"root"` // true = jadikan kategori utama (hapus parent)
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if input.Nama != "" {
+		kategori.Nama = strings.TrimSpace(input.Nama)
+	}
+	if input.ParentID != nil {
+		kategori.ParentID = input.ParentID
+	}
+	if input.Root {
+		kategori.ParentID = nil
+	}
+	if input.Alias != nil {
+		kategori.Alias = *input.Alias
+	}
+	if msg := validateKategori(kategori); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&kategori).Error; err != nil {
+			return err
+		}
+		// Nama kategori pada transaksi selalu mengikuti nama kategori terbaru
+		if err := tx.Model(&models.Transaksi{}).Where("kategori_id = ?", kategori.ID).Update("kategori", kategori.Nama).Error; err != nil {
+			return err
+		}
+		return normalisasiTransaksi(tx, kategori)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update kategori"})
+		return
+	}
+	c.JSON(http.StatusOK, kategori)
+}
+
+// DeleteKategori - Hapus kategori tanpa sub kategori. Transaksi tetap menyimpan nama kategorinya.
+func DeleteKategori(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	var children int64
+	database.DB.Model(&models.Kategori{}).Where("parent_id = ?", id).Count(&children)
+	if children > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Kategori masih memiliki sub kategori"})
+		return
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Transaksi{}).Where("kategori_id = ?", id).Update("kategori_id", nil).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.Kategori{}, id).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete kategori"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Kategori deleted"})
+}
+
+// validateKategori - Cek jenis, parent (jenis sama, tidak membentuk siklus) dan nama unik
+func validateKategori(k models.Kategori) string {
+	if k.Nama == "" {
+		return "Nama is required"
+	}
+	if k.Jenis != "pemasukan" && k.Jenis != "pengeluaran" {
+		return "Jenis must be pemasukan or pengeluaran"
+	}
+
+	parentID := k.ParentID
+	for depth := 0; parentID != nil; depth++ {
+		if (k.ID != 0 && *parentID == k.ID) || depth > 20 {
+			return "Parent kategori membentuk siklus"
+		}
+		var parent models.Kategori
+		if err := database.DB.First(&parent, *parentID).Error; err != nil {
+			return "Parent kategori not found"
+		}
+		if parent.Jenis != k.Jenis {
+			return "Parent kategori harus memiliki jenis yang sama"
+		}
+		parentID = parent.ParentID
+	}
+
+	var count int64
+	database.DB.Model(&models.Kategori{}).
+		Where("jenis = ? AND LOWER(nama) = LOWER(?) AND id <> ?", k.Jenis, k.Nama, k.ID).
+		Count(&count)
+	if count > 0 {
+		return "Kategori dengan nama yang sama sudah ada"
+	}
+	return ""
+}
+
+// kategoriNames - Nama dan alias kategori dalam huruf kecil untuk pencocokan teks bebas
+func kategoriNames(k models.Kategori) []string {
+	names := []string{strings.ToLower(strings.TrimSpace(k.Nama))}
+	for _, alias := range strings.Split(k.Alias, ",") {
+		if alias = strings.ToLower(strings.TrimSpace(alias)); alias != "" {
+			names = append(names, alias)
+		}
+	}
+	return names
+}
+
+// normalisasiTransaksi - Tautkan transaksi berkategori teks bebas yang cocok dengan nama/alias
+// kategori, dan seragamkan teks kategorinya
+func normalisasiTransaksi(tx *gorm.DB, k models.Kategori) error {
+	return tx.Model(&models.Transaksi{}).
+		Where("kategori_id IS NULL AND LOWER(jenis) = ? AND LOWER(TRIM(kategori)) IN ?", k.Jenis, kategoriNames(k)).
+		Updates(map[string]interface{}{"kategori_id": k.ID, "kategori": k.Nama}).Error
+}
+
+// resolveKategori - Cari kategori untuk transaksi dari kategori_id atau teks kategori (nama/alias,
+// tanpa membedakan huruf besar). Mengembalikan nil bila teks tidak cocok dengan kategori mana pun.
+func resolveKategori(jenis string, kategoriID *uint, nama string) (*models.Kategori, error) {
+	jenis = strings.ToLower(jenis)
+	if kategoriID != nil {
+		var kategori models.Kategori
+		if err := database.DB.First(&kategori, *kategoriID).Error; err != nil {
+			return nil, err
+		}
+		if kategori.Jenis != jenis {
+			return nil, errKategoriJenis
+		}
+		return &kategori, nil
+	}
+
+	nama = strings.ToLower(strings.TrimSpace(nama))
+	if nama == "" {
+		return nil, nil
+	}
+	var list []models.Kategori
+	if err := database.DB.Where("jenis = ?", jenis).Find(&list).Error; err != nil {
+		return nil, err
+	}
+	for i := range list {
+		for _, name := range kategoriNames(list[i]) {
+			if name == nama {
+				return &list[i], nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+// KategoriReport - Total transaksi per kategori, termasuk total sub kategorinya
+type KategoriReport struct {
+	KategoriID *uint  `json:"kategori_id"`
+	Nama       string `json:"nama"`
+	Jenis      string `json:"jenis"`
+	ParentID   *uint  `json:"parent_id"`
+	Langsung   int    `json:"langsung"` // transaksi yang ditautkan langsung ke kategori ini
+	Total      int    `json:"total"`    // termasuk seluruh sub kategori
+	Jumlah     int    `json:"jumlah_transaksi"`
+}
+
+// GetKategoriReport - Rekap transaksi per kategori (dengan rollup ke parent) dalam rentang tanggal.
+// Transaksi yang belum bertaut kategori dikelompokkan per teks kategori (tanpa beda huruf besar).
+// Query: start_date, end_date (YYYY-MM-DD, default tahun berjalan), jenis.
+func GetKategoriReport(c *gin.Context) {
+	end := today()
+	var err error
+	if value := c.Query("end_date"); value != "" {
+		if end, err = time.Parse("2006-01-02", value); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid end_date format"})
+			return
+		}
+	}
+	start := time.Date(end.Year(), 1, 1, 0, 0, 0, 0, time.UTC)
+	if value := c.Query("start_date"); value != "" {
+		if start, err = time.Parse("2006-01-02", value); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid start_date format"})
+			return
+		}
+	}
+	args := map[string]interface{}{
+		"start": start.Format("2006-01-02"),
+		"end":   end.Format("2006-01-02"),
+		"jenis": strings.ToLower(c.Query("jenis")),
+	}
+
+	var report []KategoriReport
+	if err := database.DB.Raw(`
+		WITH RECURSIVE tree AS (
+			SELECT id, id AS root FROM kategoris WHERE deleted_at IS NULL
+			UNION ALL
+			SELECT k.id, t.root FROM kategoris k JOIN tree t ON k.parent_id = t.id WHERE k.deleted_at IS NULL
+		),
+		transaksi AS (
+			SELECT * FROM transaksis
+			WHERE deleted_at IS NULL AND tanggal BETWEEN CAST(@start AS date) AND CAST(@end AS date)
+		)
+		SELECT k.id AS kategori_id, k.nama, k.jenis, k.parent_id,
+			COALESCE(SUM(x.jumlah) FILTER (WHERE x.kategori_id = k.id), 0) AS langsung,
+			COALESCE(SUM(x.jumlah), 0) AS total,
+			COUNT(x.id) AS jumlah
+		FROM kategoris k
+		JOIN tree t ON t.root = k.id
+		LEFT JOIN transaksi x ON x.kategori_id = t.id
+		WHERE k.deleted_at IS NULL AND (@jenis = '' OR k.jenis = @jenis)
+		GROUP BY k.id, k.nama, k.jenis, k.parent_id
+		UNION ALL
+		SELECT NULL, MIN(kategori), LOWER(jenis), NULL, SUM(jumlah), SUM(jumlah), COUNT(*)
+		FROM transaksi
+		WHERE kategori_id IS NULL AND (@jenis = '' OR LOWER(jenis) = @jenis)
+		GROUP BY LOWER(TRIM(kategori)), LOWER(jenis)
+		ORDER BY 3, 6 DESC`, args).Scan(&report).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build kategori report"})
+		return
+	}
+	if report == nil {
+		report = []KategoriReport{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"start_date": args["start"],
+		"end_date":   args["end"],
+		"kategori":   report,
+	})
+}
@@ -0,0 +1,293 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"kos-muhandis/backend/database"
+	"kos-muhandis/backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LedgerEntry - Satu baris buku kas (pembayaran tagihan atau transaksi)
+type LedgerEntry struct {
+	Sumber    string    `json:"sumber"` // tagihan, transaksi
+	RefID     uint      `json:"ref_id"` // id pembayaran/tagihan/transaksi
+	TagihanID *uint     `json:"tagihan_id,omitempty"`
+	Tanggal   time.Time `json:"tanggal"`
+	Jenis     string    `json:"jenis"` // pemasukan, pengeluaran
+	Kategori  string    `json:"kategori"`
+	Deskripsi string    `json:"deskripsi"`
+	Jumlah    int       `json:"jumlah"`
+	Saldo     int       `json:"saldo"` // saldo berjalan setelah baris ini (urut kronologis)
+}
+
+// LedgerSummary - Ringkasan buku kas untuk periode yang diminta
+type LedgerSummary struct {
+	StartDate        string `json:"start_date"`
+	EndDate          string `json:"end_date"`
+	Basis            string `json:"basis"`
+	SaldoAwal        int    `json:"saldo_awal"`
+	TotalPemasukan   int    `json:"total_pemasukan"`
+	TotalPengeluaran int    `json:"total_pengeluaran"`
+	SaldoAkhir       int    `json:"saldo_akhir"`
+}
+
+// ledgerQuery - Sumber data buku kas. Basis cash memakai seluruh transaksi (pembayaran tagihan
+// tercatat sebagai transaksi pemasukan tertaut), basis accrual memakai tagihan pada tanggal 1
+// periode tagihan ditambah transaksi yang bukan pembayaran tagihan.
+func ledgerQuery(basis string) string {
+	if basis == BasisAccrual {
+		return `
+		SELECT 'tagihan' AS sumber, t.id AS ref_id, t.id AS tagihan_id, TO_DATE(LEFT(t.bulan, 7), 'YYYY-MM') AS tanggal,
+			'pemasukan' AS jenis, t.jenis_tagihan AS kategori,
+			'Tagihan ' || t.jenis_tagihan || ' ' || LEFT(t.bulan, 7) || ' - ' || COALESCE(py.nama, '') AS deskripsi, t.jumlah
+		FROM tagihans t
+		LEFT JOIN penyewas py ON py.id = t.penyewa_id
+		WHERE t.deleted_at IS NULL
+		UNION ALL
+		SELECT 'transaksi', id, NULL, tanggal, LOWER(jenis), kategori, COALESCE(NULLIF(keterangan, ''), kategori), jumlah
+		FROM transaksis
+		WHERE deleted_at IS NULL AND pembayaran_id IS NULL`
+	}
+
+	return `
+		SELECT CASE WHEN pembayaran_id IS NULL THEN 'transaksi' ELSE 'tagihan' END AS sumber,
+			COALESCE(pembayaran_id, id) AS ref_id, tagihan_id, tanggal, LOWER(jenis) AS jenis, kategori,
+			COALESCE(NULLIF(keterangan, ''), kategori) AS deskripsi, jumlah
+		FROM transaksis
+		WHERE deleted_at IS NULL`
+}
+
+// GetLedgerReport - Buku kas gabungan pembayaran tagihan dan transaksi dalam rentang tanggal.
+// Query: start_date, end_date (wajib, YYYY-MM-DD), basis (accrual|cash, default cash),
+// jenis (pemasukan|pengeluaran), kategori (bisa dipisah koma), sumber (tagihan|transaksi),
+// sort (asc|desc), page, per_page.
+// Saldo awal, saldo berjalan dan saldo akhir dihitung dari baris yang lolos filter.
+func GetLedgerReport(c *gin.Context) {
+	startDate := c.Query("start_date")
+	endDate := c.Query("end_date")
+	if startDate == "" || endDate == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "start_date and end_date are required"})
+		return
+	}
+	start, err := time.Parse("2006-01-02", startDate)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid start_date format"})
+		return
+	}
+	end, err := time.Parse("2006-01-02", endDate)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid end_date format"})
+		return
+	}
+	if end.Before(start) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "end_date must not be before start_date"})
+		return
+	}
+
+	basis, ok := reportBasis(c, BasisCash)
+	if !ok {
+		return
+	}
+
+	sort := strings.ToLower(c.DefaultQuery("sort", "asc"))
+	if sort != "asc" && sort != "desc" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "sort must be asc or desc"})
+		return
+	}
+	page, perPage := pagination(c)
+
+	var filters []string
+	var args []interface{}
+	if jenis := strings.ToLower(c.Query("jenis")); jenis != "" {
+		filters = append(filters, "jenis = ?")
+		args = append(args, jenis)
+	}
+	if sumber := c.Query("sumber"); sumber != "" {
+		filters = append(filters, "sumber = ?")
+		args = append(args, sumber)
+	}
+	if kategori := c.Query("kategori"); kategori != "" {
+		var list []string
+		for _, k := range strings.Split(kategori, ",") {
+			if k = strings.TrimSpace(k); k != "" {
+				list = append(list, strings.ToLower(k))
+			}
+		}
+		filters = append(filters, "LOWER(kategori) IN ?")
+		args = append(args, list)
+	}
+	where := "TRUE"
+	if len(filters) > 0 {
+		where = strings.Join(filters, " AND ")
+	}
+
+	base := `
+		WITH ledger AS (` + ledgerQuery(basis) + `
+		),
+		filtered AS (
+			SELECT * FROM ledger WHERE ` + where + `
+		)`
+
+	endExclusive := end.AddDate(0, 0, 1).Format("2006-01-02")
+
+	var summary LedgerSummary
+	summaryArgs := append(append([]interface{}{}, args...), startDate, startDate, endExclusive, startDate, endExclusive)
+	if err := database.DB.Raw(base+`
+		SELECT
+			COALESCE(SUM(CASE WHEN jenis = 'pengeluaran' THEN -jumlah ELSE jumlah END) FILTER (WHERE tanggal < ?), 0) AS saldo_awal,
+			COALESCE(SUM(jumlah) FILTER (WHERE jenis <> 'pengeluaran' AND tanggal >= ? AND tanggal < ?), 0) AS total_pemasukan,
+			COALESCE(SUM(jumlah) FILTER (WHERE jenis = 'pengeluaran' AND tanggal >= ? AND tanggal < ?), 0) AS total_pengeluaran
+		FROM filtered`, summaryArgs...).Scan(&summary).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build ledger"})
+		return
+	}
+	summary.StartDate = startDate
+	summary.EndDate = endDate
+	summary.Basis = basis
+	summary.SaldoAkhir = summary.SaldoAwal + summary.TotalPemasukan - summary.TotalPengeluaran
+
+	var total int64
+	countArgs := append(append([]interface{}{}, args...), startDate, endExclusive)
+	if err := database.DB.Raw(base+`SELECT COUNT(*) FROM filtered WHERE tanggal >= ? AND tanggal < ?`, countArgs...).Scan(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build ledger"})
+		return
+	}
+
+	query := base + `,
+		berjalan AS (
+			SELECT *, SUM(CASE WHEN jenis = 'pengeluaran' THEN -jumlah ELSE jumlah END)
+				OVER (ORDER BY tanggal, sumber, ref_id ROWS UNBOUNDED PRECEDING) AS saldo
+			FROM filtered
+		)
+		SELECT * FROM berjalan
+		WHERE tanggal >= ? AND tanggal < ?
+		ORDER BY tanggal ` + sort + `, sumber ` + sort + `, ref_id ` + sort
+
+	entryArgs := append(append([]interface{}{}, args...), startDate, endExclusive)
+	var entries []LedgerEntry
+	if c.Query("format") != "" {
+		// Export selalu berisi seluruh baris periode, tanpa pagination
+		if err := database.DB.Raw(query, entryArgs...).Scan(&entries).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build ledger"})
+			return
+		}
+		table := services.ExportTable{
+			Title: "Buku Kas",
+			Columns: []services.ExportColumn{
+				{Header: "Tanggal"}, {Header: "Sumber"}, {Header: "Jenis"}, {Header: "Kategori"}, {Header: "Deskripsi"},
+				{Header: "Pemasukan", Rupiah: true}, {Header: "Pengeluaran", Rupiah: true}, {Header: "Saldo", Rupiah: true},
+			},
+		}
+		table.AddRow(startDate, "", "", "", "Saldo Awal", 0, 0, summary.SaldoAwal)
+		for _, e := range entries {
+			masuk, keluar := e.Jumlah, 0
+			if e.Jenis == "pengeluaran" {
+				masuk, keluar = 0, e.Jumlah
+			}
+			table.AddRow(e.Tanggal.Format("2006-01-02"), e.Sumber, e.Jenis, e.Kategori, e.Deskripsi, masuk, keluar, e.Saldo)
+		}
+		table.AddRow(endDate, "", "", "", "Saldo Akhir", summary.TotalPemasukan, summary.TotalPengeluaran, summary.SaldoAkhir)
+		respondExport(c, "buku-kas-"+basis+"-"+startDate+"-"+endDate, table)
+		return
+	}
+
+	entryArgs = append(entryArgs, perPage, (page-1)*perPage)
+	if err := database.DB.Raw(query+` LIMIT ? OFFSET ?`, entryArgs...).Scan(&entries).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build ledger"})
+		return
+	}
+	if entries == nil {
+		entries = []LedgerEntry{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"summary":  summary,
+		"entries":  entries,
+		"page":     page,
+		"per_page": perPage,
+		"total":    total,
+	})
+}
+
+// GetDetailReport - Bentuk lama /report/detail untuk klien yang sudah ada: array {tipe, deskripsi,
+// jumlah, tanggal} tanpa pagination, isinya dari buku kas. Query: start_date, end_date (wajib),
+// basis (accrual|cash, default accrual), format (csv|xlsx). Klien baru memakai /report/ledger.
+func GetDetailReport(c *gin.Context) {
+	startDate := c.Query("start_date")
+	endDate := c.Query("end_date")
+	if startDate == "" || endDate == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "start_date and end_date are required"})
+		return
+	}
+	start, err := time.Parse("2006-01-02", startDate)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid start_date format"})
+		return
+	}
+	end, err := time.Parse("2006-01-02", endDate)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid end_date format"})
+		return
+	}
+	basis, ok := reportBasis(c, BasisAccrual)
+	if !ok {
+		return
+	}
+
+	var entries []LedgerEntry
+	if err := database.DB.Raw(`
+		SELECT * FROM (`+ledgerQuery(basis)+`
+		) ledger
+		WHERE tanggal >= ? AND tanggal < ?
+		ORDER BY tanggal, sumber, ref_id`, start.Format("2006-01-02"), end.AddDate(0, 0, 1).Format("2006-01-02")).Scan(&entries).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build report"})
+		return
+	}
+
+	table := services.ExportTable{
+		Title: "Buku Besar",
+		Columns: []services.ExportColumn{
+			{Header: "Tanggal"}, {Header: "Tipe"}, {Header: "Deskripsi"}, {Header: "Jumlah", Rupiah: true},
+		},
+	}
+	details := []gin.H{}
+	for _, e := range entries {
+		tipe := e.Jenis
+		if e.Sumber == "tagihan" {
+			tipe = "Pendapatan"
+		}
+		tanggal := e.Tanggal.Format("2006-01-02")
+		details = append(details, gin.H{
+			"tipe":      tipe,
+			"deskripsi": e.Deskripsi,
+			"jumlah":    e.Jumlah,
+			"tanggal":   tanggal,
+		})
+		table.AddRow(tanggal, tipe, e.Deskripsi, e.Jumlah)
+	}
+	if respondExport(c, "buku-besar-"+startDate+"-"+endDate, table) {
+		return
+	}
+	c.JSON(http.StatusOK, details)
+}
+
+// pagination - Baca query page & per_page (default 1 dan 50, maksimum 500)
+func pagination(c *gin.Context) (int, int) {
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	perPage, err := strconv.Atoi(c.DefaultQuery("per_page", "50"))
+	if err != nil || perPage < 1 {
+		perPage = 50
+	}
+	if perPage > 500 {
+		perPage = 500
+	}
+	return page, perPage
+}
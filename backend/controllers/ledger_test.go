@@ -0,0 +1,51 @@
+package controllers
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestGetDetailReport(t *testing.T) {
+	setupTestDB(t)
+	seedLaporanBulanan(t)
+
+	tests := []struct {
+		name    string
+		query   string
+		tanggal []string
+	}{
+		{"accrual", "basis=accrual", []string{"2025-01-01", "2025-02-01", "2025-02-15", "2025-03-01", "2025-03-20"}},
+		{"cash", "basis=cash", []string{"2025-01-05", "2025-02-10", "2025-02-15", "2025-03-02", "2025-03-20"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target := "/report/detail?start_date=2025-01-01&end_date=2025-03-31&" + tt.query
+			w := panggilHandler(GetDetailReport, http.MethodGet, target, nil)
+			cekStatus(t, w, http.StatusOK)
+			var rows []struct{ Tipe, Deskripsi, Tanggal string }
+			decodeJSON(t, w, &rows)
+			got := []string{}
+			for _, r := range rows {
+				got = append(got, r.Tanggal)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.tanggal, ",") {
+				t.Errorf("tanggal = %v, want %v", got, tt.tanggal)
+			}
+
+			w = panggilHandler(GetDetailReport, http.MethodGet, target+"&format=csv", nil)
+			cekStatus(t, w, http.StatusOK)
+			if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/csv") {
+				t.Fatalf("content type = %q", ct)
+			}
+			for _, tanggal := range tt.tanggal {
+				if !strings.Contains(w.Body.String(), tanggal) {
+					t.Errorf("CSV missing row %s:\n%s", tanggal, w.Body.String())
+				}
+			}
+		})
+	}
+
+	w := panggilHandler(GetDetailReport, http.MethodGet, "/report/detail?start_date=2025-01-01&end_date=2025-03-31&format=pdf", nil)
+	cekStatus(t, w, http.StatusBadRequest)
+}
@@ -2,86 +2,112 @@ package controllers
 
 import (
 	"net/http"
+	"strings"
 	"time"
 
 	"kos-muhandis/backend/database"
 	"kos-muhandis/backend/models"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
-// CheckAndCreateNotifikasi - Check tagihan jatuh tempo dan buat notifikasi
+// kandidatNotifikasiQuery - Per tagihan belum lunas dan penerima hanya langkah eskalasi aktif terakhir
+// yang sudah tercapai, sehingga tagihan lama tidak memicu semua langkah sekaligus; langkah yang
+// notifikasinya sudah ada dilewati. Jatuh tempo = hari terakhir bulan tagihan, sama dengan jatuhTempo.
+const kandidatNotifikasiQuery = `
+	WITH tagihan AS (
+		SELECT id, CASE WHEN bulan ~ '^[0-9]{4}-(0[1-9]|1[0-2])'
+			THEN CAST(@hari_ini AS date) - CAST(to_date(LEFT(bulan, 7), 'YYYY-MM') + INTERVAL '1 month' - INTERVAL '1 day' AS date)
+			END AS hari_terlambat
+		FROM tagihans
+		WHERE deleted_at IS NULL AND status <> 'Lunas'
+			AND NOT (COALESCE(jumlah, 0) > 0 AND COALESCE(terbayar, 0) >= jumlah)
+	),
+	langkah AS (
+		SELECT DISTINCT ON (t.id, l.penerima) t.id AS tagihan_id, l.id AS langkah_id, l.tipe
+		FROM tagihan t
+		JOIN langkah_eskalasis l ON l.aktif AND l.hari <= t.hari_terlambat
+		ORDER BY t.id, l.penerima, l.hari DESC, l.id
+	)
+	SELECT k.tagihan_id, k.langkah_id FROM langkah k
+	WHERE NOT EXISTS (
+		SELECT 1 FROM notifikasis n
+		WHERE n.tagihan_id = k.tagihan_id AND n.tipe = k.tipe AND n.deleted_at IS NULL
+	)
+	ORDER BY k.tagihan_id, k.langkah_id`
+
+// CheckAndCreateNotifikasi - Check tagihan jatuh tempo dan buat notifikasi sesuai urutan eskalasi.
+// Tagihan yang sudah lunas tidak lagi dieskalasi. Kandidat dipilih di SQL; indeks unik
+// (tagihan_id, tipe) mencegah notifikasi ganda saat check berjalan bersamaan.
 func CheckAndCreateNotifikasi(c *gin.Context) {
-	var tagihanList []models.Tagihan
-	if err := database.DB.Preload("Penyewa").Find(&tagihanList).Error; err != nil {
+	var kandidat []struct {
+		TagihanID uint
+		LangkahID uint
+	}
+	if err := database.DB.Raw(kandidatNotifikasiQuery, map[string]interface{}{
+		"hari_ini": today().Format("2006-01-02"),
+	}).Scan(&kandidat).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tagihan"})
 		return
 	}
 
-	createdCount := 0
-	today := time.Now()
-
-	for _, tagihan := range tagihanList {
-		// Skip if already Lunas
-		if tagihan.Status == "Lunas" {
-			continue
+	var created int64
+	if len(kandidat) > 0 {
+		var tagihanIDs, langkahIDs []uint
+		for _, k := range kandidat {
+			tagihanIDs = append(tagihanIDs, k.TagihanID)
+			langkahIDs = append(langkahIDs, k.LangkahID)
 		}
-
-		// Parse bulan (format: "2025-11")
-		tagihanDate, err := time.Parse("2006-01", tagihan.Bulan)
-		if err != nil {
-			continue
+		var tagihanList []models.Tagihan
+		if err := database.DB.Preload("Penyewa.Kamar").Find(&tagihanList, tagihanIDs).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tagihan"})
+			return
 		}
-
-		// Set tanggal due ke akhir bulan (atau hari pertama bulan berikutnya)
-		dueDate := tagihanDate.AddDate(0, 1, -1) // Last day of the month
-
-		// Check berapa hari lagi sampai due
-		daysUntilDue := int(dueDate.Sub(today).Hours() / 24)
-
-		var tipeNotifikasi string
-		var shouldCreate bool
-
-		if daysUntilDue < 0 {
-			// OVERDUE
-			tipeNotifikasi = "OVERDUE"
-			shouldCreate = true
-		} else if daysUntilDue == 0 {
-			// H-1 (hari ini deadline)
-			tipeNotifikasi = "H-1"
-			shouldCreate = true
-		} else if daysUntilDue == 3 {
-			// H-3
-			tipeNotifikasi = "H-3"
-			shouldCreate = true
-		} else if daysUntilDue == 7 {
-			// H-7
-			tipeNotifikasi = "H-7"
-			shouldCreate = true
+		var langkahList []models.LangkahEskalasi
+		if err := database.DB.Find(&langkahList, langkahIDs).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch eskalasi"})
+			return
+		}
+		tagihanMap := make(map[uint]*models.Tagihan)
+		for i := range tagihanList {
+			tagihanMap[tagihanList[i].ID] = &tagihanList[i]
+		}
+		langkahMap := make(map[uint]models.LangkahEskalasi)
+		for _, l := range langkahList {
+			langkahMap[l.ID] = l
 		}
 
-		if shouldCreate {
-			// Check if notification already exists for this tagihan and tipe
-			var existingNotif models.Notifikasi
-			if err := database.DB.Where("tagihan_id = ? AND tipe = ?", tagihan.ID, tipeNotifikasi).First(&existingNotif).Error; err != nil {
-				// Notification doesn't exist, create it
-				notif := models.Notifikasi{
-					PenyewaID: tagihan.PenyewaID,
-					TagihanID: tagihan.ID,
-					Tipe:      tipeNotifikasi,
-					Status:    "pending",
-					Message:   GenerateNotifikasiMessage(tagihan.Penyewa.Nama, tagihan.Bulan, tagihan.Jumlah, tipeNotifikasi),
-				}
-				if err := database.DB.Create(&notif).Error; err == nil {
-					createdCount++
-				}
+		var daftar []models.Notifikasi
+		for _, k := range kandidat {
+			tagihan, l := tagihanMap[k.TagihanID], langkahMap[k.LangkahID]
+			if tagihan == nil || l.ID == 0 || !adaPenerima(l.Penerima, tagihan.Penyewa) {
+				continue
+			}
+			daftar = append(daftar, models.Notifikasi{
+				PenyewaID: tagihan.PenyewaID,
+				TagihanID: tagihan.ID,
+				Tipe:      l.Tipe,
+				Penerima:  l.Penerima,
+				Kanal:     l.Kanal,
+				Status:    "pending",
+				Message:   pesanLangkah(l, tagihan.Penyewa, tagihan),
+			})
+		}
+		if len(daftar) > 0 {
+			result := database.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&daftar)
+			if result.Error != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create notifikasi"})
+				return
 			}
+			created = result.RowsAffected
 		}
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Notifikasi check completed",
-		"created": createdCount,
+		"created": created,
 	})
 }
 
@@ -124,39 +150,74 @@ func GetNotifikasiDashboard(c *gin.Context) {
 	c.JSON(http.StatusOK, summary)
 }
 
-// GetNotifikasiList - Get list of notifications
+// GetNotifikasiList - Daftar notifikasi terbaru. Query: status, tipe, penyewa_id, penerima,
+// start_date, end_date (tanggal dibuat, YYYY-MM-DD), page, per_page.
 func GetNotifikasiList(c *gin.Context) {
-	var notifikasi []models.NotifikasiResponse
-
-	query := `
-		SELECT 
-			n.id, n.penyewa_id, p.nama as penyewa_nama, n.tagihan_id, 
-			n.tipe, n.status, n.message, n.sent_at, t.bulan, t.jumlah, n.created_at
-		FROM notifikasi n
-		JOIN penyewa p ON n.penyewa_id = p.id
-		JOIN tagihan t ON n.tagihan_id = t.id
-		WHERE n.deleted_at IS NULL
-		ORDER BY n.created_at DESC
-		LIMIT 50
-	`
-
-	if err := database.DB.Raw(query).Scan(&notifikasi).Error; err != nil {
+	query := database.DB.Table("notifikasis n").
+		Joins("JOIN penyewas p ON p.id = n.penyewa_id").
+		Joins("JOIN tagihans t ON t.id = n.tagihan_id").
+		Where("n.deleted_at IS NULL")
+	if status := c.Query("status"); status != "" {
+		query = query.Where("n.status = ?", status)
+	}
+	if tipe := c.Query("tipe"); tipe != "" {
+		query = query.Where("n.tipe = ?", strings.ToUpper(tipe))
+	}
+	if penyewaID := c.Query("penyewa_id"); penyewaID != "" {
+		query = query.Where("n.penyewa_id = ?", penyewaID)
+	}
+	if penerima := c.Query("penerima"); penerima != "" {
+		query = query.Where("n.penerima = ?", penerima)
+	}
+	if value := c.Query("start_date"); value != "" {
+		start, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid start_date format"})
+			return
+		}
+		query = query.Where("n.created_at >= ?", start)
+	}
+	if value := c.Query("end_date"); value != "" {
+		end, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid end_date format"})
+			return
+		}
+		query = query.Where("n.created_at < ?", end.AddDate(0, 0, 1))
+	}
+	page, perPage := pagination(c)
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch notifikasi"})
+		return
+	}
+
+	notifikasi := []models.NotifikasiResponse{}
+	if err := query.Select(`n.id, n.penyewa_id, p.nama AS penyewa_nama, n.tagihan_id, n.tipe, n.penerima,
+			n.status, n.message, n.sent_at, n.dilihat_pada, t.bulan, t.jumlah, n.created_at`).
+		Order("n.created_at DESC, n.id DESC").
+		Limit(perPage).Offset((page - 1) * perPage).
+		Scan(&notifikasi).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch notifikasi"})
 		return
 	}
 
-	c.JSON(http.StatusOK, notifikasi)
+	c.JSON(http.StatusOK, gin.H{
+		"notifikasi": notifikasi,
+		"page":       page,
+		"per_page":   perPage,
+		"total":      total,
+	})
 }
 
-// MarkNotifikasiAsRead - Mark notification as read
+// MarkNotifikasiAsRead - Tandai notifikasi sudah dilihat pengelola (dilihat_pada). Status read
+// hanya berasal dari laporan provider bahwa penyewa sudah membaca pesan.
 func MarkNotifikasiAsRead(c *gin.Context) {
 	id := c.Param("id")
 	now := time.Now()
 
-	if err := database.DB.Model(&models.Notifikasi{}).Where("id = ?", id).Updates(map[string]interface{}{
-		"status":  "read",
-		"sent_at": now,
-	}).Error; err != nil {
+	if err := database.DB.Model(&models.Notifikasi{}).Where("id = ?", id).Update("dilihat_pada", now).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update notifikasi"})
 		return
 	}
@@ -175,19 +236,3 @@ func DeleteNotifikasi(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"message": "Notifikasi deleted"})
 }
-
-// Helper function untuk generate message
-func GenerateNotifikasiMessage(namaPenyewa string, bulan string, jumlah int, tipe string) string {
-	var message string
-	switch tipe {
-	case "H-7":
-		message = "Pengingat: Tagihan bulan " + bulan + " akan jatuh tempo dalam 7 hari"
-	case "H-3":
-		message = "Perhatian: Tagihan bulan " + bulan + " akan jatuh tempo dalam 3 hari"
-	case "H-1":
-		message = "Mendesak: Tagihan bulan " + bulan + " jatuh tempo hari ini"
-	case "OVERDUE":
-		message = "Tertunggak: Tagihan bulan " + bulan + " sudah terlewat"
-	}
-	return message
-}
@@ -0,0 +1,263 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"kos-muhandis/backend/database"
+	"kos-muhandis/backend/models"
+)
+
+// resetLangkahEskalasi - Kembalikan urutan eskalasi ke langkah bawaan (H-7, H-3, H-1, OVERDUE)
+func resetLangkahEskalasi(t *testing.T) {
+	t.Helper()
+	err := database.DB.Exec(`TRUNCATE langkah_eskalasis RESTART IDENTITY`).Error
+	if err == nil {
+		err = database.DB.Exec(`
+			INSERT INTO langkah_eskalasis (tipe, hari, penerima, template)
+			VALUES ('H-7', -7, 'penyewa', 'H-7'), ('H-3', -3, 'penyewa', 'H-3'),
+				('H-1', 0, 'penyewa', 'H-1'), ('OVERDUE', 1, 'penyewa', 'OVERDUE')
+		`).Error
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// bulanRelatif - Periode tagihan n bulan dari bulan ini (WIB)
+func bulanRelatif(n int) string {
+	now := today()
+	return time.Date(now.Year(), now.Month()+time.Month(n), 1, 0, 0, 0, 0, time.UTC).Format("2006-01")
+}
+
+func cekNotifikasi(t *testing.T) int64 {
+	t.Helper()
+	w := panggilHandler(CheckAndCreateNotifikasi, http.MethodPost, "/notifikasi/check", nil)
+	cekStatus(t, w, http.StatusOK)
+	var resp struct{ Created int64 }
+	decodeJSON(t, w, &resp)
+	return resp.Created
+}
+
+// tipeNotifikasi - Tipe notifikasi aktif per tagihan
+func tipeNotifikasi(t *testing.T) map[uint][]string {
+	t.Helper()
+	var list []models.Notifikasi
+	if err := database.DB.Order("id").Find(&list).Error; err != nil {
+		t.Fatal(err)
+	}
+	result := make(map[uint][]string)
+	for _, n := range list {
+		result[n.TagihanID] = append(result[n.TagihanID], n.Tipe)
+	}
+	return result
+}
+
+func TestCheckAndCreateNotifikasi(t *testing.T) {
+	setupTestDB(t)
+	resetLangkahEskalasi(t)
+	penyewa := seedPenyewa(t, "Eka")
+	belum := seedTagihan(t, penyewa, bulanRelatif(-3), 1000000)
+	cicil := seedTagihan(t, penyewa, bulanRelatif(-2), 1000000)
+	seedBayar(t, cicil, 400000, today().Format("2006-01-02"))
+	lunas := seedTagihan(t, penyewa, bulanRelatif(-1), 1000000)
+	seedBayar(t, lunas, 1000000, today().Format("2006-01-02"))
+	mendatang := seedTagihan(t, penyewa, bulanRelatif(2), 1000000)
+
+	// Tagihan lama hanya mendapat langkah terakhir yang tercapai, bukan H-7..OVERDUE sekaligus
+	if got := cekNotifikasi(t); got != 2 {
+		t.Fatalf("created = %d, want 2", got)
+	}
+	got := tipeNotifikasi(t)
+	for _, id := range []uint{belum.ID, cicil.ID} {
+		if len(got[id]) != 1 || got[id][0] != "OVERDUE" {
+			t.Errorf("tagihan %d: tipe %v, want [OVERDUE]", id, got[id])
+		}
+	}
+	for _, id := range []uint{lunas.ID, mendatang.ID} {
+		if len(got[id]) != 0 {
+			t.Errorf("tagihan %d: tipe %v, want none", id, got[id])
+		}
+	}
+
+	var n models.Notifikasi
+	database.DB.Where("tagihan_id = ?", belum.ID).First(&n)
+	if n.PenyewaID != penyewa.ID || n.Penerima != "penyewa" || n.Status != "pending" || n.Message == "" {
+		t.Errorf("notifikasi = %+v", n)
+	}
+
+	// Check berulang tidak membuat notifikasi ganda
+	for i := 0; i < 3; i++ {
+		if got := cekNotifikasi(t); got != 0 {
+			t.Fatalf("run %d: created = %d, want 0", i+2, got)
+		}
+	}
+	var total int64
+	database.DB.Model(&models.Notifikasi{}).Count(&total)
+	if total != 2 {
+		t.Errorf("notifikasi = %d, want 2", total)
+	}
+
+	// Indeks unik (tagihan_id, tipe) menolak duplikat dari jalur lain
+	dup := models.Notifikasi{PenyewaID: penyewa.ID, TagihanID: belum.ID, Tipe: "OVERDUE", Status: "pending"}
+	if err := database.DB.Create(&dup).Error; err == nil {
+		t.Error("duplicate (tagihan_id, tipe) was inserted")
+	}
+}
+
+func TestCheckAndCreateNotifikasiConcurrent(t *testing.T) {
+	setupTestDB(t)
+	resetLangkahEskalasi(t)
+	penyewa := seedPenyewa(t, "Fajar")
+	for i := -6; i < -1; i++ {
+		seedTagihan(t, penyewa, bulanRelatif(i), 1000000)
+	}
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		created int64
+	)
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w := panggilHandler(CheckAndCreateNotifikasi, http.MethodPost, "/notifikasi/check", nil)
+			var resp struct{ Created int64 }
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || w.Code != http.StatusOK {
+				t.Errorf("status %d: %s", w.Code, w.Body.String())
+			}
+			mu.Lock()
+			created += resp.Created
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	var total int64
+	database.DB.Model(&models.Notifikasi{}).Count(&total)
+	if total != 5 || created != 5 {
+		t.Errorf("notifikasi = %d, created = %d, want 5", total, created)
+	}
+}
+
+func TestEskalasiBerhentiSaatLunas(t *testing.T) {
+	setupTestDB(t)
+	resetLangkahEskalasi(t)
+	penyewa := seedPenyewa(t, "Gita")
+	dibayar := seedTagihan(t, penyewa, bulanRelatif(-3), 1000000)
+	terbuka := seedTagihan(t, penyewa, bulanRelatif(-4), 1000000)
+
+	if got := cekNotifikasi(t); got != 2 {
+		t.Fatalf("created = %d, want 2", got)
+	}
+
+	// Langkah berikutnya tercapai setelah tagihan pertama dilunasi dengan dua cicilan
+	if err := database.DB.Exec(`INSERT INTO langkah_eskalasis (tipe, hari, penerima, template)
+		VALUES ('SOMASI', 14, 'penyewa', 'OVERDUE')`).Error; err != nil {
+		t.Fatal(err)
+	}
+	seedBayar(t, dibayar, 500000, today().Format("2006-01-02"))
+	seedBayar(t, dibayar, 500000, today().Format("2006-01-02"))
+
+	if got := cekNotifikasi(t); got != 1 {
+		t.Fatalf("created = %d, want 1", got)
+	}
+	got := tipeNotifikasi(t)
+	if fmt.Sprint(got[dibayar.ID]) != "[OVERDUE]" || fmt.Sprint(got[terbuka.ID]) != "[OVERDUE SOMASI]" {
+		t.Errorf("tipe = %v", got)
+	}
+}
+
+func TestGetNotifikasiList(t *testing.T) {
+	setupTestDB(t)
+	p1 := seedPenyewa(t, "Hana")
+	p2 := seedPenyewa(t, "Indra")
+	t1 := seedTagihan(t, p1, "2025-01", 1000000)
+	t2 := seedTagihan(t, p1, "2025-02", 1000000)
+	t3 := seedTagihan(t, p2, "2025-01", 1000000)
+
+	seed := []struct {
+		tagihan models.Tagihan
+		tipe    string
+		status  string
+		dibuat  string
+	}{
+		{t1, "OVERDUE", "pending", "2025-01-05"},
+		{t2, "OVERDUE", "sent", "2025-01-20"},
+		{t3, "H-3", "sent", "2025-02-10"},
+		{t3, "OVERDUE", "failed", "2025-03-01"},
+		{t1, "H-1", "read", "2025-03-15"},
+	}
+	for _, s := range seed {
+		n := models.Notifikasi{
+			PenyewaID: s.tagihan.PenyewaID, TagihanID: s.tagihan.ID, Tipe: s.tipe, Status: s.status,
+			Message: "pesan", CreatedAt: tanggalTest(t, s.dibuat).Add(10 * time.Hour),
+		}
+		if err := database.DB.Create(&n).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	p1ID, p2ID := strconv.Itoa(int(p1.ID)), strconv.Itoa(int(p2.ID))
+	tests := []struct {
+		name    string
+		query   string
+		ids     []uint
+		total   int64
+		page    int
+		perPage int
+	}{
+		{"semua, terbaru dulu", "", []uint{5, 4, 3, 2, 1}, 5, 1, 50},
+		{"halaman 1", "page=1&per_page=2", []uint{5, 4}, 5, 1, 2},
+		{"halaman 2", "page=2&per_page=2", []uint{3, 2}, 5, 2, 2},
+		{"halaman terakhir", "page=3&per_page=2", []uint{1}, 5, 3, 2},
+		{"lewat halaman terakhir", "page=4&per_page=2", []uint{}, 5, 4, 2},
+		{"status", "status=sent", []uint{3, 2}, 2, 1, 50},
+		{"tipe tanpa membedakan huruf", "tipe=overdue", []uint{4, 2, 1}, 3, 1, 50},
+		{"penyewa", "penyewa_id=" + p2ID, []uint{4, 3}, 2, 1, 50},
+		{"rentang tanggal inklusif", "start_date=2025-01-20&end_date=2025-02-10", []uint{3, 2}, 2, 1, 50},
+		{"hanya start_date", "start_date=2025-03-01", []uint{5, 4}, 2, 1, 50},
+		{"hanya end_date", "end_date=2025-01-05", []uint{1}, 1, 1, 50},
+		{"gabungan dengan halaman", "tipe=OVERDUE&penyewa_id=" + p1ID + "&per_page=1&page=2", []uint{1}, 2, 2, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := panggilHandler(GetNotifikasiList, http.MethodGet, "/notifikasi?"+tt.query, nil)
+			cekStatus(t, w, http.StatusOK)
+			var resp struct {
+				Notifikasi []models.NotifikasiResponse
+				Page       int
+				PerPage    int `json:"per_page"`
+				Total      int64
+			}
+			decodeJSON(t, w, &resp)
+			ids := []uint{}
+			for _, n := range resp.Notifikasi {
+				ids = append(ids, n.ID)
+			}
+			if fmt.Sprint(ids) != fmt.Sprint(tt.ids) || resp.Total != tt.total || resp.Page != tt.page || resp.PerPage != tt.perPage {
+				t.Errorf("ids %v total %d page %d per_page %d; want %v %d %d %d",
+					ids, resp.Total, resp.Page, resp.PerPage, tt.ids, tt.total, tt.page, tt.perPage)
+			}
+		})
+	}
+
+	for _, query := range []string{"start_date=2025-13-01", "end_date=01-02-2025"} {
+		w := panggilHandler(GetNotifikasiList, http.MethodGet, "/notifikasi?"+query, nil)
+		cekStatus(t, w, http.StatusBadRequest)
+	}
+
+	// Nama penyewa dan data tagihan ikut di tiap baris
+	w := panggilHandler(GetNotifikasiList, http.MethodGet, "/notifikasi?penyewa_id="+p2ID+"&tipe=H-3", nil)
+	var resp struct{ Notifikasi []models.NotifikasiResponse }
+	decodeJSON(t, w, &resp)
+	if len(resp.Notifikasi) != 1 || resp.Notifikasi[0].PenyewaNama != "Indra" || resp.Notifikasi[0].Bulan != "2025-01" {
+		t.Errorf("row = %+v", resp.Notifikasi)
+	}
+}
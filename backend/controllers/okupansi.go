@@ -0,0 +1,258 @@
+package controllers
+
+import (
+	"math"
+	"net/http"
+	"time"
+
+	"kos-muhandis/backend/database"
+	"kos-muhandis/backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OkupansiBulan - Okupansi dan pendapatan kamar per bulan
+type OkupansiBulan struct {
+	Bulan           string  `json:"bulan"`
+	HariTersedia    int     `json:"hari_tersedia"` // jumlah kamar-hari yang bisa disewakan
+	HariTerisi      int     `json:"hari_terisi"`
+	TingkatOkupansi float64 `json:"tingkat_okupansi"` // persen
+	PenyewaMasuk    int     `json:"penyewa_masuk"`
+	PenyewaKeluar   int     `json:"penyewa_keluar"`
+	Pendapatan      int     `json:"pendapatan"` // pendapatan sewa kamar
+	RevPAR          int     `json:"revpar"`     // pendapatan per kamar tersedia per bulan
+}
+
+// OkupansiKamar - Okupansi satu kamar dalam periode laporan
+type OkupansiKamar struct {
+	KamarID         uint    `json:"kamar_id"`
+	Nama            string  `json:"nama"`
+	HariTersedia    int     `json:"hari_tersedia"`
+	HariTerisi      int     `json:"hari_terisi"`
+	HariKosong      int     `json:"hari_kosong"`
+	TingkatOkupansi float64 `json:"tingkat_okupansi"`
+	JumlahPenyewa   int     `json:"jumlah_penyewa"`
+	PenyewaKeluar   int     `json:"penyewa_keluar"`
+}
+
+// OkupansiSummary - Ringkasan okupansi untuk seluruh periode
+type OkupansiSummary struct {
+	StartDate           string  `json:"start_date"`
+	EndDate             string  `json:"end_date"`
+	Basis               string  `json:"basis"`
+	HariTersedia        int     `json:"hari_tersedia"`
+	HariTerisi          int     `json:"hari_terisi"`
+	TingkatOkupansi     float64 `json:"tingkat_okupansi"`
+	RataRataKamar       float64 `json:"rata_rata_kamar"` // rata-rata kamar tersedia per hari
+	PenyewaKeluar       int     `json:"penyewa_keluar"`
+	TingkatTurnover     float64 `json:"tingkat_turnover"`             // penyewa keluar / rata-rata kamar, persen
+	RataRataLamaTinggal float64 `json:"rata_rata_lama_tinggal"`       // hari, penyewa yang keluar dalam periode
+	RataRataLamaAktif   float64 `json:"rata_rata_lama_tinggal_aktif"` // hari, penyewa yang masih tinggal sampai end_date
+	Pendapatan          int     `json:"pendapatan"`
+	RevPAR              int     `json:"revpar"`
+}
+
+// okupansiQuery - Status kamar per hari diturunkan dari periode sewa penyewa:
+// mulai = tanggal_masuk (atau tanggal dibuat), selesai = tanggal_keluar atau tanggal
+// penyewa dihapus, mana yang lebih dulu. Kamar dihitung tersedia sejak dibuat (atau sejak
+// penyewa pertamanya masuk untuk data impor) sampai dihapus.
+const okupansiQuery = `
+	WITH hunian AS (
+		SELECT id, kamar_id, COALESCE(tanggal_masuk::date, created_at::date) AS mulai,
+			LEAST(tanggal_keluar::date, deleted_at::date) AS selesai
+		FROM penyewas
+	),
+	kamar AS (
+		SELECT k.id, k.nama,
+			LEAST(k.created_at::date, (SELECT MIN(h.mulai) FROM hunian h WHERE h.kamar_id = k.id)) AS mulai,
+			k.deleted_at::date AS selesai
+		FROM kamars k
+	),
+	hari AS (
+		SELECT d::date AS tanggal FROM generate_series(CAST(@start AS date), CAST(@end AS date), INTERVAL '1 day') d
+	),
+	kamar_hari AS (
+		SELECT k.id AS kamar_id, h.tanggal,
+			EXISTS (
+				SELECT 1 FROM hunian p
+				WHERE p.kamar_id = k.id AND h.tanggal >= p.mulai AND (p.selesai IS NULL OR h.tanggal < p.selesai)
+			) AS terisi
+		FROM kamar k
+		JOIN hari h ON h.tanggal >= k.mulai AND (k.selesai IS NULL OR h.tanggal < k.selesai)
+	)`
+
+// maksTahunOkupansi - Rentang start_date..end_date terpanjang; query membuat satu baris per kamar per hari
+const maksTahunOkupansi = 5
+
+// GetOccupancyReport - Analitik okupansi historis: tingkat okupansi per bulan, hari kosong
+// per kamar, rata-rata lama tinggal, turnover dan RevPAR.
+// Query: start_date, end_date (YYYY-MM-DD, default 12 bulan terakhir sampai hari ini, maksimal 5 tahun),
+// basis (accrual|cash, default accrual) untuk pendapatan, format (csv|xlsx).
+func GetOccupancyReport(c *gin.Context) {
+	end := today()
+	var err error
+	if value := c.Query("end_date"); value != "" {
+		if end, err = time.Parse("2006-01-02", value); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid end_date format"})
+			return
+		}
+	}
+	start := time.Date(end.Year(), end.Month()-11, 1, 0, 0, 0, 0, time.UTC)
+	if value := c.Query("start_date"); value != "" {
+		if start, err = time.Parse("2006-01-02", value); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid start_date format"})
+			return
+		}
+	}
+	if end.Before(start) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "end_date must not be before start_date"})
+		return
+	}
+	if end.After(start.AddDate(maksTahunOkupansi, 0, 0)) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Periode okupansi maksimal 5 tahun"})
+		return
+	}
+	basis, ok := reportBasis(c, BasisAccrual)
+	if !ok {
+		return
+	}
+
+	args := map[string]interface{}{
+		"start": start.Format("2006-01-02"),
+		"end":   end.Format("2006-01-02"),
+	}
+
+	// Pendapatan sewa kamar saja (tagihan listrik/air/dll tidak dihitung di RevPAR)
+	pendapatan := `
+		SELECT LEFT(bulan, 7) AS bulan, SUM(jumlah) AS jumlah
+		FROM tagihans
+		WHERE deleted_at IS NULL AND COALESCE(NULLIF(jenis_tagihan, ''), 'Penyewa') = 'Penyewa'
+		GROUP BY 1`
+	if basis == BasisCash {
+		pendapatan = `
+		SELECT TO_CHAR(p.tanggal, 'YYYY-MM') AS bulan, SUM(p.jumlah) AS jumlah
+		FROM penerimaan_tagihan p
+		JOIN tagihans t ON t.id = p.tagihan_id
+		WHERE p.tanggal BETWEEN CAST(@start AS date) AND CAST(@end AS date)
+			AND COALESCE(NULLIF(t.jenis_tagihan, ''), 'Penyewa') = 'Penyewa'
+		GROUP BY 1`
+	}
+
+	var bulanan []OkupansiBulan
+	if err := database.DB.Raw(okupansiQuery+`,
+		pendapatan AS (`+pendapatan+`
+		)
+		SELECT b.bulan,
+			COUNT(kh.kamar_id) AS hari_tersedia,
+			COUNT(kh.kamar_id) FILTER (WHERE kh.terisi) AS hari_terisi,
+			(SELECT COUNT(*) FROM hunian p WHERE TO_CHAR(p.mulai, 'YYYY-MM') = b.bulan
+				AND p.mulai BETWEEN CAST(@start AS date) AND CAST(@end AS date)) AS penyewa_masuk,
+			(SELECT COUNT(*) FROM hunian p WHERE TO_CHAR(p.selesai, 'YYYY-MM') = b.bulan
+				AND p.selesai BETWEEN CAST(@start AS date) AND CAST(@end AS date)) AS penyewa_keluar,
+			COALESCE((SELECT jumlah FROM pendapatan WHERE pendapatan.bulan = b.bulan), 0) AS pendapatan
+		FROM (SELECT DISTINCT TO_CHAR(tanggal, 'YYYY-MM') AS bulan FROM hari) b
+		LEFT JOIN kamar_hari kh ON TO_CHAR(kh.tanggal, 'YYYY-MM') = b.bulan
+		GROUP BY b.bulan
+		ORDER BY b.bulan`, args).Scan(&bulanan).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build occupancy report"})
+		return
+	}
+
+	var kamar []OkupansiKamar
+	if err := database.DB.Raw(okupansiQuery+`
+		SELECT k.id AS kamar_id, k.nama,
+			COUNT(kh.kamar_id) AS hari_tersedia,
+			COUNT(kh.kamar_id) FILTER (WHERE kh.terisi) AS hari_terisi,
+			(SELECT COUNT(*) FROM hunian p WHERE p.kamar_id = k.id AND p.mulai <= CAST(@end AS date)
+				AND (p.selesai IS NULL OR p.selesai > CAST(@start AS date))) AS jumlah_penyewa,
+			(SELECT COUNT(*) FROM hunian p WHERE p.kamar_id = k.id
+				AND p.selesai BETWEEN CAST(@start AS date) AND CAST(@end AS date)) AS penyewa_keluar
+		FROM kamar k
+		JOIN kamar_hari kh ON kh.kamar_id = k.id
+		GROUP BY k.id, k.nama
+		ORDER BY k.nama`, args).Scan(&kamar).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build occupancy report"})
+		return
+	}
+
+	var summary OkupansiSummary
+	if err := database.DB.Raw(okupansiQuery+`
+		SELECT
+			COALESCE((SELECT AVG(selesai - mulai) FROM hunian
+				WHERE selesai BETWEEN CAST(@start AS date) AND CAST(@end AS date)), 0) AS rata_rata_lama_tinggal,
+			COALESCE((SELECT AVG(CAST(@end AS date) - mulai) FROM hunian
+				WHERE mulai <= CAST(@end AS date) AND (selesai IS NULL OR selesai > CAST(@end AS date))), 0) AS rata_rata_lama_aktif`,
+		args).Scan(&summary).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build occupancy report"})
+		return
+	}
+	summary.StartDate = args["start"].(string)
+	summary.EndDate = args["end"].(string)
+	summary.Basis = basis
+	summary.RataRataLamaTinggal = math.Round(summary.RataRataLamaTinggal*10) / 10
+	summary.RataRataLamaAktif = math.Round(summary.RataRataLamaAktif*10) / 10
+
+	for i := range bulanan {
+		b := &bulanan[i]
+		month, _ := time.Parse("2006-01", b.Bulan)
+		b.TingkatOkupansi = persen(b.HariTerisi, b.HariTersedia)
+		// Kamar tersedia rata-rata bulan itu = kamar-hari / jumlah hari kalender
+		if b.HariTersedia > 0 {
+			hariBulan := month.AddDate(0, 1, -1).Day()
+			b.RevPAR = int(math.Round(float64(b.Pendapatan) * float64(hariBulan) / float64(b.HariTersedia)))
+		}
+		summary.HariTersedia += b.HariTersedia
+		summary.HariTerisi += b.HariTerisi
+		summary.PenyewaKeluar += b.PenyewaKeluar
+		summary.Pendapatan += b.Pendapatan
+	}
+	for i := range kamar {
+		k := &kamar[i]
+		k.HariKosong = k.HariTersedia - k.HariTerisi
+		k.TingkatOkupansi = persen(k.HariTerisi, k.HariTersedia)
+	}
+
+	hari := int(end.Sub(start).Hours()/24) + 1
+	summary.TingkatOkupansi = persen(summary.HariTerisi, summary.HariTersedia)
+	summary.RataRataKamar = math.Round(float64(summary.HariTersedia)/float64(hari)*10) / 10
+	if summary.RataRataKamar > 0 {
+		summary.TingkatTurnover = math.Round(float64(summary.PenyewaKeluar)/summary.RataRataKamar*1000) / 10
+	}
+	if summary.HariTersedia > 0 {
+		// Pendapatan per kamar tersedia per bulan (30 hari)
+		summary.RevPAR = int(math.Round(float64(summary.Pendapatan) * 30 / float64(summary.HariTersedia)))
+	}
+
+	table := services.ExportTable{
+		Title: "Okupansi",
+		Columns: []services.ExportColumn{
+			{Header: "Bulan"}, {Header: "Kamar-Hari Tersedia"}, {Header: "Kamar-Hari Terisi"}, {Header: "Okupansi (%)"},
+			{Header: "Penyewa Masuk"}, {Header: "Penyewa Keluar"}, {Header: "Pendapatan", Rupiah: true}, {Header: "RevPAR", Rupiah: true},
+		},
+	}
+	for _, b := range bulanan {
+		table.AddRow(b.Bulan, b.HariTersedia, b.HariTerisi, b.TingkatOkupansi, b.PenyewaMasuk, b.PenyewaKeluar, b.Pendapatan, b.RevPAR)
+	}
+	if respondExport(c, "okupansi-"+summary.StartDate+"-"+summary.EndDate, table) {
+		return
+	}
+
+	if bulanan == nil {
+		bulanan = []OkupansiBulan{}
+	}
+	if kamar == nil {
+		kamar = []OkupansiKamar{}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"summary": summary,
+		"bulanan": bulanan,
+		"kamar":   kamar,
+	})
+}
+
+func persen(part, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return math.Round(float64(part)/float64(total)*1000) / 10
+}
@@ -0,0 +1,23 @@
+package controllers
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestGetOccupancyReportPeriode(t *testing.T) {
+	tests := []struct {
+		query string
+		want  int
+	}{
+		{"?start_date=2020-01-01&end_date=2025-12-31", http.StatusBadRequest},
+		{"?start_date=2025-02-01&end_date=2025-01-31", http.StatusBadRequest},
+		{"?start_date=2025-13-01", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		w := panggilHandler(GetOccupancyReport, http.MethodGet, "/report/okupansi"+tt.query, nil)
+		if w.Code != tt.want {
+			t.Errorf("%s: status %d, want %d: %s", tt.query, w.Code, tt.want, w.Body.String())
+		}
+	}
+}
@@ -0,0 +1,328 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"kos-muhandis/backend/database"
+	"kos-muhandis/backend/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GetPembayaranByTagihan - Riwayat pembayaran satu tagihan
+func GetPembayaranByTagihan(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	var pembayaran []models.Pembayaran
+	if err := database.DB.Where("tagihan_id = ?", id).Order("tanggal ASC, id ASC").Find(&pembayaran).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch pembayaran"})
+		return
+	}
+	c.JSON(http.StatusOK, pembayaran)
+}
+
+// CreatePembayaran - Catat pembayaran (lunas atau cicilan) untuk tagihan
+func CreatePembayaran(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	var input struct {
+		Jumlah       int    `json:"jumlah" binding:"required"`
+		Tanggal      string `json:"tanggal"`
+		DiterimaOleh string `json:"diterima_oleh"`
+		Keterangan   string `json:"keterangan"`
+		RekeningID   *uint  `json:"rekening_id"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if input.Jumlah <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Jumlah must be greater than zero"})
+		return
+	}
+	tanggal, err := paymentDate(input.Tanggal)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format"})
+		return
+	}
+	rekeningID, msg := pilihRekening(input.RekeningID)
+	if msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	var tagihan models.Tagihan
+	var pembayaran models.Pembayaran
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		tagihan, pembayaran, err = bayarTagihan(tx, uint(id), models.Pembayaran{
+			Jumlah:       input.Jumlah,
+			Tanggal:      tanggal,
+			DiterimaOleh: input.DiterimaOleh,
+			Keterangan:   input.Keterangan,
+			RekeningID:   rekeningID,
+		}, nil)
+		return err
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Tagihan not found"})
+		return
+	}
+	if errors.Is(err, errOverpayment) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record pembayaran"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"pembayaran": pembayaran, "tagihan": tagihan})
+}
+
+// DeletePembayaran - Batalkan pembayaran, kurangi terbayar pada tagihan dan hapus transaksinya
+func DeletePembayaran(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var pembayaran models.Pembayaran
+		if err := tx.First(&pembayaran, id).Error; err != nil {
+			return err
+		}
+		return hapusPembayaran(tx, pembayaran)
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Pembayaran not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete pembayaran"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Pembayaran deleted"})
+}
+
+var errOverpayment = errors.New("Jumlah pembayaran melebihi sisa tagihan")
+
+var errTerbayarNegatif = errors.New("Terbayar tagihan tidak boleh kurang dari nol")
+
+// bayarTagihan - Tambah terbayar tagihan lalu catat pembayarannya. Transaksi pemasukan yang
+// sudah ada bisa ditautkan sebagai pencatatan kasnya; tanpa itu transaksi baru dibuat.
+// input berisi jumlah, tanggal, diterima_oleh, keterangan dan rekening pembayaran.
+func bayarTagihan(tx *gorm.DB, tagihanID uint, input models.Pembayaran, transaksi *models.Transaksi) (models.Tagihan, models.Pembayaran, error) {
+	var tagihan models.Tagihan
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&tagihan, tagihanID).Error; err != nil {
+		return tagihan, models.Pembayaran{}, err
+	}
+	if tagihan.Terbayar+input.Jumlah > tagihan.Jumlah {
+		return tagihan, models.Pembayaran{}, errOverpayment
+	}
+
+	tagihan.Terbayar += input.Jumlah
+	tagihan.Status = statusFromTerbayar(tagihan.Jumlah, tagihan.Terbayar)
+	tagihan.TanggalBayar = input.Tanggal.Format("2006-01-02")
+	if input.DiterimaOleh != "" {
+		tagihan.DiterimaOleh = input.DiterimaOleh
+	}
+	if err := tx.Save(&tagihan).Error; err != nil {
+		return tagihan, models.Pembayaran{}, err
+	}
+
+	pembayaran, err := recordPembayaran(tx, tagihan, input, transaksi)
+	return tagihan, pembayaran, err
+}
+
+// recordPembayaran - Simpan satu baris riwayat pembayaran beserta transaksi pemasukannya.
+// Jumlah negatif dipakai untuk koreksi ketika terbayar pada tagihan dikurangi.
+// Tanpa rekening, pembayaran masuk ke rekening transaksi tertaut atau rekening kas.
+func recordPembayaran(tx *gorm.DB, tagihan models.Tagihan, pembayaran models.Pembayaran, transaksi *models.Transaksi) (models.Pembayaran, error) {
+	pembayaran.TagihanID = tagihan.ID
+	pembayaran.PenyewaID = tagihan.PenyewaID
+	if pembayaran.DiterimaOleh == "" {
+		pembayaran.DiterimaOleh = tagihan.DiterimaOleh
+	}
+	if pembayaran.RekeningID == nil && transaksi != nil {
+		pembayaran.RekeningID = transaksi.RekeningID
+	}
+	if pembayaran.RekeningID == nil {
+		pembayaran.RekeningID = rekeningDefault(tx)
+	}
+	if pembayaran.RekeningID == nil {
+		return pembayaran, errTanpaRekening
+	}
+	if err := tx.Create(&pembayaran).Error; err != nil {
+		return pembayaran, err
+	}
+
+	if transaksi == nil {
+		baru := transaksiPembayaran(tx, tagihan, pembayaran)
+		return pembayaran, tx.Create(&baru).Error
+	}
+	transaksi.PembayaranID = &pembayaran.ID
+	transaksi.TagihanID = &tagihan.ID
+	transaksi.RekeningID = pembayaran.RekeningID
+	if transaksi.KamarID == nil && tagihan.KamarID != 0 {
+		transaksi.KamarID = &tagihan.KamarID
+	}
+	return pembayaran, tx.Save(transaksi).Error
+}
+
+// transaksiPembayaran - Transaksi pemasukan untuk satu baris pembayaran tagihan
+func transaksiPembayaran(tx *gorm.DB, tagihan models.Tagihan, pembayaran models.Pembayaran) models.Transaksi {
+	kategori := kategoriTagihan(tagihan.JenisTagihan)
+	var penyewa models.Penyewa
+	tx.Unscoped().Select("nama").First(&penyewa, tagihan.PenyewaID)
+
+	label := "Pembayaran"
+	if pembayaran.Jumlah < 0 {
+		label = "Koreksi pembayaran"
+	}
+	bulan := tagihan.Bulan
+	if len(bulan) > 7 {
+		bulan = bulan[:7]
+	}
+	transaksi := models.Transaksi{
+		Jenis:        "pemasukan",
+		Kategori:     kategori,
+		Keterangan:   label + " " + kategori + " " + bulan + " - " + penyewa.Nama,
+		PembayaranID: &pembayaran.ID,
+		TagihanID:    &tagihan.ID,
+		RekeningID:   pembayaran.RekeningID,
+		Jumlah:       pembayaran.Jumlah,
+		Tanggal:      pembayaran.Tanggal,
+	}
+	if tagihan.KamarID != 0 {
+		transaksi.KamarID = &tagihan.KamarID
+	}
+	if resolved, err := resolveKategori(transaksi.Jenis, nil, kategori); err == nil && resolved != nil {
+		transaksi.KategoriID = &resolved.ID
+		transaksi.Kategori = resolved.Nama
+	}
+	return transaksi
+}
+
+// kategoriTagihan - Kategori transaksi untuk jenis tagihan (tagihan sewa = "Sewa Kamar")
+func kategoriTagihan(jenis string) string {
+	if jenis == "" || jenis == "Penyewa" {
+		return "Sewa Kamar"
+	}
+	return jenis
+}
+
+// hapusPembayaran - Kurangi terbayar tagihan, hapus pembayaran beserta transaksi pemasukannya
+func hapusPembayaran(tx *gorm.DB, pembayaran models.Pembayaran) error {
+	var tagihan models.Tagihan
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&tagihan, pembayaran.TagihanID).Error; err != nil {
+		return err
+	}
+
+	tagihan.Terbayar -= pembayaran.Jumlah
+	if tagihan.Terbayar < 0 {
+		tagihan.Terbayar = 0
+	}
+	tagihan.Status = statusFromTerbayar(tagihan.Jumlah, tagihan.Terbayar)
+	if err := tx.Save(&tagihan).Error; err != nil {
+		return err
+	}
+	if err := lepasMutasiTransaksi(tx, "pembayaran_id = ?", pembayaran.ID); err != nil {
+		return err
+	}
+	if err := tx.Model(&models.PembayaranOnline{}).Where("pembayaran_id = ?", pembayaran.ID).
+		Updates(map[string]interface{}{"status": "Perlu Dicek", "pembayaran_id": nil, "catatan": "Pembayaran tagihan dibatalkan"}).Error; err != nil {
+		return err
+	}
+	// Bukti transfer yang disetujui kembali ke antrean verifikasi
+	if err := tx.Model(&models.BuktiPembayaran{}).Where("pembayaran_id = ?", pembayaran.ID).
+		Updates(map[string]interface{}{"status": "Menunggu", "pembayaran_id": nil, "diverifikasi_oleh": "", "diverifikasi_pada": nil}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("pembayaran_id = ?", pembayaran.ID).Delete(&models.Transaksi{}).Error; err != nil {
+		return err
+	}
+	return tx.Delete(&pembayaran).Error
+}
+
+// ubahPembayaran - Sesuaikan pembayaran & terbayar tagihan setelah transaksi tertautnya diubah
+func ubahPembayaran(tx *gorm.DB, transaksi models.Transaksi) error {
+	var pembayaran models.Pembayaran
+	if err := tx.First(&pembayaran, *transaksi.PembayaranID).Error; err != nil {
+		return err
+	}
+	var tagihan models.Tagihan
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&tagihan, pembayaran.TagihanID).Error; err != nil {
+		return err
+	}
+
+	terbayar := tagihan.Terbayar + transaksi.Jumlah - pembayaran.Jumlah
+	if terbayar > tagihan.Jumlah {
+		return errOverpayment
+	}
+	if terbayar < 0 {
+		return errTerbayarNegatif
+	}
+	if terbayar != tagihan.Terbayar {
+		tagihan.Terbayar = terbayar
+		tagihan.Status = statusFromTerbayar(tagihan.Jumlah, tagihan.Terbayar)
+		if err := tx.Save(&tagihan).Error; err != nil {
+			return err
+		}
+	}
+
+	pembayaran.Jumlah = transaksi.Jumlah
+	pembayaran.Tanggal = transaksi.Tanggal
+	pembayaran.RekeningID = transaksi.RekeningID
+	return tx.Save(&pembayaran).Error
+}
+
+// syncPembayaran - Catat selisih terbayar setelah tagihan dibuat/diubah langsung
+func syncPembayaran(tx *gorm.DB, tagihan models.Tagihan, terbayarLama int) error {
+	delta := tagihan.Terbayar - terbayarLama
+	if delta == 0 {
+		return nil
+	}
+	tanggal, err := paymentDate(tagihan.TanggalBayar)
+	if err != nil || delta < 0 {
+		tanggal = today()
+	}
+	keterangan := ""
+	if delta < 0 {
+		keterangan = "Koreksi pembayaran"
+	}
+	_, err = recordPembayaran(tx, tagihan, models.Pembayaran{Jumlah: delta, Tanggal: tanggal, Keterangan: keterangan}, nil)
+	return err
+}
+
+// totalPembayaran - Jumlah seluruh riwayat pembayaran satu tagihan
+func totalPembayaran(tx *gorm.DB, tagihanID uint) (int, error) {
+	var total int
+	err := tx.Model(&models.Pembayaran{}).Where("tagihan_id = ?", tagihanID).Select("COALESCE(SUM(jumlah), 0)").Scan(&total).Error
+	return total, err
+}
+
+func statusFromTerbayar(jumlah, terbayar int) string {
+	if terbayar >= jumlah {
+		return "Lunas"
+	}
+	if terbayar > 0 {
+		return "Cicil"
+	}
+	return "Belum Lunas"
+}
+
+// paymentDate - Parse tanggal pembayaran (YYYY-MM-DD), default hari ini
+func paymentDate(value string) (time.Time, error) {
+	if value == "" {
+		return today(), nil
+	}
+	if len(value) > 10 {
+		value = value[:10]
+	}
+	return time.Parse("2006-01-02", value)
+}
+
+func today() time.Time {
+	now := time.Now().In(time.FixedZone("WIB", 7*3600))
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+}
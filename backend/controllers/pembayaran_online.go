@@ -0,0 +1,286 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"kos-muhandis/backend/database"
+	"kos-muhandis/backend/models"
+	"kos-muhandis/backend/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// masaBerlakuOnline - Lama instruksi pembayaran online berlaku sebelum kedaluwarsa
+const masaBerlakuOnline = 24 * time.Hour
+
+var metodeOnline = map[string]bool{"va": true, "qris": true, "link": true}
+
+// GetPembayaranOnline - Daftar pembayaran online. Query: tagihan_id, gateway, status
+func GetPembayaranOnline(c *gin.Context) {
+	query := database.DB.Order("created_at DESC, id DESC")
+	if tagihanID := c.Query("tagihan_id"); tagihanID != "" {
+		query = query.Where("tagihan_id = ?", tagihanID)
+	}
+	if gateway := c.Query("gateway"); gateway != "" {
+		query = query.Where("gateway = ?", gateway)
+	}
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	var online []models.PembayaranOnline
+	if err := query.Find(&online).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch pembayaran online"})
+		return
+	}
+	c.JSON(http.StatusOK, online)
+}
+
+// CreatePembayaranOnline - Buat VA / QRIS / payment link untuk sisa tagihan. Permintaan yang
+// masih menunggu dengan jumlah sama dipakai ulang. Body: metode (va, qris, link), rekening_id
+// penampung dana (default rekening qris, lalu kas).
+func CreatePembayaranOnline(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	var input struct {
+		Metode     string `json:"metode"`
+		RekeningID *uint  `json:"rekening_id"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if input.Metode == "" {
+		input.Metode = "link"
+	}
+	if !metodeOnline[input.Metode] {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Metode must be va, qris or link"})
+		return
+	}
+	gateway, err := services.NewPaymentGateway()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var tagihan models.Tagihan
+	if err := database.DB.Preload("Penyewa").First(&tagihan, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Tagihan not found"})
+		return
+	}
+	sisa := tagihan.Jumlah - tagihan.Terbayar
+	if sisa <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Tagihan sudah lunas"})
+		return
+	}
+
+	var aktif models.PembayaranOnline
+	err = database.DB.Where("tagihan_id = ? AND gateway = ? AND metode = ? AND jumlah = ? AND status = ? AND (kedaluwarsa IS NULL OR kedaluwarsa > ?)",
+		tagihan.ID, gateway.Name(), input.Metode, sisa, "Menunggu", time.Now()).First(&aktif).Error
+	if err == nil {
+		c.JSON(http.StatusOK, aktif)
+		return
+	}
+
+	rekeningID := rekeningJenis(database.DB, "qris")
+	if input.RekeningID != nil {
+		var msg string
+		if rekeningID, msg = pilihRekening(input.RekeningID); msg != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+			return
+		}
+	}
+	if rekeningID == nil {
+		rekeningID = rekeningDefault(database.DB)
+	}
+
+	kedaluwarsa := time.Now().Add(masaBerlakuOnline)
+	orderID := fmt.Sprintf("TAG-%d-%d", tagihan.ID, time.Now().UnixNano()/int64(time.Millisecond))
+	request := services.PaymentRequest{
+		OrderID:      orderID,
+		Amount:       sisa,
+		Method:       input.Metode,
+		Description:  "Tagihan " + kategoriTagihan(tagihan.JenisTagihan) + " " + services.NamaBulan(tagihan.Bulan),
+		CustomerName: tagihan.Penyewa.Nama,
+		ExpiresAt:    kedaluwarsa,
+	}
+	if tagihan.Penyewa.NoHP != nil {
+		request.CustomerPhone = *tagihan.Penyewa.NoHP
+	}
+	charge, err := gateway.CreatePayment(request)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to create payment: " + err.Error()})
+		return
+	}
+	if !charge.ExpiresAt.IsZero() {
+		kedaluwarsa = charge.ExpiresAt
+	}
+
+	online := models.PembayaranOnline{
+		TagihanID:   tagihan.ID,
+		Gateway:     gateway.Name(),
+		Metode:      input.Metode,
+		OrderID:     orderID,
+		Referensi:   charge.Reference,
+		Jumlah:      sisa,
+		Status:      "Menunggu",
+		PaymentURL:  charge.PaymentURL,
+		NomorVA:     charge.VANumber,
+		QRString:    charge.QRString,
+		RekeningID:  rekeningID,
+		Kedaluwarsa: &kedaluwarsa,
+	}
+	if err := database.DB.Create(&online).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save pembayaran online"})
+		return
+	}
+	c.JSON(http.StatusCreated, online)
+}
+
+// PaymentWebhook - Callback payment gateway (publik). Tanda tangan diverifikasi oleh gateway;
+// callback berulang untuk order yang sudah diproses dijawab 200 tanpa mencatat ulang.
+func PaymentWebhook(c *gin.Context) {
+	gateway, err := services.NewPaymentGateway()
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		return
+	}
+	if c.Param("gateway") != gateway.Name() {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Unknown gateway"})
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, 1<<20))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read body"})
+		return
+	}
+	status, response := prosesWebhook(gateway, body, c.Request.Header)
+	c.JSON(status, response)
+}
+
+// SimulasiPembayaranOnline - Kirim webhook bertanda tangan dari FakeGateway untuk uji end-to-end
+// tanpa gateway sungguhan. Body: status (paid, expired, failed; default paid), jumlah (default
+// jumlah permintaan).
+func SimulasiPembayaranOnline(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	var input struct {
+		Status string `json:"status"`
+		Jumlah int    `json:"jumlah"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	gateway, err := services.NewPaymentGateway()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	fake, ok := gateway.(services.FakeGateway)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Simulasi hanya tersedia untuk gateway fake"})
+		return
+	}
+	var online models.PembayaranOnline
+	if err := database.DB.First(&online, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Pembayaran online not found"})
+		return
+	}
+	if input.Status == "" {
+		input.Status = services.PaymentPaid
+	}
+	if input.Jumlah == 0 {
+		input.Jumlah = online.Jumlah
+	}
+
+	body, header := fake.Simulate(online.OrderID, input.Status, input.Jumlah)
+	status, response := prosesWebhook(fake, body, header)
+	c.JSON(status, response)
+}
+
+// prosesWebhook - Verifikasi dan terapkan notifikasi gateway. Pembayaran lunas dicatat lewat
+// bayarTagihan ke rekening penampung; order dikunci agar callback ganda hanya diproses sekali.
+func prosesWebhook(gateway services.PaymentGateway, body []byte, header http.Header) (int, gin.H) {
+	notif, err := gateway.ParseWebhook(body, header)
+	if errors.Is(err, services.ErrInvalidSignature) {
+		return http.StatusUnauthorized, gin.H{"error": "Invalid signature"}
+	}
+	if err != nil {
+		return http.StatusBadRequest, gin.H{"error": "Invalid payload"}
+	}
+	if notif.Status == services.PaymentPaid && notif.Amount <= 0 {
+		return http.StatusBadRequest, gin.H{"error": "Amount must be positive"}
+	}
+
+	var online models.PembayaranOnline
+	diproses := false
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("gateway = ? AND order_id = ?", gateway.Name(), notif.OrderID).First(&online).Error; err != nil {
+			return err
+		}
+		if online.Status != "Menunggu" && online.Status != "Kedaluwarsa" {
+			return nil
+		}
+		if notif.Reference != "" {
+			online.Referensi = notif.Reference
+		}
+
+		switch notif.Status {
+		case services.PaymentPaid:
+			dibayar := notif.PaidAt
+			if dibayar.IsZero() {
+				dibayar = time.Now()
+			}
+			online.DibayarPada = &dibayar
+			if notif.Amount != online.Jumlah {
+				// Nominal dari gateway harus sama dengan order; selisih dicek manual, tidak dicatat otomatis
+				online.Status = "Perlu Dicek"
+				online.Catatan = fmt.Sprintf("Dana %d diterima, tidak sama dengan jumlah order %d", notif.Amount, online.Jumlah)
+				break
+			}
+			wib := dibayar.In(time.FixedZone("WIB", 7*3600))
+			_, pembayaran, err := bayarTagihan(tx, online.TagihanID, models.Pembayaran{
+				Jumlah:       notif.Amount,
+				Tanggal:      time.Date(wib.Year(), wib.Month(), wib.Day(), 0, 0, 0, 0, time.UTC),
+				DiterimaOleh: "Gateway " + gateway.Name(),
+				Keterangan:   "Pembayaran online " + online.OrderID,
+				RekeningID:   online.RekeningID,
+			}, nil)
+			switch {
+			case err == nil:
+				online.Status = "Lunas"
+				online.PembayaranID = &pembayaran.ID
+			case errors.Is(err, errOverpayment) || errors.Is(err, gorm.ErrRecordNotFound):
+				// Dana sudah diterima gateway, tapi tagihan sudah terbayar dari jalur lain atau dihapus
+				online.Status = "Perlu Dicek"
+				online.Catatan = fmt.Sprintf("Dana %d diterima tetapi tidak bisa dicatat: %v", notif.Amount, err)
+			default:
+				return err
+			}
+		case services.PaymentExpired:
+			online.Status = "Kedaluwarsa"
+		case services.PaymentFailed:
+			online.Status = "Gagal"
+		default:
+			return nil
+		}
+		diproses = true
+		return tx.Save(&online).Error
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return http.StatusNotFound, gin.H{"error": "Order not found"}
+	}
+	if err != nil {
+		return http.StatusInternalServerError, gin.H{"error": "Failed to process webhook"}
+	}
+	if !diproses {
+		return http.StatusOK, gin.H{"message": "Webhook ignored", "status": online.Status}
+	}
+	return http.StatusOK, gin.H{"message": "Webhook processed", "status": online.Status}
+}
@@ -0,0 +1,57 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"kos-muhandis/backend/database"
+	"kos-muhandis/backend/models"
+	"kos-muhandis/backend/services"
+)
+
+func TestProsesWebhookJumlah(t *testing.T) {
+	setupTestDB(t)
+	gateway := services.FakeGateway{Secret: "rahasia"}
+	penyewa := seedPenyewa(t, "Dewi")
+
+	tests := []struct {
+		name       string
+		jumlah     int
+		httpStatus int
+		status     string
+		terbayar   int
+	}{
+		{"sesuai order", 1000000, http.StatusOK, "Lunas", 1000000},
+		{"kurang dari order", 900000, http.StatusOK, "Perlu Dicek", 0},
+		{"lebih dari order", 1100000, http.StatusOK, "Perlu Dicek", 0},
+		{"nol", 0, http.StatusBadRequest, "Menunggu", 0},
+		{"negatif", -1000000, http.StatusBadRequest, "Menunggu", 0},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tagihan := seedTagihan(t, penyewa, fmt.Sprintf("2025-%02d", i+1), 1000000)
+			online := models.PembayaranOnline{
+				TagihanID: tagihan.ID, Gateway: gateway.Name(), OrderID: fmt.Sprintf("ORD-%d", i), Jumlah: 1000000,
+			}
+			if err := database.DB.Create(&online).Error; err != nil {
+				t.Fatal(err)
+			}
+
+			body, header := gateway.Simulate(online.OrderID, services.PaymentPaid, tt.jumlah)
+			status, resp := prosesWebhook(gateway, body, header)
+			if status != tt.httpStatus {
+				t.Fatalf("http status = %d, want %d: %v", status, tt.httpStatus, resp)
+			}
+
+			database.DB.First(&online, online.ID)
+			database.DB.First(&tagihan, tagihan.ID)
+			if online.Status != tt.status || tagihan.Terbayar != tt.terbayar {
+				t.Errorf("order %s, terbayar %d; want %s, %d", online.Status, tagihan.Terbayar, tt.status, tt.terbayar)
+			}
+			if tt.status == "Perlu Dicek" && (online.Catatan == "" || online.PembayaranID != nil) {
+				t.Errorf("catatan %q, pembayaran %v", online.Catatan, online.PembayaranID)
+			}
+		})
+	}
+}
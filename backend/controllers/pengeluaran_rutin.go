@@ -0,0 +1,134 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"kos-muhandis/backend/database"
+	"kos-muhandis/backend/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func GetPengeluaranRutin(c *gin.Context) {
+	var rutin []models.PengeluaranRutin
+	if err := database.DB.Order("nama ASC").Find(&rutin).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch pengeluaran rutin"})
+		return
+	}
+	c.JSON(http.StatusOK, rutin)
+}
+
+func CreatePengeluaranRutin(c *gin.Context) {
+	var input struct {
+		Nama          string  `json:"nama" binding:"required"`
+		Kategori      string  `json:"kategori" binding:"required"`
+		Jumlah        int     `json:"jumlah" binding:"required"`
+		IntervalBulan int     `json:"interval_bulan"`
+		Mulai         string  `json:"mulai" binding:"required"`
+		Selesai       *string `json:"selesai"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	mulai, err := time.Parse("2006-01-02", input.Mulai)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format"})
+		return
+	}
+	var selesai *time.Time
+	if input.Selesai != nil && *input.Selesai != "" {
+		parsed, err := time.Parse("2006-01-02", *input.Selesai)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format"})
+			return
+		}
+		selesai = &parsed
+	}
+	interval := input.IntervalBulan
+	if interval <= 0 {
+		interval = 1
+	}
+	rutin := models.PengeluaranRutin{
+		Nama:          input.Nama,
+		Kategori:      input.Kategori,
+		Jumlah:        input.Jumlah,
+		IntervalBulan: interval,
+		Mulai:         mulai,
+		Selesai:       selesai,
+	}
+	if err := database.DB.Create(&rutin).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create pengeluaran rutin"})
+		return
+	}
+	c.JSON(http.StatusCreated, rutin)
+}
+
+func UpdatePengeluaranRutin(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	var rutin models.PengeluaranRutin
+	if err := database.DB.First(&rutin, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Pengeluaran rutin not found"})
+		return
+	}
+	var input struct {
+		Nama          string  `json:"nama"`
+		Kategori      string  `json:"kategori"`
+		Jumlah        int     `json:"jumlah"`
+		IntervalBulan int     `json:"interval_bulan"`
+		Mulai         string  `json:"mulai"`
+		Selesai       *string `json:"selesai"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if input.Nama != "" {
+		rutin.Nama = input.Nama
+	}
+	if input.Kategori != "" {
+		rutin.Kategori = input.Kategori
+	}
+	if input.Jumlah != 0 {
+		rutin.Jumlah = input.Jumlah
+	}
+	if input.IntervalBulan > 0 {
+		rutin.IntervalBulan = input.IntervalBulan
+	}
+	if input.Mulai != "" {
+		mulai, err := time.Parse("2006-01-02", input.Mulai)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format"})
+			return
+		}
+		rutin.Mulai = mulai
+	}
+	if input.Selesai != nil {
+		if *input.Selesai == "" {
+			rutin.Selesai = nil
+		} else {
+			selesai, err := time.Parse("2006-01-02", *input.Selesai)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format"})
+				return
+			}
+			rutin.Selesai = &selesai
+		}
+	}
+	if err := database.DB.Save(&rutin).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update pengeluaran rutin"})
+		return
+	}
+	c.JSON(http.StatusOK, rutin)
+}
+
+func DeletePengeluaranRutin(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	if err := database.DB.Delete(&models.PengeluaranRutin{}, id).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete pengeluaran rutin"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Pengeluaran rutin deleted"})
+}
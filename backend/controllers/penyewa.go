@@ -99,12 +99,17 @@ func GetPenyewaByID(c *gin.Context) {
 
 func CreatePenyewa(c *gin.Context) {
 	var input struct {
-		Nama         string  `json:"nama" binding:"required"`
-		Email        *string `json:"email"`
-		NoHP         *string `json:"no_hp"`
-		Alamat       *string `json:"alamat"`
-		KamarID      uint    `json:"kamar_id" binding:"required"`
-		TanggalMasuk *string `json:"tanggal_masuk"`
+		Nama               string  `json:"nama" binding:"required"`
+		Email              *string `json:"email"`
+		NoHP               *string `json:"no_hp"`
+		Alamat             *string `json:"alamat"`
+		KamarID            uint    `json:"kamar_id" binding:"required"`
+		TanggalMasuk       *string `json:"tanggal_masuk"`
+		TanggalKeluar      *string `json:"tanggal_keluar"`
+		Bahasa             string  `json:"bahasa" binding:"omitempty,oneof=id en"`
+		KontakDaruratNama  *string `json:"kontak_darurat_nama"`
+		KontakDaruratNoHP  *string `json:"kontak_darurat_no_hp"`
+		KontakDaruratEmail *string `json:"kontak_darurat_email"`
 	}
 	if err := c.ShouldBindJSON(&input); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -121,13 +126,28 @@ func CreatePenyewa(c *gin.Context) {
 		tanggalMasuk = &parsedTime
 	}
 
+	var tanggalKeluar *time.Time
+	if input.TanggalKeluar != nil && *input.TanggalKeluar != "" {
+		parsedTime, err := time.Parse("2006-01-02", *input.TanggalKeluar)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format"})
+			return
+		}
+		tanggalKeluar = &parsedTime
+	}
+
 	penyewa := models.Penyewa{
-		Nama:         input.Nama,
-		Email:        input.Email,
-		NoHP:         input.NoHP,
-		Alamat:       input.Alamat,
-		KamarID:      input.KamarID,
-		TanggalMasuk: tanggalMasuk,
+		Nama:               input.Nama,
+		Email:              input.Email,
+		NoHP:               input.NoHP,
+		Alamat:             input.Alamat,
+		KamarID:            input.KamarID,
+		TanggalMasuk:       tanggalMasuk,
+		TanggalKeluar:      tanggalKeluar,
+		Bahasa:             input.Bahasa,
+		KontakDaruratNama:  input.KontakDaruratNama,
+		KontakDaruratNoHP:  input.KontakDaruratNoHP,
+		KontakDaruratEmail: input.KontakDaruratEmail,
 	}
 	if err := database.DB.Create(&penyewa).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create penyewa"})
@@ -150,12 +170,17 @@ func UpdatePenyewa(c *gin.Context) {
 		return
 	}
 	var input struct {
-		Nama         string  `json:"nama"`
-		Email        *string `json:"email"`
-		NoHP         *string `json:"no_hp"`
-		Alamat       *string `json:"alamat"`
-		KamarID      uint    `json:"kamar_id"`
-		TanggalMasuk *string `json:"tanggal_masuk"`
+		Nama               string  `json:"nama"`
+		Email              *string `json:"email"`
+		NoHP               *string `json:"no_hp"`
+		Alamat             *string `json:"alamat"`
+		KamarID            uint    `json:"kamar_id"`
+		TanggalMasuk       *string `json:"tanggal_masuk"`
+		TanggalKeluar      *string `json:"tanggal_keluar"`
+		Bahasa             string  `json:"bahasa" binding:"omitempty,oneof=id en"`
+		KontakDaruratNama  *string `json:"kontak_darurat_nama"`
+		KontakDaruratNoHP  *string `json:"kontak_darurat_no_hp"`
+		KontakDaruratEmail *string `json:"kontak_darurat_email"`
 	}
 	if err := c.ShouldBindJSON(&input); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -176,6 +201,18 @@ func UpdatePenyewa(c *gin.Context) {
 	if input.KamarID != 0 {
 		penyewa.KamarID = input.KamarID
 	}
+	if input.Bahasa != "" {
+		penyewa.Bahasa = input.Bahasa
+	}
+	if input.KontakDaruratNama != nil {
+		penyewa.KontakDaruratNama = input.KontakDaruratNama
+	}
+	if input.KontakDaruratNoHP != nil {
+		penyewa.KontakDaruratNoHP = input.KontakDaruratNoHP
+	}
+	if input.KontakDaruratEmail != nil {
+		penyewa.KontakDaruratEmail = input.KontakDaruratEmail
+	}
 	if input.TanggalMasuk != nil && *input.TanggalMasuk != "" {
 		tanggalMasuk, err := time.Parse("2006-01-02", *input.TanggalMasuk)
 		if err != nil {
@@ -184,6 +221,18 @@ func UpdatePenyewa(c *gin.Context) {
 		}
 		penyewa.TanggalMasuk = &tanggalMasuk
 	}
+	if input.TanggalKeluar != nil {
+		if *input.TanggalKeluar == "" {
+			penyewa.TanggalKeluar = nil
+		} else {
+			tanggalKeluar, err := time.Parse("2006-01-02", *input.TanggalKeluar)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format"})
+				return
+			}
+			penyewa.TanggalKeluar = &tanggalKeluar
+		}
+	}
 	if err := database.DB.Save(&penyewa).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update penyewa"})
 		return
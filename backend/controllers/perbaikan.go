@@ -0,0 +1,354 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"kos-muhandis/backend/database"
+	"kos-muhandis/backend/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const maxFotoSize = 5 << 20
+
+var prioritasPerbaikan = map[string]bool{"Rendah": true, "Sedang": true, "Tinggi": true, "Darurat": true}
+
+// statusPerbaikanBerikut - Alur status tiket: Dilaporkan -> Dikerjakan <-> Menunggu -> Selesai,
+// tiket yang belum selesai bisa Dibatalkan
+var statusPerbaikanBerikut = map[string][]string{
+	"Dilaporkan": {"Dikerjakan", "Dibatalkan"},
+	"Dikerjakan": {"Menunggu", "Selesai", "Dibatalkan"},
+	"Menunggu":   {"Dikerjakan", "Selesai", "Dibatalkan"},
+}
+
+var errStatusPerbaikan = errors.New("Perubahan status tidak diizinkan")
+
+// validasiError - Pesan validasi input yang muncul di dalam transaksi DB, dijawab 400 apa adanya
+type validasiError string
+
+func (e validasiError) Error() string { return string(e) }
+
+// GetPerbaikan - Daftar tiket perbaikan, filter kamar_id, status, prioritas
+func GetPerbaikan(c *gin.Context) {
+	query := database.DB.Preload("Kamar").Preload("Foto")
+	if kamarID := c.Query("kamar_id"); kamarID != "" {
+		query = query.Where("kamar_id = ?", kamarID)
+	}
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if prioritas := c.Query("prioritas"); prioritas != "" {
+		query = query.Where("prioritas = ?", prioritas)
+	}
+
+	var perbaikan []models.Perbaikan
+	if err := query.Order("created_at DESC").Find(&perbaikan).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch perbaikan"})
+		return
+	}
+	c.JSON(http.StatusOK, perbaikan)
+}
+
+// GetPerbaikanByID - Detail tiket perbaikan beserta foto
+func GetPerbaikanByID(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	var perbaikan models.Perbaikan
+	if err := database.DB.Preload("Kamar").Preload("Foto").First(&perbaikan, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Perbaikan not found"})
+		return
+	}
+	c.JSON(http.StatusOK, perbaikan)
+}
+
+// CreatePerbaikan - Laporkan kerusakan kamar (oleh penyewa atau staf).
+// set_perbaikan=true mengubah status kamar menjadi Perbaikan.
+func CreatePerbaikan(c *gin.Context) {
+	var input struct {
+		KamarID        uint   `json:"kamar_id" binding:"required"`
+		Judul          string `json:"judul" binding:"required"`
+		Deskripsi      string `json:"deskripsi"`
+		PenyewaID      *uint  `json:"penyewa_id"`
+		DilaporkanOleh string `json:"dilaporkan_oleh"`
+		Prioritas      string `json:"prioritas"`
+		Petugas        string `json:"petugas"`
+		SetPerbaikan   bool   `json:"set_perbaikan"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if input.Prioritas == "" {
+		input.Prioritas = "Sedang"
+	}
+	if !prioritasPerbaikan[input.Prioritas] {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Prioritas must be Rendah, Sedang, Tinggi or Darurat"})
+		return
+	}
+
+	var kamar models.Kamar
+	if err := database.DB.First(&kamar, input.KamarID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Kamar not found"})
+		return
+	}
+
+	perbaikan := models.Perbaikan{
+		KamarID:        input.KamarID,
+		Judul:          input.Judul,
+		Deskripsi:      input.Deskripsi,
+		Pelapor:        "staf",
+		DilaporkanOleh: input.DilaporkanOleh,
+		Prioritas:      input.Prioritas,
+		Status:         "Dilaporkan",
+		Petugas:        input.Petugas,
+	}
+	if input.PenyewaID != nil {
+		var penyewa models.Penyewa
+		if err := database.DB.First(&penyewa, *input.PenyewaID).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Penyewa not found"})
+			return
+		}
+		perbaikan.Pelapor = "penyewa"
+		perbaikan.PenyewaID = input.PenyewaID
+		if perbaikan.DilaporkanOleh == "" {
+			perbaikan.DilaporkanOleh = penyewa.Nama
+		}
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&perbaikan).Error; err != nil {
+			return err
+		}
+		if input.SetPerbaikan {
+			return tx.Model(&kamar).Update("status", "Perbaikan").Error
+		}
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create perbaikan"})
+		return
+	}
+	c.JSON(http.StatusCreated, perbaikan)
+}
+
+// UpdatePerbaikan - Ubah detail tiket (judul, deskripsi, prioritas, petugas, catatan)
+func UpdatePerbaikan(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	var perbaikan models.Perbaikan
+	if err := database.DB.First(&perbaikan, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Perbaikan not found"})
+		return
+	}
+	var input struct {
+		Judul     string `json:"judul"`
+		Deskripsi string `json:"deskripsi"`
+		Prioritas string `json:"prioritas"`
+		Petugas   string `json:"petugas"`
+		Catatan   string `json:"catatan"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if input.Prioritas != "" && !prioritasPerbaikan[input.Prioritas] {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Prioritas must be Rendah, Sedang, Tinggi or Darurat"})
+		return
+	}
+	if input.Judul != "" {
+		perbaikan.Judul = input.Judul
+	}
+	if input.Deskripsi != "" {
+		perbaikan.Deskripsi = input.Deskripsi
+	}
+	if input.Prioritas != "" {
+		perbaikan.Prioritas = input.Prioritas
+	}
+	if input.Petugas != "" {
+		perbaikan.Petugas = input.Petugas
+	}
+	if input.Catatan != "" {
+		perbaikan.Catatan = input.Catatan
+	}
+	if err := database.DB.Save(&perbaikan).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update perbaikan"})
+		return
+	}
+	c.JSON(http.StatusOK, perbaikan)
+}
+
+// UpdateStatusPerbaikan - Pindahkan tiket ke status berikutnya. Saat Selesai dengan biaya > 0
+// dibuat Transaksi pengeluaran (kategori default "Perbaikan"); kamar_tersedia=true
+// mengembalikan kamar berstatus Perbaikan menjadi Tersedia bila tidak ada tiket lain yang terbuka.
+func UpdateStatusPerbaikan(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	var input struct {
+		Status        string `json:"status" binding:"required"`
+		Petugas       string `json:"petugas"`
+		Catatan       string `json:"catatan"`
+		Biaya         int    `json:"biaya"`
+		Tanggal       string `json:"tanggal"`
+		Kategori      string `json:"kategori"`
+		RekeningID    *uint  `json:"rekening_id"`
+		KamarTersedia bool   `json:"kamar_tersedia"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if input.Biaya < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Biaya must not be negative"})
+		return
+	}
+	tanggal, err := paymentDate(input.Tanggal)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format"})
+		return
+	}
+	if input.Kategori == "" {
+		input.Kategori = "Perbaikan"
+	}
+
+	var perbaikan models.Perbaikan
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&perbaikan, id).Error; err != nil {
+			return err
+		}
+		allowed := false
+		for _, next := range statusPerbaikanBerikut[perbaikan.Status] {
+			if next == input.Status {
+				allowed = true
+			}
+		}
+		if !allowed {
+			return errStatusPerbaikan
+		}
+
+		perbaikan.Status = input.Status
+		if input.Petugas != "" {
+			perbaikan.Petugas = input.Petugas
+		}
+		if input.Catatan != "" {
+			perbaikan.Catatan = input.Catatan
+		}
+		if input.Status == "Dikerjakan" && perbaikan.TanggalMulai == nil {
+			perbaikan.TanggalMulai = &tanggal
+		}
+
+		if input.Status == "Selesai" {
+			perbaikan.TanggalSelesai = &tanggal
+			perbaikan.Biaya = input.Biaya
+			if input.Biaya > 0 {
+				transaksi := models.Transaksi{
+					Jenis:      "pengeluaran",
+					Jumlah:     input.Biaya,
+					Tanggal:    tanggal,
+					Keterangan: "Perbaikan kamar: " + perbaikan.Judul,
+				}
+				kamarID := perbaikan.KamarID
+				if msg := applyTransaksiRefs(&transaksi, nil, input.Kategori, nil, &kamarID); msg != "" {
+					return validasiError(msg)
+				}
+				if msg := applyRekening(&transaksi, input.RekeningID); msg != "" {
+					return validasiError(msg)
+				}
+				if err := tx.Create(&transaksi).Error; err != nil {
+					return err
+				}
+				perbaikan.TransaksiID = &transaksi.ID
+			}
+		}
+		if err := tx.Save(&perbaikan).Error; err != nil {
+			return err
+		}
+
+		if (input.Status == "Selesai" || input.Status == "Dibatalkan") && input.KamarTersedia {
+			var terbuka int64
+			if err := tx.Model(&models.Perbaikan{}).
+				Where("kamar_id = ? AND id <> ? AND status NOT IN ?", perbaikan.KamarID, perbaikan.ID, []string{"Selesai", "Dibatalkan"}).
+				Count(&terbuka).Error; err != nil {
+				return err
+			}
+			if terbuka == 0 {
+				return tx.Model(&models.Kamar{}).
+					Where("id = ? AND status = ?", perbaikan.KamarID, "Perbaikan").
+					Update("status", "Tersedia").Error
+			}
+		}
+		return nil
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Perbaikan not found"})
+		return
+	}
+	if errors.Is(err, errStatusPerbaikan) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("%s: %s -> %s", err.Error(), perbaikan.Status, input.Status)})
+		return
+	}
+	var verr validasiError
+	if errors.As(err, &verr) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": verr.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update status perbaikan"})
+		return
+	}
+	c.JSON(http.StatusOK, perbaikan)
+}
+
+// UploadFotoPerbaikan - Upload foto kerusakan/hasil perbaikan (multipart: file)
+func UploadFotoPerbaikan(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	var perbaikan models.Perbaikan
+	if err := database.DB.First(&perbaikan, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Perbaikan not found"})
+		return
+	}
+
+	upload, ok := readUpload(c, maxFotoSize, imageTypes)
+	if !ok {
+		return
+	}
+	key, err := storeUpload("perbaikan", perbaikan.ID, upload)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store file"})
+		return
+	}
+
+	foto := models.PerbaikanFoto{
+		PerbaikanID: perbaikan.ID,
+		NamaFile:    upload.NamaFile,
+		ContentType: upload.ContentType,
+		StorageKey:  key,
+	}
+	if err := database.DB.Create(&foto).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save foto"})
+		return
+	}
+	c.JSON(http.StatusCreated, foto)
+}
+
+// GetFotoPerbaikan - Tampilkan file foto perbaikan
+func GetFotoPerbaikan(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	var foto models.PerbaikanFoto
+	if err := database.DB.First(&foto, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Foto not found"})
+		return
+	}
+	serveUpload(c, foto.StorageKey, foto.NamaFile, foto.ContentType)
+}
+
+// DeletePerbaikan - Hapus tiket perbaikan (transaksi pengeluaran yang sudah dibuat tetap ada)
+func DeletePerbaikan(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	if err := database.DB.Delete(&models.Perbaikan{}, id).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete perbaikan"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Perbaikan deleted"})
+}
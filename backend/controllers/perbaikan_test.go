@@ -0,0 +1,58 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+
+	"kos-muhandis/backend/database"
+	"kos-muhandis/backend/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestUpdateStatusPerbaikanValidasi(t *testing.T) {
+	setupTestDB(t)
+	penyewa := seedPenyewa(t, "Budi")
+	perbaikan := models.Perbaikan{KamarID: penyewa.KamarID, Judul: "Keran bocor", Status: "Dikerjakan"}
+	if err := database.DB.Create(&perbaikan).Error; err != nil {
+		t.Fatal(err)
+	}
+	var akun models.Akun
+	if err := database.DB.Where("kode = ?", "1101").First(&akun).Error; err != nil {
+		t.Fatal(err)
+	}
+	nonaktif := models.Rekening{Nama: "Bank Lama", Jenis: "bank", AkunID: akun.ID}
+	if err := database.DB.Create(&nonaktif).Error; err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { database.DB.Unscoped().Delete(&nonaktif) })
+	database.DB.Model(&nonaktif).Update("aktif", false)
+	param := gin.Param{Key: "id", Value: strconv.Itoa(int(perbaikan.ID))}
+
+	tests := []struct {
+		name string
+		body gin.H
+		want string
+	}{
+		{"rekening tidak ada", gin.H{"status": "Selesai", "biaya": 50000, "rekening_id": 9999}, "Rekening not found"},
+		{"rekening tidak aktif", gin.H{"status": "Selesai", "biaya": 50000, "rekening_id": nonaktif.ID}, "Rekening tidak aktif"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := panggilHandler(UpdateStatusPerbaikan, http.MethodPut, "/perbaikan/1/status", tt.body, param)
+			cekStatus(t, w, http.StatusBadRequest)
+			var resp struct{ Error string }
+			decodeJSON(t, w, &resp)
+			if resp.Error != tt.want {
+				t.Errorf("error = %q, want %q", resp.Error, tt.want)
+			}
+		})
+	}
+
+	var saved models.Perbaikan
+	database.DB.First(&saved, perbaikan.ID)
+	if saved.Status != "Dikerjakan" || saved.TransaksiID != nil {
+		t.Errorf("perbaikan changed after rejected update: status %s transaksi %v", saved.Status, saved.TransaksiID)
+	}
+}
@@ -0,0 +1,72 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"kos-muhandis/backend/database"
+	"kos-muhandis/backend/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func GetPerubahanHarga(c *gin.Context) {
+	var perubahan []models.PerubahanHarga
+	query := database.DB.Order("berlaku_mulai ASC")
+	if kamarID := c.Query("kamar_id"); kamarID != "" {
+		query = query.Where("kamar_id = ?", kamarID)
+	}
+	if err := query.Find(&perubahan).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch perubahan harga"})
+		return
+	}
+	c.JSON(http.StatusOK, perubahan)
+}
+
+func CreatePerubahanHarga(c *gin.Context) {
+	var input struct {
+		KamarID      uint   `json:"kamar_id" binding:"required"`
+		HargaBaru    int    `json:"harga_baru" binding:"required"`
+		BerlakuMulai string `json:"berlaku_mulai" binding:"required"`
+		Keterangan   string `json:"keterangan"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if input.HargaBaru <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Harga baru must be greater than zero"})
+		return
+	}
+	berlakuMulai, err := time.Parse("2006-01-02", input.BerlakuMulai)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format"})
+		return
+	}
+	var kamar models.Kamar
+	if err := database.DB.First(&kamar, input.KamarID).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Kamar not found"})
+		return
+	}
+	perubahan := models.PerubahanHarga{
+		KamarID:      input.KamarID,
+		HargaBaru:    input.HargaBaru,
+		BerlakuMulai: berlakuMulai,
+		Keterangan:   input.Keterangan,
+	}
+	if err := database.DB.Create(&perubahan).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create perubahan harga"})
+		return
+	}
+	c.JSON(http.StatusCreated, perubahan)
+}
+
+func DeletePerubahanHarga(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	if err := database.DB.Delete(&models.PerubahanHarga{}, id).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete perubahan harga"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Perubahan harga deleted"})
+}
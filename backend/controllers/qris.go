@@ -0,0 +1,240 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"kos-muhandis/backend/database"
+	"kos-muhandis/backend/models"
+	"kos-muhandis/backend/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// masaBerlakuQRIS - Lama kode unik QRIS statis dicadangkan untuk satu tagihan
+const masaBerlakuQRIS = 7 * 24 * time.Hour
+
+var errKodeUnikHabis = errors.New("Semua kode unik sedang dipakai, coba lagi nanti")
+
+// CreateQRISTagihan - QRIS ber-nominal untuk sisa tagihan dari QRIS statis merchant.
+// Body: rekening_id (rekening qris yang punya qris_statis; default yang pertama), kode_unik
+// (default true: tambah 1-999 rupiah agar nominal unik dan mutasi bisa dicocokkan otomatis).
+// Permintaan yang masih menunggu untuk sisa yang sama dipakai ulang.
+func CreateQRISTagihan(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	var input struct {
+		RekeningID *uint `json:"rekening_id"`
+		KodeUnik   *bool `json:"kode_unik"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var rekening models.Rekening
+	query := database.DB.Where("aktif = ? AND COALESCE(qris_statis, '') <> ''", true)
+	if input.RekeningID != nil {
+		query = query.Where("id = ?", *input.RekeningID)
+	}
+	if err := query.Order("id ASC").First(&rekening).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Rekening dengan QRIS statis aktif tidak ditemukan"})
+		return
+	}
+
+	var tagihan models.Tagihan
+	if err := database.DB.First(&tagihan, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Tagihan not found"})
+		return
+	}
+	sisa := tagihan.Jumlah - tagihan.Terbayar
+	if sisa <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Tagihan sudah lunas"})
+		return
+	}
+	pakaiKode := input.KodeUnik == nil || *input.KodeUnik
+
+	var online models.PembayaranOnline
+	baru := false
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		// Kunci rekening agar dua permintaan bersamaan tidak mendapat nominal yang sama
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&models.Rekening{}, rekening.ID).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("gateway = ? AND rekening_id = ? AND status = ? AND kedaluwarsa > ?", "qris", rekening.ID, "Menunggu", time.Now()).
+			Where("tagihan_id = ? AND jumlah - kode_unik = ? AND (kode_unik > 0) = ?", tagihan.ID, sisa, pakaiKode).
+			First(&online).Error; err == nil {
+			return nil
+		}
+		baru = true
+
+		kode := 0
+		if pakaiKode {
+			var err error
+			if kode, err = kodeUnikQRIS(tx, rekening.ID, tagihan.ID, sisa); err != nil {
+				return err
+			}
+		}
+		payload, err := services.QRISDinamis(rekening.QRISStatis, sisa+kode)
+		if err != nil {
+			return err
+		}
+		kedaluwarsa := time.Now().Add(masaBerlakuQRIS)
+		online = models.PembayaranOnline{
+			TagihanID:   tagihan.ID,
+			Gateway:     "qris",
+			Metode:      "qris",
+			OrderID:     fmt.Sprintf("QRIS-%d-%d", tagihan.ID, time.Now().UnixNano()/int64(time.Millisecond)),
+			Jumlah:      sisa + kode,
+			KodeUnik:    kode,
+			Status:      "Menunggu",
+			QRString:    payload,
+			RekeningID:  &rekening.ID,
+			Kedaluwarsa: &kedaluwarsa,
+		}
+		return tx.Create(&online).Error
+	})
+	if errors.Is(err, errKodeUnikHabis) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create QRIS: " + err.Error()})
+		return
+	}
+	if baru {
+		c.JSON(http.StatusCreated, online)
+		return
+	}
+	c.JSON(http.StatusOK, online)
+}
+
+// kodeUnikQRIS - Kode 1-999 sehingga sisa+kode belum dipakai permintaan QRIS lain yang masih
+// menunggu di rekening yang sama. Pencarian dimulai dari id tagihan agar kodenya stabil.
+func kodeUnikQRIS(tx *gorm.DB, rekeningID, tagihanID uint, sisa int) (int, error) {
+	var dipakai []int
+	if err := tx.Model(&models.PembayaranOnline{}).
+		Where("gateway = ? AND rekening_id = ? AND status = ? AND kedaluwarsa > ?", "qris", rekeningID, "Menunggu", time.Now()).
+		Where("jumlah BETWEEN ? AND ?", sisa, sisa+999).
+		Pluck("jumlah", &dipakai).Error; err != nil {
+		return 0, err
+	}
+	terpakai := make(map[int]bool, len(dipakai))
+	for _, j := range dipakai {
+		terpakai[j] = true
+	}
+	mulai := int(tagihanID % 999)
+	for i := 0; i < 999; i++ {
+		kode := (mulai+i)%999 + 1
+		if !terpakai[sisa+kode] {
+			return kode, nil
+		}
+	}
+	return 0, errKodeUnikHabis
+}
+
+// cocokkanQRIS - Lunasi permintaan QRIS statis yang nominalnya sama persis dengan mutasi masuk
+// Belum Cocok di rekeningnya, dengan tanggal mutasi dalam masa berlaku (ditambah toleransi).
+func cocokkanQRIS(tx *gorm.DB, rekeningID uint) (int, error) {
+	var mutasi []models.MutasiRekening
+	if err := tx.Where("rekening_id = ? AND status = ? AND jumlah > 0", rekeningID, "Belum Cocok").
+		Order("tanggal, id").Find(&mutasi).Error; err != nil {
+		return 0, err
+	}
+	if len(mutasi) == 0 {
+		return 0, nil
+	}
+	var menunggu []models.PembayaranOnline
+	if err := tx.Where("gateway = ? AND rekening_id = ? AND status = ?", "qris", rekeningID, "Menunggu").
+		Order("created_at, id").Find(&menunggu).Error; err != nil {
+		return 0, err
+	}
+
+	cocok := 0
+	for i := range mutasi {
+		for j := range menunggu {
+			online := &menunggu[j]
+			if online.Status != "Menunggu" || online.Jumlah != mutasi[i].Jumlah || !dalamMasaQRIS(*online, mutasi[i].Tanggal) {
+				continue
+			}
+			lunas, err := lunasiQRIS(tx, online, &mutasi[i])
+			if err != nil {
+				return cocok, err
+			}
+			if lunas {
+				cocok++
+			}
+			break
+		}
+	}
+	return cocok, nil
+}
+
+// dalamMasaQRIS - Tanggal mutasi antara tanggal dibuat dan kedaluwarsa, ditambah toleransi hari
+func dalamMasaQRIS(online models.PembayaranOnline, tanggal time.Time) bool {
+	dibuat := time.Date(online.CreatedAt.Year(), online.CreatedAt.Month(), online.CreatedAt.Day(), 0, 0, 0, 0, time.UTC)
+	if tanggal.Before(dibuat.AddDate(0, 0, -1)) {
+		return false
+	}
+	return online.Kedaluwarsa == nil || !tanggal.After(online.Kedaluwarsa.AddDate(0, 0, toleransiCocok))
+}
+
+// lunasiQRIS - Catat pembayaran tagihan dari mutasi QRIS. Kode unik dicatat sebagai pemasukan
+// tersendiri. Bila tagihan sudah terbayar dari jalur lain, permintaan ditandai Perlu Dicek dan
+// mutasi dibiarkan Belum Cocok (false).
+func lunasiQRIS(tx *gorm.DB, online *models.PembayaranOnline, mutasi *models.MutasiRekening) (bool, error) {
+	tagihan, pembayaran, err := bayarTagihan(tx, online.TagihanID, models.Pembayaran{
+		Jumlah:       online.Jumlah - online.KodeUnik,
+		Tanggal:      mutasi.Tanggal,
+		DiterimaOleh: "QRIS",
+		Keterangan:   "Pembayaran QRIS " + online.OrderID,
+		RekeningID:   online.RekeningID,
+	}, nil)
+	if errors.Is(err, errOverpayment) || errors.Is(err, gorm.ErrRecordNotFound) {
+		online.Status = "Perlu Dicek"
+		online.Catatan = fmt.Sprintf("Mutasi %d tidak bisa dicatat: %v", mutasi.ID, err)
+		return false, tx.Save(online).Error
+	}
+	if err != nil {
+		return false, err
+	}
+
+	var transaksi models.Transaksi
+	if err := tx.Where("pembayaran_id = ?", pembayaran.ID).First(&transaksi).Error; err != nil {
+		return false, err
+	}
+	if online.KodeUnik > 0 {
+		kode := models.Transaksi{
+			Jenis:      "pemasukan",
+			Jumlah:     online.KodeUnik,
+			Tanggal:    mutasi.Tanggal,
+			Keterangan: "Kode unik QRIS " + online.OrderID,
+			RekeningID: online.RekeningID,
+		}
+		if msg := applyTransaksiRefs(&kode, nil, "Kode Unik Pembayaran", nil, &tagihan.KamarID); msg != "" {
+			return false, errors.New(msg)
+		}
+		if err := tx.Create(&kode).Error; err != nil {
+			return false, err
+		}
+		online.KodeTransaksiID = &kode.ID
+	}
+
+	mutasi.TransaksiID = &transaksi.ID
+	mutasi.TransferID = nil
+	mutasi.Status = "Cocok"
+	if err := tx.Save(mutasi).Error; err != nil {
+		return false, err
+	}
+	dibayar := mutasi.Tanggal
+	online.Status = "Lunas"
+	online.PembayaranID = &pembayaran.ID
+	online.MutasiID = &mutasi.ID
+	online.DibayarPada = &dibayar
+	return true, tx.Save(online).Error
+}
@@ -0,0 +1,347 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"kos-muhandis/backend/database"
+	"kos-muhandis/backend/models"
+	"kos-muhandis/backend/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+var jenisRekening = map[string]bool{"kas": true, "bank": true, "qris": true}
+
+// MutasiBuku - Satu baris mutasi rekening menurut pembukuan (jurnal akun rekening)
+type MutasiBuku struct {
+	Sumber     string    `json:"sumber"` // pembayaran, transaksi, transfer, manual
+	RefID      uint      `json:"ref_id"`
+	Tanggal    time.Time `json:"tanggal"`
+	Keterangan string    `json:"keterangan"`
+	Masuk      int       `json:"masuk"`
+	Keluar     int       `json:"keluar"`
+	Saldo      int       `json:"saldo"`
+}
+
+// GetRekening - Daftar rekening kas/bank beserta saldo per tanggal. Query: tanggal, aktif
+func GetRekening(c *gin.Context) {
+	asOf, ok := asOfDate(c)
+	if !ok {
+		return
+	}
+	query := database.DB.Preload("Akun").Order("id ASC")
+	if aktif := c.Query("aktif"); aktif != "" {
+		query = query.Where("aktif = ?", aktif == "true")
+	}
+	var rekening []models.Rekening
+	if err := query.Find(&rekening).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch rekening"})
+		return
+	}
+
+	saldo, err := saldoAkun("", asOf.Format("2006-01-02"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch saldo rekening"})
+		return
+	}
+	perAkun := make(map[uint]int)
+	for _, s := range saldo {
+		perAkun[s.AkunID] = s.Saldo
+	}
+	total := 0
+	for i := range rekening {
+		rekening[i].Saldo = perAkun[rekening[i].AkunID]
+		total += rekening[i].Saldo
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"tanggal":     asOf.Format("2006-01-02"),
+		"rekening":    rekening,
+		"total_saldo": total,
+	})
+}
+
+// CreateRekening - Tambah rekening. Tanpa akun_id dibuatkan akun aset baru (kode 11xx).
+// qris_statis (payload QRIS merchant) divalidasi format dan CRC-nya.
+// Saldo awal dicatat lewat jurnal manual (debit akun rekening, kredit Modal Pemilik).
+func CreateRekening(c *gin.Context) {
+	var input struct {
+		Nama       string `json:"nama" binding:"required"`
+		Jenis      string `json:"jenis" binding:"required"`
+		NoRekening string `json:"no_rekening"`
+		AtasNama   string `json:"atas_nama"`
+		AkunID     *uint  `json:"akun_id"`
+		QRISStatis string `json:"qris_statis"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	rekening := models.Rekening{
+		Nama:       strings.TrimSpace(input.Nama),
+		Jenis:      strings.ToLower(input.Jenis),
+		NoRekening: input.NoRekening,
+		AtasNama:   input.AtasNama,
+		QRISStatis: strings.TrimSpace(input.QRISStatis),
+		Aktif:      true,
+	}
+	if !jenisRekening[rekening.Jenis] {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Jenis must be kas, bank or qris"})
+		return
+	}
+	if rekening.QRISStatis != "" {
+		if err := services.ValidateQRIS(rekening.QRISStatis); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if input.AkunID != nil {
+			akunID, err := akunRekening(tx, *input.AkunID, 0)
+			if err != nil {
+				return err
+			}
+			rekening.AkunID = akunID
+		} else {
+			akun := models.Akun{Kode: kodeAkunBaru(tx), Nama: rekening.Nama, Tipe: "aset"}
+			if err := tx.Create(&akun).Error; err != nil {
+				return err
+			}
+			rekening.AkunID = akun.ID
+		}
+		return tx.Create(&rekening).Error
+	})
+	if errors.Is(err, errAkunRekening) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create rekening"})
+		return
+	}
+	database.DB.Preload("Akun").First(&rekening, rekening.ID)
+	c.JSON(http.StatusCreated, rekening)
+}
+
+// UpdateRekening - Ubah data rekening atau nonaktifkan (aktif=false)
+func UpdateRekening(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	var rekening models.Rekening
+	if err := database.DB.First(&rekening, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Rekening not found"})
+		return
+	}
+	var input struct {
+		Nama       string  `json:"nama"`
+		Jenis      string  `json:"jenis"`
+		NoRekening *string `json:"no_rekening"`
+		AtasNama   *string `json:"atas_nama"`
+		QRISStatis *string `json:"qris_statis"`
+		Aktif      *bool   `json:"aktif"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if input.Jenis != "" {
+		if !jenisRekening[strings.ToLower(input.Jenis)] {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Jenis must be kas, bank or qris"})
+			return
+		}
+		rekening.Jenis = strings.ToLower(input.Jenis)
+	}
+	if input.Nama != "" {
+		rekening.Nama = strings.TrimSpace(input.Nama)
+	}
+	if input.NoRekening != nil {
+		rekening.NoRekening = *input.NoRekening
+	}
+	if input.AtasNama != nil {
+		rekening.AtasNama = *input.AtasNama
+	}
+	if input.QRISStatis != nil {
+		payload := strings.TrimSpace(*input.QRISStatis)
+		if payload != "" {
+			if err := services.ValidateQRIS(payload); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+		}
+		rekening.QRISStatis = payload
+	}
+	if input.Aktif != nil {
+		rekening.Aktif = *input.Aktif
+	}
+	if err := database.DB.Save(&rekening).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update rekening"})
+		return
+	}
+	c.JSON(http.StatusOK, rekening)
+}
+
+// DeleteRekening - Hapus rekening yang belum pernah dipakai transaksi/transfer
+func DeleteRekening(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	var rekening models.Rekening
+	if err := database.DB.First(&rekening, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Rekening not found"})
+		return
+	}
+	var dipakai int64
+	database.DB.Model(&models.Transaksi{}).Where("rekening_id = ?", id).Count(&dipakai)
+	if dipakai == 0 {
+		database.DB.Model(&models.Transfer{}).Where("dari_rekening_id = ? OR ke_rekening_id = ?", id, id).Count(&dipakai)
+	}
+	if dipakai > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Rekening sudah dipakai, nonaktifkan saja"})
+		return
+	}
+	if err := database.DB.Delete(&rekening).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete rekening"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Rekening deleted"})
+}
+
+// GetMutasiRekening - Mutasi rekening menurut pembukuan dengan saldo berjalan.
+// Query: start_date, end_date (default awal tahun s/d hari ini)
+func GetMutasiRekening(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	var rekening models.Rekening
+	if err := database.DB.First(&rekening, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Rekening not found"})
+		return
+	}
+	start, end, ok := periodeLaporan(c)
+	if !ok {
+		return
+	}
+	args := map[string]interface{}{
+		"akun":  rekening.AkunID,
+		"start": start.Format("2006-01-02"),
+		"end":   end.Format("2006-01-02"),
+	}
+
+	var saldoAwal int
+	var mutasi []MutasiBuku
+	err := database.DB.Raw(`
+		WITH jurnal AS (`+jurnalQuery+`
+		)
+		SELECT COALESCE(SUM(debit - kredit), 0) FROM jurnal
+		WHERE akun_id = @akun AND tanggal < CAST(@start AS date)`, args).Scan(&saldoAwal).Error
+	if err == nil {
+		err = database.DB.Raw(`
+			WITH jurnal AS (`+jurnalQuery+`
+			)
+			SELECT sumber, ref_id, tanggal, keterangan, debit AS masuk, kredit AS keluar
+			FROM jurnal
+			WHERE akun_id = @akun AND tanggal BETWEEN CAST(@start AS date) AND CAST(@end AS date)
+			ORDER BY tanggal, sumber, ref_id`, args).Scan(&mutasi).Error
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch mutasi rekening"})
+		return
+	}
+
+	saldo, masuk, keluar := saldoAwal, 0, 0
+	for i := range mutasi {
+		saldo += mutasi[i].Masuk - mutasi[i].Keluar
+		mutasi[i].Saldo = saldo
+		masuk += mutasi[i].Masuk
+		keluar += mutasi[i].Keluar
+	}
+	if mutasi == nil {
+		mutasi = []MutasiBuku{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"rekening":     rekening,
+		"start_date":   args["start"],
+		"end_date":     args["end"],
+		"saldo_awal":   saldoAwal,
+		"total_masuk":  masuk,
+		"total_keluar": keluar,
+		"saldo_akhir":  saldo,
+		"mutasi":       mutasi,
+	})
+}
+
+var errAkunRekening = errors.New("Akun rekening harus akun aset yang belum dipakai rekening lain")
+
+// akunRekening - Pastikan akun bertipe aset dan belum dipakai rekening lain
+func akunRekening(tx *gorm.DB, akunID, rekeningID uint) (uint, error) {
+	var akun models.Akun
+	if err := tx.First(&akun, akunID).Error; err != nil || akun.Tipe != "aset" {
+		return 0, errAkunRekening
+	}
+	var dipakai int64
+	tx.Model(&models.Rekening{}).Where("akun_id = ? AND id <> ?", akunID, rekeningID).Count(&dipakai)
+	if dipakai > 0 {
+		return 0, errAkunRekening
+	}
+	return akun.ID, nil
+}
+
+// kodeAkunBaru - Kode akun kas/bank berikutnya (11xx)
+func kodeAkunBaru(tx *gorm.DB) string {
+	var kode []string
+	tx.Unscoped().Model(&models.Akun{}).Where("kode ~ '^11[0-9]{2}$'").Pluck("kode", &kode)
+	next := 1101
+	for _, k := range kode {
+		if n, err := strconv.Atoi(k); err == nil && n >= next {
+			next = n + 1
+		}
+	}
+	return fmt.Sprintf("%d", next)
+}
+
+// rekeningDefault - Rekening kas pertama, dipakai bila transaksi/pembayaran tidak menyebut rekening
+func rekeningDefault(tx *gorm.DB) *uint {
+	return rekeningJenis(tx, "kas")
+}
+
+// rekeningJenis - Rekening aktif pertama dengan jenis tertentu (kas, bank, qris)
+func rekeningJenis(tx *gorm.DB, jenis string) *uint {
+	var rekening models.Rekening
+	if err := tx.Where("jenis = ? AND aktif = ?", jenis, true).Order("id ASC").First(&rekening).Error; err != nil {
+		return nil
+	}
+	return &rekening.ID
+}
+
+// errTanpaRekening - Transaksi dan pembayaran wajib punya rekening; tidak ada lagi backfill saat start
+var errTanpaRekening = errors.New("Belum ada rekening kas aktif, pilih rekening_id")
+
+// pilihRekening - Validasi rekening aktif, default rekening kas. Mengembalikan pesan error.
+func pilihRekening(rekeningID *uint) (*uint, string) {
+	if rekeningID == nil || *rekeningID == 0 {
+		if id := rekeningDefault(database.DB); id != nil {
+			return id, ""
+		}
+		return nil, errTanpaRekening.Error()
+	}
+	var rekening models.Rekening
+	if err := database.DB.First(&rekening, *rekeningID).Error; err != nil {
+		return nil, "Rekening not found"
+	}
+	if !rekening.Aktif {
+		return nil, "Rekening tidak aktif"
+	}
+	return &rekening.ID, ""
+}
+
+// applyRekening - Tetapkan rekening transaksi (lihat pilihRekening)
+func applyRekening(transaksi *models.Transaksi, rekeningID *uint) string {
+	id, msg := pilihRekening(rekeningID)
+	if msg == "" {
+		transaksi.RekeningID = id
+	}
+	return msg
+}
@@ -0,0 +1,196 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"kos-muhandis/backend/database"
+	"kos-muhandis/backend/models"
+	"kos-muhandis/backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RekeningKoranEntry - Satu mutasi rekening koran penyewa
+type RekeningKoranEntry struct {
+	Tanggal    time.Time `json:"tanggal"`
+	Jenis      string    `json:"jenis"`  // tagihan, denda, kredit, pembayaran, koreksi
+	Sumber     string    `json:"sumber"` // tagihan, pembayaran
+	RefID      uint      `json:"ref_id"` // id tagihan/pembayaran
+	TagihanID  uint      `json:"tagihan_id"`
+	Keterangan string    `json:"keterangan"`
+	Debit      int       `json:"debit"`  // menambah kewajiban penyewa
+	Kredit     int       `json:"kredit"` // mengurangi kewajiban penyewa
+	Saldo      int       `json:"saldo"`
+}
+
+// RekeningKoranSummary - Ringkasan saldo rekening koran
+type RekeningKoranSummary struct {
+	PenyewaID   uint   `json:"penyewa_id"`
+	Nama        string `json:"nama"`
+	StartDate   string `json:"start_date"`
+	EndDate     string `json:"end_date"`
+	SaldoAwal   int    `json:"saldo_awal"`
+	TotalDebit  int    `json:"total_debit"`
+	TotalKredit int    `json:"total_kredit"`
+	SaldoAkhir  int    `json:"saldo_akhir"`
+}
+
+// rekeningKoranQuery - Mutasi penyewa: tagihan dicatat pada tanggal 1 periodenya, pembayaran
+// pada tanggal bayar. Tagihan berjenis Denda dicatat sebagai denda; jumlah negatif atau jenis
+// Kredit/Potongan sebagai kredit. Pembayaran negatif (koreksi) menambah kembali saldo.
+const rekeningKoranQuery = `
+	WITH mutasi AS (
+		SELECT TO_DATE(LEFT(t.bulan, 7), 'YYYY-MM') AS tanggal,
+			CASE
+				WHEN LOWER(t.jenis_tagihan) = 'denda' THEN 'denda'
+				WHEN t.jumlah < 0 OR LOWER(t.jenis_tagihan) IN ('kredit', 'potongan') THEN 'kredit'
+				ELSE 'tagihan'
+			END AS jenis,
+			'tagihan' AS sumber, t.id AS ref_id, t.id AS tagihan_id,
+			COALESCE(NULLIF(NULLIF(t.jenis_tagihan, ''), 'Penyewa'), 'Sewa kamar') || ' ' || LEFT(t.bulan, 7) AS keterangan,
+			CASE WHEN t.jumlah < 0 OR LOWER(t.jenis_tagihan) IN ('kredit', 'potongan') THEN -ABS(t.jumlah) ELSE t.jumlah END AS mutasi
+		FROM tagihans t
+		WHERE t.deleted_at IS NULL AND t.penyewa_id = @penyewa
+		UNION ALL
+		SELECT p.tanggal,
+			CASE WHEN p.jumlah < 0 THEN 'koreksi' ELSE 'pembayaran' END,
+			'pembayaran', COALESCE(p.pembayaran_id, p.tagihan_id), p.tagihan_id,
+			CASE WHEN p.jumlah < 0 THEN 'Koreksi pembayaran ' ELSE 'Pembayaran ' END || COALESCE(NULLIF(NULLIF(t.jenis_tagihan, ''), 'Penyewa'), 'sewa kamar') || ' ' || p.bulan,
+			-p.jumlah
+		FROM penerimaan_tagihan p
+		JOIN tagihans t ON t.id = p.tagihan_id
+		WHERE t.penyewa_id = @penyewa
+	),
+	berjalan AS (
+		SELECT *, SUM(mutasi) OVER (
+			ORDER BY tanggal, CASE WHEN sumber = 'tagihan' THEN 0 ELSE 1 END, ref_id
+			ROWS UNBOUNDED PRECEDING) AS saldo
+		FROM mutasi
+	)`
+
+// GetRekeningKoran - Rekening koran penyewa: saldo awal, tagihan, denda/kredit, pembayaran,
+// saldo berjalan dan saldo akhir. Query: start_date, end_date (YYYY-MM-DD, default dari
+// tanggal masuk sampai hari ini), format=pdf untuk versi cetak.
+func GetRekeningKoran(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid penyewa ID"})
+		return
+	}
+
+	var penyewa models.Penyewa
+	if err := database.DB.Preload("Kamar").First(&penyewa, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Penyewa not found"})
+		return
+	}
+
+	end := today()
+	if value := c.Query("end_date"); value != "" {
+		if end, err = time.Parse("2006-01-02", value); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid end_date format"})
+			return
+		}
+	}
+	start := time.Date(end.Year(), 1, 1, 0, 0, 0, 0, time.UTC)
+	if penyewa.TanggalMasuk != nil {
+		start = time.Date(penyewa.TanggalMasuk.Year(), penyewa.TanggalMasuk.Month(), 1, 0, 0, 0, 0, time.UTC)
+	}
+	if value := c.Query("start_date"); value != "" {
+		if start, err = time.Parse("2006-01-02", value); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid start_date format"})
+			return
+		}
+	}
+	if end.Before(start) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "end_date must not be before start_date"})
+		return
+	}
+
+	args := map[string]interface{}{
+		"penyewa": penyewa.ID,
+		"start":   start.Format("2006-01-02"),
+		"end":     end.AddDate(0, 0, 1).Format("2006-01-02"),
+	}
+
+	var summary RekeningKoranSummary
+	if err := database.DB.Raw(rekeningKoranQuery+`
+		SELECT
+			COALESCE(SUM(mutasi) FILTER (WHERE tanggal < @start), 0) AS saldo_awal,
+			COALESCE(SUM(mutasi) FILTER (WHERE mutasi > 0 AND tanggal >= @start AND tanggal < @end), 0) AS total_debit,
+			COALESCE(-SUM(mutasi) FILTER (WHERE mutasi < 0 AND tanggal >= @start AND tanggal < @end), 0) AS total_kredit
+		FROM mutasi`, args).Scan(&summary).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build rekening koran"})
+		return
+	}
+	summary.PenyewaID = penyewa.ID
+	summary.Nama = penyewa.Nama
+	summary.StartDate = start.Format("2006-01-02")
+	summary.EndDate = end.Format("2006-01-02")
+	summary.SaldoAkhir = summary.SaldoAwal + summary.TotalDebit - summary.TotalKredit
+
+	var entries []RekeningKoranEntry
+	if err := database.DB.Raw(rekeningKoranQuery+`
+		SELECT tanggal, jenis, sumber, ref_id, tagihan_id, keterangan,
+			GREATEST(mutasi, 0) AS debit, GREATEST(-mutasi, 0) AS kredit, saldo
+		FROM berjalan
+		WHERE tanggal >= @start AND tanggal < @end
+		ORDER BY tanggal, CASE WHEN sumber = 'tagihan' THEN 0 ELSE 1 END, ref_id`, args).Scan(&entries).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build rekening koran"})
+		return
+	}
+	if entries == nil {
+		entries = []RekeningKoranEntry{}
+	}
+
+	if c.Query("format") == "pdf" {
+		pdf := services.RenderRekeningKoranPDF(buildRekeningKoran(penyewa, summary, entries, start, end))
+		filename := fmt.Sprintf("rekening-koran-%d-%s-%s.pdf", penyewa.ID, summary.StartDate, summary.EndDate)
+		c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+		c.Data(http.StatusOK, "application/pdf", pdf)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"summary": summary,
+		"entries": entries,
+	})
+}
+
+func buildRekeningKoran(penyewa models.Penyewa, summary RekeningKoranSummary, entries []RekeningKoranEntry, start, end time.Time) services.RekeningKoran {
+	namaKos := os.Getenv("APP_NAME")
+	if namaKos == "" {
+		namaKos = "Kos Muhandis"
+	}
+	namaKamar := ""
+	if penyewa.Kamar != nil {
+		namaKamar = penyewa.Kamar.Nama
+	}
+
+	r := services.RekeningKoran{
+		NamaKos:     namaKos,
+		AlamatKos:   os.Getenv("KOS_ADDRESS"),
+		Tanggal:     services.FormatTanggal(time.Now()),
+		NamaPenyewa: penyewa.Nama,
+		NoHP:        stringValue(penyewa.NoHP),
+		NamaKamar:   namaKamar,
+		Periode:     services.FormatTanggal(start) + " - " + services.FormatTanggal(end),
+		SaldoAwal:   summary.SaldoAwal,
+		TotalDebit:  summary.TotalDebit,
+		TotalKredit: summary.TotalKredit,
+		SaldoAkhir:  summary.SaldoAkhir,
+	}
+	for _, e := range entries {
+		r.Baris = append(r.Baris, services.RekeningKoranBaris{
+			Tanggal:    services.FormatTanggal(e.Tanggal),
+			Keterangan: e.Keterangan,
+			Debit:      e.Debit,
+			Kredit:     e.Kredit,
+			Saldo:      e.Saldo,
+		})
+	}
+	return r
+}
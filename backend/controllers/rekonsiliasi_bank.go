@@ -0,0 +1,637 @@
+package controllers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"kos-muhandis/backend/database"
+	"kos-muhandis/backend/models"
+	"kos-muhandis/backend/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// toleransiCocok - Selisih hari maksimum antara mutasi bank dan transaksi saat cocok otomatis
+const toleransiCocok = 3
+
+var mutasiBankFields = []importField{
+	{Name: "tanggal", Required: true, Description: "Tanggal mutasi"},
+	{Name: "keterangan", Description: "Keterangan / berita transfer"},
+	{Name: "referensi", Description: "Nomor referensi bank"},
+	{Name: "jumlah", Description: "Nominal; negatif, akhiran DB, atau kolom tipe DB berarti uang keluar"},
+	{Name: "tipe", Description: "DB/CR (D/K) untuk kolom jumlah"},
+	{Name: "debit", Description: "Uang keluar (bila tanpa kolom jumlah)"},
+	{Name: "kredit", Description: "Uang masuk (bila tanpa kolom jumlah)"},
+	{Name: "saldo", Description: "Saldo setelah mutasi"},
+}
+
+// MutasiBankReport - Hasil import rekening koran bank
+type MutasiBankReport struct {
+	DryRun    bool              `json:"dry_run"`
+	TotalRows int               `json:"total_rows"`
+	ValidRows int               `json:"valid_rows"`
+	Errors    []ImportRowError  `json:"errors"`
+	Baru      int               `json:"baru"`
+	Duplikat  int               `json:"duplikat"`   // sudah pernah diimport
+	Cocok     int               `json:"cocok"`      // dicocokkan otomatis
+	CocokQRIS int               `json:"cocok_qris"` // melunasi permintaan QRIS statis
+	Mapping   map[string]string `json:"mapping"`
+}
+
+// ImportMutasiBank - Import rekening koran (CSV/XLSX) untuk satu rekening lalu cocokkan otomatis
+// dengan permintaan QRIS statis ber-nominal unik, lalu transaksi/transfer yang nominalnya sama
+// dan tanggalnya berdekatan.
+// Form field: file, mapping (JSON {"field": "Header Kolom"}), dry_run (default true).
+// Baris yang sudah pernah diimport dilewati.
+func ImportMutasiBank(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	var rekening models.Rekening
+	if err := database.DB.First(&rekening, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Rekening not found"})
+		return
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "File is required"})
+		return
+	}
+	if fileHeader.Size > maxImportFileSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "File too large (max 10 MB)"})
+		return
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read file"})
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read file"})
+		return
+	}
+	rows, err := services.ReadSpreadsheet(fileHeader.Filename, data)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(rows) < 2 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "File has no data rows"})
+		return
+	}
+
+	userMapping := map[string]string{}
+	if raw := c.PostForm("mapping"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &userMapping); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid mapping: " + err.Error()})
+			return
+		}
+	}
+	columns, mapping, mappingErrors := resolveImportColumns(mutasiBankFields, rows[0], userMapping)
+	if _, ok := columns["jumlah"]; !ok && len(mappingErrors) == 0 {
+		_, debit := columns["debit"]
+		_, kredit := columns["kredit"]
+		if !debit && !kredit {
+			mappingErrors = append(mappingErrors, ImportRowError{Row: 1, Field: "jumlah", Message: "Kolom jumlah atau debit/kredit tidak ditemukan"})
+		}
+	}
+	report := MutasiBankReport{
+		DryRun:  c.DefaultPostForm("dry_run", "true") != "false",
+		Mapping: mapping,
+		Errors:  mappingErrors,
+	}
+	if len(mappingErrors) > 0 {
+		c.JSON(http.StatusBadRequest, report)
+		return
+	}
+
+	mutasi, rowErrors := parseMutasiBank(rekening.ID, rows, columns)
+	report.Errors = rowErrors
+	report.TotalRows = len(mutasi) + countErrorRows(rowErrors)
+	report.ValidRows = len(mutasi)
+
+	hashes := make([]string, 0, len(mutasi))
+	for _, m := range mutasi {
+		hashes = append(hashes, m.Hash)
+	}
+	var existing []string
+	if len(hashes) > 0 {
+		database.DB.Model(&models.MutasiRekening{}).Where("rekening_id = ? AND hash IN ?", rekening.ID, hashes).Pluck("hash", &existing)
+	}
+	sudahAda := make(map[string]bool, len(existing))
+	for _, h := range existing {
+		sudahAda[h] = true
+	}
+	var baru []models.MutasiRekening
+	for _, m := range mutasi {
+		if sudahAda[m.Hash] {
+			report.Duplikat++
+			continue
+		}
+		baru = append(baru, m)
+	}
+	report.Baru = len(baru)
+
+	if report.DryRun {
+		c.JSON(http.StatusOK, report)
+		return
+	}
+	if len(rowErrors) > 0 {
+		c.JSON(http.StatusUnprocessableEntity, report)
+		return
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if len(baru) > 0 {
+			if err := tx.Create(&baru).Error; err != nil {
+				return err
+			}
+		}
+		// Nominal unik QRIS lebih pasti, dicocokkan sebelum transaksi/transfer biasa
+		var err error
+		if report.CocokQRIS, err = cocokkanQRIS(tx, rekening.ID); err != nil {
+			return err
+		}
+		report.Cocok, err = cocokkanOtomatis(tx, rekening.ID)
+		return err
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to import mutasi bank"})
+		return
+	}
+	c.JSON(http.StatusCreated, report)
+}
+
+// parseMutasiBank - Validasi baris rekening koran. Hash dibentuk dari isi baris ditambah urutan
+// kemunculan baris identik, sehingga import ulang file yang sama tidak menggandakan data.
+func parseMutasiBank(rekeningID uint, rows [][]string, columns map[string]int) ([]models.MutasiRekening, []ImportRowError) {
+	var mutasi []models.MutasiRekening
+	kembar := make(map[string]int)
+	errs := eachImportRow(rows, columns, func(r *importRow) {
+		if r.required("tanggal") == "" {
+			return
+		}
+		tanggal := r.date("tanggal")
+
+		jumlah := 0
+		if value := r.get("jumlah"); value != "" {
+			// Format BCA: "1,500,000.00 DB" / "1,500,000.00 CR"
+			upper := strings.ToUpper(value)
+			keluar := strings.HasSuffix(upper, "DB") || strings.HasPrefix(strings.ToUpper(r.get("tipe")), "D")
+			nominal := strings.TrimSpace(strings.TrimSuffix(strings.TrimSuffix(upper, "DB"), "CR"))
+			n, err := parseImportAmount(nominal)
+			if err != nil {
+				r.fail("jumlah", fmt.Sprintf("Jumlah tidak valid: %q", value))
+			}
+			if n > 0 && keluar {
+				n = -n
+			}
+			jumlah = n
+		} else {
+			jumlah = r.amount("kredit", false) - r.amount("debit", false)
+		}
+		if jumlah == 0 && !r.hasError("jumlah") && !r.hasError("debit") && !r.hasError("kredit") {
+			r.fail("jumlah", "Jumlah wajib diisi")
+		}
+
+		var saldo *int
+		if value := r.get("saldo"); value != "" {
+			n, err := parseImportAmount(value)
+			if err != nil {
+				r.fail("saldo", fmt.Sprintf("Saldo tidak valid: %q", value))
+			}
+			saldo = &n
+		}
+		if len(r.errors) > 0 || tanggal == nil {
+			return
+		}
+
+		m := models.MutasiRekening{
+			RekeningID: rekeningID,
+			Tanggal:    *tanggal,
+			Keterangan: r.get("keterangan"),
+			Referensi:  r.get("referensi"),
+			Jumlah:     jumlah,
+			Saldo:      saldo,
+			Status:     "Belum Cocok",
+		}
+		kunci := fmt.Sprintf("%s|%d|%s|%s", m.Tanggal.Format("2006-01-02"), m.Jumlah, m.Keterangan, m.Referensi)
+		if saldo != nil {
+			kunci += fmt.Sprintf("|%d", *saldo)
+		}
+		kembar[kunci]++
+		sum := sha256.Sum256([]byte(fmt.Sprintf("%s#%d", kunci, kembar[kunci])))
+		m.Hash = hex.EncodeToString(sum[:])
+		mutasi = append(mutasi, m)
+	})
+	return mutasi, errs
+}
+
+// kandidatCocok - Transaksi/transfer pembukuan yang belum punya pasangan mutasi bank
+type kandidatCocok struct {
+	TransaksiID *uint
+	TransferID  *uint
+	Tanggal     time.Time
+	Nilai       int // positif = masuk ke rekening
+	dipakai     bool
+}
+
+// cocokkanOtomatis - Pasangkan mutasi Belum Cocok dengan transaksi/transfer di rekening yang sama
+// bernominal sama, memilih tanggal terdekat dalam toleransi. Mengembalikan jumlah baris yang cocok.
+func cocokkanOtomatis(tx *gorm.DB, rekeningID uint) (int, error) {
+	var mutasi []models.MutasiRekening
+	if err := tx.Where("rekening_id = ? AND status = ?", rekeningID, "Belum Cocok").Order("tanggal, id").Find(&mutasi).Error; err != nil {
+		return 0, err
+	}
+	if len(mutasi) == 0 {
+		return 0, nil
+	}
+
+	args := map[string]interface{}{
+		"rekening": rekeningID,
+		"mulai":    mutasi[0].Tanggal.AddDate(0, 0, -toleransiCocok).Format("2006-01-02"),
+		"sampai":   mutasi[len(mutasi)-1].Tanggal.AddDate(0, 0, toleransiCocok).Format("2006-01-02"),
+	}
+	var kandidat []kandidatCocok
+	if err := tx.Raw(`
+		SELECT t.id AS transaksi_id, NULL AS transfer_id, t.tanggal,
+			CASE WHEN LOWER(t.jenis) = 'pengeluaran' THEN -t.jumlah ELSE t.jumlah END AS nilai
+		FROM transaksis t
+		WHERE t.deleted_at IS NULL AND t.rekening_id = @rekening
+			AND t.tanggal BETWEEN CAST(@mulai AS date) AND CAST(@sampai AS date)
+			AND NOT `+transaksiTercocok+`
+		UNION ALL
+		SELECT NULL, f.id, f.tanggal,
+			CASE WHEN f.ke_rekening_id = @rekening THEN f.jumlah ELSE -(f.jumlah + f.biaya) END
+		FROM transfers f
+		WHERE f.deleted_at IS NULL AND (f.dari_rekening_id = @rekening OR f.ke_rekening_id = @rekening)
+			AND f.tanggal BETWEEN CAST(@mulai AS date) AND CAST(@sampai AS date)
+			AND NOT EXISTS (SELECT 1 FROM mutasi_rekenings m WHERE m.transfer_id = f.id AND m.rekening_id = @rekening)
+		ORDER BY 3`, args).Scan(&kandidat).Error; err != nil {
+		return 0, err
+	}
+
+	cocok := 0
+	for i := range mutasi {
+		best := -1
+		bestSelisih := toleransiCocok + 1
+		for j := range kandidat {
+			k := &kandidat[j]
+			if k.dipakai || k.Nilai != mutasi[i].Jumlah {
+				continue
+			}
+			selisih := int(mutasi[i].Tanggal.Sub(k.Tanggal).Hours() / 24)
+			if selisih < 0 {
+				selisih = -selisih
+			}
+			if selisih < bestSelisih {
+				best, bestSelisih = j, selisih
+			}
+		}
+		if best < 0 {
+			continue
+		}
+		kandidat[best].dipakai = true
+		mutasi[i].TransaksiID = kandidat[best].TransaksiID
+		mutasi[i].TransferID = kandidat[best].TransferID
+		mutasi[i].Status = "Cocok"
+		if err := tx.Save(&mutasi[i]).Error; err != nil {
+			return cocok, err
+		}
+		cocok++
+	}
+	return cocok, nil
+}
+
+// GetMutasiBank - Baris rekening koran yang sudah diimport. Query: status, start_date, end_date
+func GetMutasiBank(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	query := database.DB.Preload("Transaksi").Preload("Transfer").Where("rekening_id = ?", id)
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if startDate := c.Query("start_date"); startDate != "" {
+		query = query.Where("tanggal >= ?", startDate)
+	}
+	if endDate := c.Query("end_date"); endDate != "" {
+		query = query.Where("tanggal <= ?", endDate)
+	}
+	var mutasi []models.MutasiRekening
+	if err := query.Order("tanggal ASC, id ASC").Find(&mutasi).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch mutasi bank"})
+		return
+	}
+	c.JSON(http.StatusOK, mutasi)
+}
+
+var (
+	errMutasiSudahCocok = errors.New("Mutasi sudah dicocokkan, batalkan dulu pasangannya")
+	errPasanganMutasi   = errors.New("Transaksi/transfer tidak sesuai dengan mutasi bank")
+	errQRISTerbayar     = errors.New("Tagihan sudah terbayar, pembayaran QRIS tidak bisa dicatat")
+)
+
+// CocokkanMutasiBank - Pasangkan manual satu mutasi bank. Body: {"transaksi_id": 1}, {"transfer_id": 1}
+// atau {"pembayaran_online_id": 1} (QRIS statis yang masih menunggu, sekaligus melunasi tagihannya).
+// Rekening dan nominal harus sama, dan pasangan belum dipakai mutasi lain.
+func CocokkanMutasiBank(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	var input struct {
+		TransaksiID *uint `json:"transaksi_id"`
+		TransferID  *uint `json:"transfer_id"`
+		OnlineID    *uint `json:"pembayaran_online_id"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	dipilih := 0
+	for _, ref := range []*uint{input.TransaksiID, input.TransferID, input.OnlineID} {
+		if ref != nil {
+			dipilih++
+		}
+	}
+	if dipilih != 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Isi salah satu dari transaksi_id, transfer_id atau pembayaran_online_id"})
+		return
+	}
+
+	var mutasi models.MutasiRekening
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&mutasi, id).Error; err != nil {
+			return err
+		}
+		if mutasi.Status == "Cocok" {
+			return errMutasiSudahCocok
+		}
+
+		if input.OnlineID != nil {
+			var online models.PembayaranOnline
+			if err := tx.First(&online, *input.OnlineID).Error; err != nil {
+				return errPasanganMutasi
+			}
+			if online.Gateway != "qris" || online.Status != "Menunggu" || online.RekeningID == nil ||
+				*online.RekeningID != mutasi.RekeningID || online.Jumlah != mutasi.Jumlah {
+				return errPasanganMutasi
+			}
+			lunas, err := lunasiQRIS(tx, &online, &mutasi)
+			if err == nil && !lunas {
+				err = errQRISTerbayar
+			}
+			return err
+		}
+
+		var dipakai int64
+		if input.TransaksiID != nil {
+			var transaksi models.Transaksi
+			if err := tx.First(&transaksi, *input.TransaksiID).Error; err != nil {
+				return errPasanganMutasi
+			}
+			nilai := transaksi.Jumlah
+			if strings.EqualFold(transaksi.Jenis, "pengeluaran") {
+				nilai = -nilai
+			}
+			if transaksi.RekeningID == nil || *transaksi.RekeningID != mutasi.RekeningID || nilai != mutasi.Jumlah {
+				return errPasanganMutasi
+			}
+			tx.Model(&models.MutasiRekening{}).Where("transaksi_id = ?", transaksi.ID).Count(&dipakai)
+		} else {
+			var transfer models.Transfer
+			if err := tx.First(&transfer, *input.TransferID).Error; err != nil {
+				return errPasanganMutasi
+			}
+			nilai := 0
+			switch mutasi.RekeningID {
+			case transfer.KeRekeningID:
+				nilai = transfer.Jumlah
+			case transfer.DariRekeningID:
+				nilai = -(transfer.Jumlah + transfer.Biaya)
+			}
+			if nilai == 0 || nilai != mutasi.Jumlah {
+				return errPasanganMutasi
+			}
+			tx.Model(&models.MutasiRekening{}).Where("transfer_id = ? AND rekening_id = ?", transfer.ID, mutasi.RekeningID).Count(&dipakai)
+		}
+		if dipakai > 0 {
+			return errPasanganMutasi
+		}
+
+		mutasi.TransaksiID = input.TransaksiID
+		mutasi.TransferID = input.TransferID
+		mutasi.Status = "Cocok"
+		return tx.Save(&mutasi).Error
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Mutasi not found"})
+		return
+	}
+	if errors.Is(err, errMutasiSudahCocok) || errors.Is(err, errPasanganMutasi) || errors.Is(err, errQRISTerbayar) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to match mutasi"})
+		return
+	}
+	c.JSON(http.StatusOK, mutasi)
+}
+
+// CatatMutasiBank - Buat transaksi dari mutasi bank yang belum tercatat (biaya admin, bunga, dll)
+// lalu langsung cocokkan. Body: kategori / kategori_id, keterangan
+func CatatMutasiBank(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	var input struct {
+		Kategori   string `json:"kategori"`
+		KategoriID *uint  `json:"kategori_id"`
+		Keterangan string `json:"keterangan"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	var mutasi models.MutasiRekening
+	if err := database.DB.First(&mutasi, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Mutasi not found"})
+		return
+	}
+	if mutasi.Status == "Cocok" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errMutasiSudahCocok.Error()})
+		return
+	}
+
+	transaksi := models.Transaksi{
+		Jenis:      "pemasukan",
+		Jumlah:     mutasi.Jumlah,
+		Tanggal:    mutasi.Tanggal,
+		Keterangan: input.Keterangan,
+		RekeningID: &mutasi.RekeningID,
+	}
+	if mutasi.Jumlah < 0 {
+		transaksi.Jenis = "pengeluaran"
+		transaksi.Jumlah = -mutasi.Jumlah
+	}
+	if transaksi.Keterangan == "" {
+		transaksi.Keterangan = mutasi.Keterangan
+	}
+	if msg := applyTransaksiRefs(&transaksi, input.KategoriID, input.Kategori, nil, nil); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&transaksi).Error; err != nil {
+			return err
+		}
+		mutasi.TransaksiID = &transaksi.ID
+		mutasi.Status = "Cocok"
+		return tx.Save(&mutasi).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create transaksi"})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"mutasi": mutasi, "transaksi": transaksi})
+}
+
+// BatalCocokMutasiBank - Lepas pasangan mutasi bank (atau batal abaikan)
+func BatalCocokMutasiBank(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	result := database.DB.Model(&models.MutasiRekening{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"transaksi_id": nil, "transfer_id": nil, "status": "Belum Cocok"})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unmatch mutasi"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Mutasi not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Mutasi unmatched"})
+}
+
+// AbaikanMutasiBank - Tandai mutasi bank yang memang tidak perlu dicatat
+func AbaikanMutasiBank(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	result := database.DB.Model(&models.MutasiRekening{}).Where("id = ? AND status = ?", id, "Belum Cocok").
+		Update("status", "Diabaikan")
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update mutasi"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Hanya mutasi Belum Cocok yang bisa diabaikan"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Mutasi diabaikan"})
+}
+
+// GetRekonsiliasiBank - Ringkasan rekonsiliasi rekening: saldo buku vs saldo bank, mutasi bank
+// yang belum cocok, dan transaksi/transfer pembukuan yang belum muncul di rekening koran.
+// Query: start_date, end_date (default awal tahun s/d hari ini)
+func GetRekonsiliasiBank(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	var rekening models.Rekening
+	if err := database.DB.First(&rekening, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Rekening not found"})
+		return
+	}
+	start, end, ok := periodeLaporan(c)
+	if !ok {
+		return
+	}
+	args := map[string]interface{}{
+		"rekening": rekening.ID,
+		"akun":     rekening.AkunID,
+		"start":    start.Format("2006-01-02"),
+		"end":      end.Format("2006-01-02"),
+	}
+
+	var saldoBuku int
+	database.DB.Raw(`
+		WITH jurnal AS (`+jurnalQuery+`
+		)
+		SELECT COALESCE(SUM(debit - kredit), 0) FROM jurnal
+		WHERE akun_id = @akun AND tanggal <= CAST(@end AS date)`, args).Scan(&saldoBuku)
+
+	var saldoBank *int
+	var terakhir models.MutasiRekening
+	if err := database.DB.Where("rekening_id = ? AND tanggal <= ? AND saldo IS NOT NULL", rekening.ID, args["end"]).
+		Order("tanggal DESC, id DESC").First(&terakhir).Error; err == nil {
+		saldoBank = terakhir.Saldo
+	}
+
+	var ringkasan []struct {
+		Status string `json:"status"`
+		Jumlah int    `json:"jumlah"`
+		Nilai  int    `json:"nilai"`
+	}
+	database.DB.Raw(`
+		SELECT status, COUNT(*) AS jumlah, COALESCE(SUM(jumlah), 0) AS nilai
+		FROM mutasi_rekenings
+		WHERE rekening_id = @rekening AND tanggal BETWEEN CAST(@start AS date) AND CAST(@end AS date)
+		GROUP BY status`, args).Scan(&ringkasan)
+
+	var belumCocok []models.MutasiRekening
+	database.DB.Where("rekening_id = ? AND status = ? AND tanggal BETWEEN ? AND ?", rekening.ID, "Belum Cocok", args["start"], args["end"]).
+		Order("tanggal, id").Find(&belumCocok)
+
+	var belumDiBank []MutasiBuku
+	if err := database.DB.Raw(`
+		SELECT CASE WHEN t.pembayaran_id IS NULL THEN 'transaksi' ELSE 'pembayaran' END AS sumber, t.id AS ref_id, t.tanggal,
+			COALESCE(NULLIF(t.keterangan, ''), t.kategori) AS keterangan,
+			CASE WHEN LOWER(t.jenis) = 'pengeluaran' THEN 0 ELSE t.jumlah END AS masuk,
+			CASE WHEN LOWER(t.jenis) = 'pengeluaran' THEN t.jumlah ELSE 0 END AS keluar
+		FROM transaksis t
+		WHERE t.deleted_at IS NULL AND t.rekening_id = @rekening
+			AND t.tanggal BETWEEN CAST(@start AS date) AND CAST(@end AS date)
+			AND NOT `+transaksiTercocok+`
+		UNION ALL
+		SELECT 'transfer', f.id, f.tanggal, COALESCE(NULLIF(f.keterangan, ''), 'Transfer'),
+			CASE WHEN f.ke_rekening_id = @rekening THEN f.jumlah ELSE 0 END,
+			CASE WHEN f.dari_rekening_id = @rekening THEN f.jumlah + f.biaya ELSE 0 END
+		FROM transfers f
+		WHERE f.deleted_at IS NULL AND (f.dari_rekening_id = @rekening OR f.ke_rekening_id = @rekening)
+			AND f.tanggal BETWEEN CAST(@start AS date) AND CAST(@end AS date)
+			AND NOT EXISTS (SELECT 1 FROM mutasi_rekenings m WHERE m.transfer_id = f.id AND m.rekening_id = @rekening)
+		ORDER BY 3, 1, 2`, args).Scan(&belumDiBank).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build rekonsiliasi"})
+		return
+	}
+
+	hasil := gin.H{
+		"rekening":      rekening,
+		"start_date":    args["start"],
+		"end_date":      args["end"],
+		"saldo_buku":    saldoBuku,
+		"saldo_bank":    saldoBank,
+		"ringkasan":     ringkasan,
+		"belum_cocok":   belumCocok,
+		"belum_di_bank": belumDiBank,
+	}
+	if saldoBank != nil {
+		hasil["selisih"] = *saldoBank - saldoBuku
+	}
+	c.JSON(http.StatusOK, hasil)
+}
+
+// transaksiTercocok - Transaksi t sudah muncul di rekening koran: dipasangkan dengan mutasi, atau
+// transaksi kode unik dari permintaan QRIS yang lunas lewat mutasi
+const transaksiTercocok = `(
+	EXISTS (SELECT 1 FROM mutasi_rekenings m WHERE m.transaksi_id = t.id)
+	OR EXISTS (SELECT 1 FROM pembayaran_onlines po WHERE po.kode_transaksi_id = t.id AND po.mutasi_id IS NOT NULL))`
+
+// lepasMutasiTransaksi - Kembalikan mutasi bank ke Belum Cocok sebelum transaksinya dihapus
+func lepasMutasiTransaksi(tx *gorm.DB, kondisi string, args ...interface{}) error {
+	return tx.Model(&models.MutasiRekening{}).
+		Where("transaksi_id IN (?)", tx.Model(&models.Transaksi{}).Select("id").Where(kondisi, args...)).
+		Updates(map[string]interface{}{"transaksi_id": nil, "status": "Belum Cocok"}).Error
+}
@@ -1,18 +1,21 @@
 package controllers
 
 import (
+	"math"
 	"net/http"
 	"strconv"
 	"time"
 
 	"kos-muhandis/backend/database"
 	"kos-muhandis/backend/models"
+	"kos-muhandis/backend/services"
 
 	"github.com/gin-gonic/gin"
 )
 
 type ReportSummary struct {
 	Periode           string `json:"periode"`
+	Basis             string `json:"basis"`
 	TotalPendapatan   int    `json:"total_pendapatan"`
 	TotalPengeluaran  int    `json:"total_pengeluaran"`
 	NetProfit         int    `json:"net_profit"`
@@ -31,79 +34,169 @@ type MonthlyReport struct {
 	TagihanBelum int    `json:"tagihan_belum"`
 }
 
-type ReportDetail struct {
-	ID      uint
-	Tipe    string // "income" or "expense"
-	Desc    string
-	Jumlah  int
-	Tanggal string
-}
-
 // GetMonthlyReport - Get laporan bulanan
+// Query: tahun (default tahun ini), bulan (1-12, opsional untuk satu bulan saja), basis (accrual|cash)
 func GetMonthlyReport(c *gin.Context) {
 	tahun := c.Query("tahun")
 	if tahun == "" {
 		tahun = strconv.Itoa(time.Now().Year())
 	}
+	year, err := strconv.Atoi(tahun)
+	if err != nil || year < 1900 || year > 9999 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tahun"})
+		return
+	}
 
-	var reports []MonthlyReport
+	from, to := 1, 12
+	if bulan := c.Query("bulan"); bulan != "" {
+		month, err := strconv.Atoi(bulan)
+		if err != nil || month < 1 || month > 12 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid bulan"})
+			return
+		}
+		from, to = month, month
+	}
 
-	for month := 1; month <= 12; month++ {
-		bulanStr := time.Date(time.Now().Year(), time.Month(month), 1, 0, 0, 0, 0, time.UTC).Format("2006-01")
+	basis, ok := reportBasis(c, BasisAccrual)
+	if !ok {
+		return
+	}
 
-		// Hitung tagihan yang lunas
-		var tagihanLunas int64
-		database.DB.Model(&models.Tagihan{}).Where("status = ? AND bulan LIKE ?", "Lunas", tahun+"-%").Count(&tagihanLunas)
+	reports, err := buildMonthlyReport(year, from, to, basis)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build monthly report"})
+		return
+	}
 
-		// Hitung tagihan belum lunas
-		var tagihanBelum int64
-		database.DB.Model(&models.Tagihan{}).Where("status != ? AND bulan LIKE ?", "Lunas", tahun+"-%").Count(&tagihanBelum)
+	table := services.ExportTable{
+		Title: "Laporan Bulanan " + tahun,
+		Columns: []services.ExportColumn{
+			{Header: "Bulan"}, {Header: "Pendapatan", Rupiah: true}, {Header: "Pengeluaran", Rupiah: true},
+			{Header: "Net Profit", Rupiah: true}, {Header: "Tagihan Lunas"}, {Header: "Tagihan Belum Lunas"},
+		},
+	}
+	for _, r := range reports {
+		table.AddRow(r.Bulan, r.Pendapatan, r.Pengeluaran, r.NetProfit, r.TagihanLunas, r.TagihanBelum)
+	}
+	if respondExport(c, "laporan-bulanan-"+basis+"-"+tahun, table) {
+		return
+	}
 
-		// Hitung total pendapatan dari tagihan yang lunas
-		var totalPendapatan int
-		database.DB.Model(&models.Tagihan{}).Where("status = ? AND bulan = ?", "Lunas", bulanStr).
-			Select("COALESCE(SUM(jumlah), 0)").Row().Scan(&totalPendapatan)
+	c.JSON(http.StatusOK, reports)
+}
 
-		// Hitung total pengeluaran
-		var totalPengeluaran int
-		database.DB.Model(&models.Transaksi{}).Where("jenis = ? AND EXTRACT(YEAR FROM tanggal)::TEXT || '-' || LPAD(EXTRACT(MONTH FROM tanggal)::TEXT, 2, '0') = ?", "Pengeluaran", bulanStr).
-			Select("COALESCE(SUM(jumlah), 0)").Row().Scan(&totalPengeluaran)
+const (
+	BasisAccrual = "accrual"
+	BasisCash    = "cash"
+)
 
-		netProfit := totalPendapatan - totalPengeluaran
+// reportBasis - Baca query basis (accrual|cash)
+func reportBasis(c *gin.Context, defaultBasis string) (string, bool) {
+	basis := c.DefaultQuery("basis", defaultBasis)
+	if basis != BasisAccrual && basis != BasisCash {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "basis must be accrual or cash"})
+		return "", false
+	}
+	return basis, true
+}
 
-		report := MonthlyReport{
-			Bulan:        bulanStr,
-			Pendapatan:   totalPendapatan,
-			Pengeluaran:  totalPengeluaran,
-			NetProfit:    netProfit,
-			TagihanLunas: int(tagihanLunas),
-			TagihanBelum: int(tagihanBelum),
-		}
-		reports = append(reports, report)
+// buildMonthlyReport - Agregasi laporan per bulan (fromMonth..toMonth) dalam satu query.
+// Basis accrual: pendapatan = total yang ditagihkan untuk periode (bulan) tagihan.
+// Basis cash: pendapatan = uang yang diterima pada bulan tersebut, termasuk cicilan,
+// berdasarkan tanggal pembayaran. Pengeluaran selalu berdasarkan tanggal transaksi.
+func buildMonthlyReport(year, fromMonth, toMonth int, basis string) ([]MonthlyReport, error) {
+	start := time.Date(year, time.Month(fromMonth), 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(year, time.Month(toMonth)+1, 1, 0, 0, 0, 0, time.UTC)
+
+	// Pemasukan di luar tagihan (transaksi tanpa pembayaran) dihitung pada kedua basis
+	pendapatan := "COALESCE(t.ditagih, 0) + COALESCE(k.lainnya, 0)"
+	if basis == BasisCash {
+		pendapatan = "COALESCE(k.diterima, 0)"
 	}
 
-	c.JSON(http.StatusOK, reports)
+	query := `
+		WITH periode AS (
+			SELECT TO_CHAR(d, 'YYYY-MM') AS bulan
+			FROM generate_series(?::date, ?::date, INTERVAL '1 month') AS d
+		),
+		tagihan AS (
+			SELECT
+				LEFT(bulan, 7) AS bulan,
+				SUM(jumlah) AS ditagih,
+				COUNT(*) FILTER (WHERE status = 'Lunas') AS tagihan_lunas,
+				COUNT(*) FILTER (WHERE status <> 'Lunas') AS tagihan_belum
+			FROM tagihans
+			WHERE deleted_at IS NULL AND LEFT(bulan, 7) >= ? AND LEFT(bulan, 7) < ?
+			GROUP BY LEFT(bulan, 7)
+		),
+		penerimaan AS (
+			SELECT TO_CHAR(tanggal, 'YYYY-MM') AS bulan, SUM(jumlah) AS diterima,
+				SUM(jumlah) FILTER (WHERE pembayaran_id IS NULL) AS lainnya
+			FROM transaksis
+			WHERE deleted_at IS NULL AND LOWER(jenis) = 'pemasukan' AND tanggal >= ? AND tanggal < ?
+			GROUP BY TO_CHAR(tanggal, 'YYYY-MM')
+		),
+		pengeluaran AS (
+			SELECT TO_CHAR(tanggal, 'YYYY-MM') AS bulan, SUM(jumlah) AS pengeluaran
+			FROM transaksis
+			WHERE deleted_at IS NULL AND LOWER(jenis) = 'pengeluaran' AND tanggal >= ? AND tanggal < ?
+			GROUP BY TO_CHAR(tanggal, 'YYYY-MM')
+		)
+		SELECT
+			p.bulan,
+			` + pendapatan + ` AS pendapatan,
+			COALESCE(e.pengeluaran, 0) AS pengeluaran,
+			` + pendapatan + ` - COALESCE(e.pengeluaran, 0) AS net_profit,
+			COALESCE(t.tagihan_lunas, 0) AS tagihan_lunas,
+			COALESCE(t.tagihan_belum, 0) AS tagihan_belum
+		FROM periode p
+		LEFT JOIN tagihan t ON t.bulan = p.bulan
+		LEFT JOIN penerimaan k ON k.bulan = p.bulan
+		LEFT JOIN pengeluaran e ON e.bulan = p.bulan
+		ORDER BY p.bulan
+	`
+
+	var reports []MonthlyReport
+	err := database.DB.Raw(query,
+		start.Format("2006-01-02"), end.AddDate(0, -1, 0).Format("2006-01-02"),
+		start.Format("2006-01"), end.Format("2006-01"),
+		start.Format("2006-01-02"), end.Format("2006-01-02"),
+		start.Format("2006-01-02"), end.Format("2006-01-02"),
+	).Scan(&reports).Error
+	return reports, err
 }
 
 // GetYearlyReport - Get laporan tahunan
+// Query: tahun (default tahun ini), basis (accrual|cash)
 func GetYearlyReport(c *gin.Context) {
 	tahun := c.Query("tahun")
 	if tahun == "" {
 		tahun = strconv.Itoa(time.Now().Year())
 	}
+	year, err := strconv.Atoi(tahun)
+	if err != nil || year < 1900 || year > 9999 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tahun"})
+		return
+	}
+	basis, ok := reportBasis(c, BasisAccrual)
+	if !ok {
+		return
+	}
 
-	var summary ReportSummary
-	summary.Periode = tahun
-
-	// Total tagihan yang lunas (pendapatan)
-	var totalPendapatan int
-	database.DB.Model(&models.Tagihan{}).Where("status = ? AND bulan LIKE ?", "Lunas", tahun+"-%").
-		Select("COALESCE(SUM(jumlah), 0)").Row().Scan(&totalPendapatan)
+	monthly, err := buildMonthlyReport(year, 1, 12, basis)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build yearly report"})
+		return
+	}
 
-	// Total pengeluaran
-	var totalPengeluaran int
-	database.DB.Model(&models.Transaksi{}).Where("jenis = ? AND EXTRACT(YEAR FROM tanggal)::TEXT = ?", "Pengeluaran", tahun).
-		Select("COALESCE(SUM(jumlah), 0)").Row().Scan(&totalPengeluaran)
+	summary := ReportSummary{Periode: tahun, Basis: basis}
+	for _, m := range monthly {
+		summary.TotalPendapatan += m.Pendapatan
+		summary.TotalPengeluaran += m.Pengeluaran
+		summary.TotalTagihanLunas += m.TagihanLunas
+		summary.TotalTagihanBelum += m.TagihanBelum
+	}
+	summary.NetProfit = summary.TotalPendapatan - summary.TotalPengeluaran
 
 	// Total kamar
 	var totalKamar int64
@@ -113,106 +206,281 @@ func GetYearlyReport(c *gin.Context) {
 	var totalOccupancy int64
 	database.DB.Model(&models.Kamar{}).Where("status = ?", "Terisi").Count(&totalOccupancy)
 
-	// Total tagihan lunas
-	var totalTagihanLunas int64
-	database.DB.Model(&models.Tagihan{}).Where("status = ? AND bulan LIKE ?", "Lunas", tahun+"-%").Count(&totalTagihanLunas)
-
-	// Total tagihan belum lunas
-	var totalTagihanBelum int64
-	database.DB.Model(&models.Tagihan{}).Where("status != ? AND bulan LIKE ?", "Lunas", tahun+"-%").Count(&totalTagihanBelum)
-
-	summary.TotalPendapatan = totalPendapatan
-	summary.TotalPengeluaran = totalPengeluaran
-	summary.NetProfit = totalPendapatan - totalPengeluaran
-	summary.TotalTagihanLunas = int(totalTagihanLunas)
-	summary.TotalTagihanBelum = int(totalTagihanBelum)
 	summary.TotalKamar = int(totalKamar)
 	summary.TotalOccupancy = int(totalOccupancy)
 
+	table := services.ExportTable{
+		Title:   "Laporan Tahunan " + tahun,
+		Columns: []services.ExportColumn{{Header: "Keterangan"}, {Header: "Nilai"}},
+	}
+	table.AddRow("Periode", summary.Periode)
+	table.AddRow("Basis", summary.Basis)
+	table.AddRow("Total Pendapatan", services.FormatRupiah(summary.TotalPendapatan))
+	table.AddRow("Total Pengeluaran", services.FormatRupiah(summary.TotalPengeluaran))
+	table.AddRow("Net Profit", services.FormatRupiah(summary.NetProfit))
+	table.AddRow("Tagihan Lunas", summary.TotalTagihanLunas)
+	table.AddRow("Tagihan Belum Lunas", summary.TotalTagihanBelum)
+	table.AddRow("Kamar Terisi", summary.TotalOccupancy)
+	table.AddRow("Total Kamar", summary.TotalKamar)
+	if respondExport(c, "laporan-tahunan-"+basis+"-"+tahun, table) {
+		return
+	}
+
 	c.JSON(http.StatusOK, summary)
 }
 
-// GetDetailReport - Get detail laporan dengan date range
-func GetDetailReport(c *gin.Context) {
-	startDate := c.Query("start_date")
-	endDate := c.Query("end_date")
+// RekonsiliasiReport - Rekonsiliasi pendapatan accrual vs cash untuk satu bulan.
+// Accrual = DiterimaPeriodeSama + DiterimaBulanLain + BelumDiterima
+// Cash    = DiterimaPeriodeSama + DiterimaPeriodeLalu + DiterimaDimuka
+type RekonsiliasiReport struct {
+	Bulan               string `json:"bulan"`
+	Accrual             int    `json:"accrual"`
+	Cash                int    `json:"cash"`
+	DiterimaPeriodeSama int    `json:"diterima_periode_sama"` // dibayar di bulan yang sama dengan periode tagihan
+	DiterimaPeriodeLalu int    `json:"diterima_periode_lalu"` // diterima bulan ini untuk tagihan periode sebelumnya
+	DiterimaDimuka      int    `json:"diterima_dimuka"`       // diterima bulan ini untuk tagihan periode berikutnya
+	DiterimaBulanLain   int    `json:"diterima_bulan_lain"`   // tagihan bulan ini yang dibayar di bulan lain
+	BelumDiterima       int    `json:"belum_diterima"`        // sisa tagihan bulan ini yang belum dibayar
+	Selisih             int    `json:"selisih"`               // cash - accrual
+}
 
-	if startDate == "" || endDate == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "start_date and end_date are required"})
+// GetRekonsiliasiReport - Rekonsiliasi pendapatan basis accrual dan cash per bulan
+func GetRekonsiliasiReport(c *gin.Context) {
+	tahun := c.Query("tahun")
+	if tahun == "" {
+		tahun = strconv.Itoa(time.Now().Year())
+	}
+	year, err := strconv.Atoi(tahun)
+	if err != nil || year < 1900 || year > 9999 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tahun"})
 		return
 	}
 
-	var details []map[string]interface{}
-
-	// Get tagihan yang lunas
-	var tagihanList []models.Tagihan
-	database.DB.Where("status = ?", "Lunas").Find(&tagihanList)
-
-	for _, tagihan := range tagihanList {
-		details = append(details, map[string]interface{}{
-			"tipe":      "Pendapatan",
-			"deskripsi": "Pembayaran Tagihan - " + tagihan.Bulan,
-			"jumlah":    tagihan.Jumlah,
-			"tanggal":   tagihan.UpdatedAt.Format("2006-01-02"),
-		})
+	query := `
+		WITH periode AS (
+			SELECT TO_CHAR(d, 'YYYY-MM') AS bulan
+			FROM generate_series(?::date, ?::date, INTERVAL '1 month') AS d
+		),
+		ditagih AS (
+			SELECT LEFT(bulan, 7) AS bulan, SUM(jumlah) AS jumlah
+			FROM tagihans
+			WHERE deleted_at IS NULL
+			GROUP BY LEFT(bulan, 7)
+		),
+		penerimaan AS (
+			SELECT bulan, TO_CHAR(tanggal, 'YYYY-MM') AS bulan_bayar, jumlah FROM penerimaan_tagihan
+		),
+		per_bulan_bayar AS (
+			SELECT
+				bulan_bayar AS bulan,
+				SUM(jumlah) AS cash,
+				SUM(jumlah) FILTER (WHERE bulan = bulan_bayar) AS periode_sama,
+				SUM(jumlah) FILTER (WHERE bulan < bulan_bayar) AS periode_lalu,
+				SUM(jumlah) FILTER (WHERE bulan > bulan_bayar) AS dimuka
+			FROM penerimaan
+			GROUP BY bulan_bayar
+		),
+		per_periode AS (
+			SELECT
+				bulan,
+				SUM(jumlah) AS total_diterima,
+				SUM(jumlah) FILTER (WHERE bulan <> bulan_bayar) AS bulan_lain
+			FROM penerimaan
+			GROUP BY bulan
+		)
+		SELECT
+			p.bulan,
+			COALESCE(d.jumlah, 0) AS accrual,
+			COALESCE(b.cash, 0) AS cash,
+			COALESCE(b.periode_sama, 0) AS diterima_periode_sama,
+			COALESCE(b.periode_lalu, 0) AS diterima_periode_lalu,
+			COALESCE(b.dimuka, 0) AS diterima_dimuka,
+			COALESCE(r.bulan_lain, 0) AS diterima_bulan_lain,
+			COALESCE(d.jumlah, 0) - COALESCE(r.total_diterima, 0) AS belum_diterima,
+			COALESCE(b.cash, 0) - COALESCE(d.jumlah, 0) AS selisih
+		FROM periode p
+		LEFT JOIN ditagih d ON d.bulan = p.bulan
+		LEFT JOIN per_bulan_bayar b ON b.bulan = p.bulan
+		LEFT JOIN per_periode r ON r.bulan = p.bulan
+		ORDER BY p.bulan
+	`
+
+	var reports []RekonsiliasiReport
+	if err := database.DB.Raw(query,
+		time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC).Format("2006-01-02"),
+		time.Date(year, 12, 1, 0, 0, 0, 0, time.UTC).Format("2006-01-02"),
+	).Scan(&reports).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build rekonsiliasi report"})
+		return
 	}
 
-	// Get transaksi
-	var transaksiList []models.Transaksi
-	database.DB.Where("tanggal BETWEEN ? AND ?", startDate, endDate).Find(&transaksiList)
-
-	for _, transaksi := range transaksiList {
-		details = append(details, map[string]interface{}{
-			"tipe":      transaksi.Jenis,
-			"deskripsi": transaksi.Kategori,
-			"jumlah":    transaksi.Jumlah,
-			"tanggal":   transaksi.Tanggal.Format("2006-01-02"),
-		})
+	table := services.ExportTable{
+		Title: "Rekonsiliasi " + tahun,
+		Columns: []services.ExportColumn{
+			{Header: "Bulan"}, {Header: "Accrual", Rupiah: true}, {Header: "Cash", Rupiah: true},
+			{Header: "Diterima Periode Sama", Rupiah: true}, {Header: "Diterima Periode Lalu", Rupiah: true},
+			{Header: "Diterima Dimuka", Rupiah: true}, {Header: "Diterima Bulan Lain", Rupiah: true},
+			{Header: "Belum Diterima", Rupiah: true}, {Header: "Selisih", Rupiah: true},
+		},
+	}
+	for _, r := range reports {
+		table.AddRow(r.Bulan, r.Accrual, r.Cash, r.DiterimaPeriodeSama, r.DiterimaPeriodeLalu,
+			r.DiterimaDimuka, r.DiterimaBulanLain, r.BelumDiterima, r.Selisih)
+	}
+	if respondExport(c, "rekonsiliasi-"+tahun, table) {
+		return
 	}
 
-	c.JSON(http.StatusOK, details)
+	c.JSON(http.StatusOK, reports)
 }
 
-// GetCashFlowProjection - Get proyeksi cash flow 6 bulan ke depan
-func GetCashFlowProjection(c *gin.Context) {
-	var projections []map[string]interface{}
-	today := time.Now()
-
-	for i := 0; i < 6; i++ {
-		projectionMonth := today.AddDate(0, i, 0)
-		monthStr := projectionMonth.Format("2006-01")
-
-		// Estimasi pendapatan dari kamar terisi
-		var totalKamarTerisi int64
-		database.DB.Model(&models.Kamar{}).Where("status = ?", "Terisi").Count(&totalKamarTerisi)
+// CashFlowProjection - Proyeksi satu bulan; field estimasi_* berisi skenario expected
+type CashFlowProjection struct {
+	services.ForecastBulan
+	EstimasiPendapatan  int     `json:"estimasi_pendapatan"`
+	EstimasiPengeluaran int     `json:"estimasi_pengeluaran"`
+	NetProfitProjection int     `json:"net_profit_projection"`
+	TingkatPenagihan    float64 `json:"tingkat_penagihan"`
+}
 
-		// Get average kamar price
-		var avgPrice int
-		database.DB.Model(&models.Kamar{}).Where("status = ?", "Terisi").
-			Select("COALESCE(AVG(harga), 0)").Row().Scan(&avgPrice)
+// GetCashFlowProjection - Get proyeksi cash flow ke depan (default 6 bulan, query bulan maks 24)
+// dengan skenario best/expected/worst, lihat services.ForecastCashFlow
+func GetCashFlowProjection(c *gin.Context) {
+	jumlahBulan, err := strconv.Atoi(c.DefaultQuery("bulan", "6"))
+	if err != nil || jumlahBulan < 1 || jumlahBulan > 24 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "bulan must be between 1 and 24"})
+		return
+	}
 
-		estimasiPendapatan := int(totalKamarTerisi) * avgPrice
+	now := today()
+	mulai := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	input := services.ForecastInput{Mulai: mulai, JumlahBulan: jumlahBulan}
+
+	// Kamar dan kontrak penyewa aktif
+	var kamarList []models.Kamar
+	var penyewaList []models.Penyewa
+	var perubahanHarga []models.PerubahanHarga
+	var pengeluaranRutin []models.PengeluaranRutin
+	if err := database.DB.Find(&kamarList).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch kamar"})
+		return
+	}
+	if err := database.DB.Where("tanggal_keluar IS NULL OR tanggal_keluar >= ?", mulai).Find(&penyewaList).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch penyewa"})
+		return
+	}
+	if err := database.DB.Find(&perubahanHarga).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch perubahan harga"})
+		return
+	}
+	if err := database.DB.Find(&pengeluaranRutin).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch pengeluaran rutin"})
+		return
+	}
 
-		// Get historical pengeluaran average
-		var avgPengeluaran int
-		database.DB.Model(&models.Transaksi{}).Where("jenis = ?", "Pengeluaran").
-			Select("COALESCE(AVG(jumlah), 0)").Row().Scan(&avgPengeluaran)
+	kontrak := make(map[uint]*time.Time)
+	terisi := make(map[uint]bool)
+	for _, p := range penyewaList {
+		// Kamar berisi lebih dari satu penyewa memakai kontrak yang paling lama berakhir
+		if !terisi[p.KamarID] {
+			terisi[p.KamarID] = true
+			kontrak[p.KamarID] = p.TanggalKeluar
+			continue
+		}
+		if kontrak[p.KamarID] != nil && (p.TanggalKeluar == nil || p.TanggalKeluar.After(*kontrak[p.KamarID])) {
+			kontrak[p.KamarID] = p.TanggalKeluar
+		}
+	}
+	for _, k := range kamarList {
+		input.Kamar = append(input.Kamar, services.ForecastKamar{
+			KamarID:       k.ID,
+			Harga:         k.Harga,
+			Perbaikan:     k.Status == "Perbaikan",
+			Terisi:        terisi[k.ID],
+			KontrakSampai: kontrak[k.ID],
+		})
+	}
+	for _, p := range perubahanHarga {
+		input.PerubahanHarga = append(input.PerubahanHarga, services.ForecastPerubahanHarga{
+			KamarID: p.KamarID, HargaBaru: p.HargaBaru, BerlakuMulai: p.BerlakuMulai,
+		})
+	}
+	for _, r := range pengeluaranRutin {
+		input.PengeluaranRutin = append(input.PengeluaranRutin, services.ForecastPengeluaranRutin{
+			Jumlah: r.Jumlah, IntervalBulan: r.IntervalBulan, Mulai: r.Mulai, Selesai: r.Selesai,
+		})
+	}
 
-		// Hitung jumlah pengeluaran bulan sebelumnya
-		lastMonth := projectionMonth.AddDate(0, -1, 0)
-		lastMonthStr := lastMonth.Format("2006-01")
+	// Tingkat penagihan 12 bulan terakhir: diterima / ditagihkan per periode tagihan
+	var penagihan []struct {
+		Bulan    string
+		Ditagih  int
+		Diterima int
+	}
+	if err := database.DB.Raw(`
+		SELECT t.bulan, t.ditagih, COALESCE(p.diterima, 0) AS diterima
+		FROM (
+			SELECT LEFT(bulan, 7) AS bulan, SUM(jumlah) AS ditagih
+			FROM tagihans
+			WHERE deleted_at IS NULL AND LEFT(bulan, 7) >= ? AND LEFT(bulan, 7) < ?
+			GROUP BY LEFT(bulan, 7)
+		) t
+		LEFT JOIN (
+			SELECT bulan, SUM(jumlah) AS diterima FROM penerimaan_tagihan GROUP BY bulan
+		) p ON p.bulan = t.bulan
+	`, mulai.AddDate(0, -12, 0).Format("2006-01"), mulai.Format("2006-01")).Scan(&penagihan).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to calculate tingkat penagihan"})
+		return
+	}
+	for _, p := range penagihan {
+		if p.Ditagih > 0 {
+			input.TingkatPenagihan = append(input.TingkatPenagihan, math.Min(1, float64(p.Diterima)/float64(p.Ditagih)))
+		}
+	}
 
-		var lastMonthPengeluaran int
-		database.DB.Model(&models.Transaksi{}).Where("jenis = ? AND EXTRACT(YEAR FROM tanggal)::TEXT || '-' || LPAD(EXTRACT(MONTH FROM tanggal)::TEXT, 2, '0') = ?", "Pengeluaran", lastMonthStr).
-			Select("COALESCE(SUM(jumlah), 0)").Row().Scan(&lastMonthPengeluaran)
+	// Pengeluaran historis 24 bulan terakhir, bulan tanpa pengeluaran dihitung nol
+	var pengeluaran []struct {
+		Bulan  string
+		Jumlah int
+	}
+	if err := database.DB.Raw(`
+		SELECT TO_CHAR(tanggal, 'YYYY-MM') AS bulan, SUM(jumlah) AS jumlah
+		FROM transaksis
+		WHERE deleted_at IS NULL AND LOWER(jenis) = 'pengeluaran' AND tanggal >= ? AND tanggal < ?
+		GROUP BY TO_CHAR(tanggal, 'YYYY-MM')
+		ORDER BY bulan
+	`, mulai.AddDate(0, -24, 0).Format("2006-01-02"), mulai.Format("2006-01-02")).Scan(&pengeluaran).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to calculate pengeluaran historis"})
+		return
+	}
+	input.PengeluaranHistoris = make(map[string]int)
+	if len(pengeluaran) > 0 {
+		first, _ := time.Parse("2006-01", pengeluaran[0].Bulan)
+		for m := first; m.Before(mulai); m = m.AddDate(0, 1, 0) {
+			input.PengeluaranHistoris[m.Format("2006-01")] = 0
+		}
+		for _, p := range pengeluaran {
+			input.PengeluaranHistoris[p.Bulan] = p.Jumlah
+		}
+	}
 
-		netProfit := estimasiPendapatan - lastMonthPengeluaran
+	rate := 1.0
+	if len(input.TingkatPenagihan) > 0 {
+		sum := 0.0
+		for _, r := range input.TingkatPenagihan {
+			sum += r
+		}
+		rate = sum / float64(len(input.TingkatPenagihan))
+	}
 
-		projections = append(projections, map[string]interface{}{
-			"bulan":                 monthStr,
-			"estimasi_pendapatan":   estimasiPendapatan,
-			"estimasi_pengeluaran":  lastMonthPengeluaran,
-			"net_profit_projection": netProfit,
+	var projections []CashFlowProjection
+	for _, f := range services.ForecastCashFlow(input) {
+		projections = append(projections, CashFlowProjection{
+			ForecastBulan:       f,
+			EstimasiPendapatan:  f.Expected.Pendapatan,
+			EstimasiPengeluaran: f.Expected.Pengeluaran,
+			NetProfitProjection: f.Expected.Net,
+			TingkatPenagihan:    math.Round(rate*1000) / 1000,
 		})
 	}
 
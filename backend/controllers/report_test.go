@@ -0,0 +1,82 @@
+package controllers
+
+import (
+	"reflect"
+	"testing"
+)
+
+// seedLaporanBulanan - Jan lunas, Feb dicicil (Feb & Mar), Mar belum dibayar, Apr kosong
+func seedLaporanBulanan(t *testing.T) {
+	penyewa := seedPenyewa(t, "Budi")
+	jan := seedTagihan(t, penyewa, "2025-01", 1000000)
+	feb := seedTagihan(t, penyewa, "2025-02", 1000000)
+	seedTagihan(t, penyewa, "2025-03", 1000000)
+	seedBayar(t, jan, 1000000, "2025-01-05")
+	seedBayar(t, feb, 400000, "2025-02-10")
+	seedBayar(t, feb, 300000, "2025-03-02")
+	seedTransaksi(t, "pengeluaran", 200000, "2025-02-15")
+	seedTransaksi(t, "pemasukan", 50000, "2025-03-20")
+}
+
+func TestBuildMonthlyReport(t *testing.T) {
+	setupTestDB(t)
+	seedLaporanBulanan(t)
+
+	kosong := func(bulan string) MonthlyReport { return MonthlyReport{Bulan: bulan} }
+	cases := []struct {
+		name     string
+		year     int
+		from, to int
+		basis    string
+		want     []MonthlyReport
+	}{
+		{
+			name: "accrual multi bulan", year: 2025, from: 1, to: 4, basis: BasisAccrual,
+			want: []MonthlyReport{
+				{Bulan: "2025-01", Pendapatan: 1000000, NetProfit: 1000000, TagihanLunas: 1},
+				{Bulan: "2025-02", Pendapatan: 1000000, Pengeluaran: 200000, NetProfit: 800000, TagihanBelum: 1},
+				{Bulan: "2025-03", Pendapatan: 1050000, NetProfit: 1050000, TagihanBelum: 1},
+				kosong("2025-04"),
+			},
+		},
+		{
+			name: "cash mengikuti tanggal cicilan", year: 2025, from: 1, to: 4, basis: BasisCash,
+			want: []MonthlyReport{
+				{Bulan: "2025-01", Pendapatan: 1000000, NetProfit: 1000000, TagihanLunas: 1},
+				{Bulan: "2025-02", Pendapatan: 400000, Pengeluaran: 200000, NetProfit: 200000, TagihanBelum: 1},
+				{Bulan: "2025-03", Pendapatan: 350000, NetProfit: 350000, TagihanBelum: 1},
+				kosong("2025-04"),
+			},
+		},
+		{
+			name: "satu bulan dengan cicilan", year: 2025, from: 2, to: 2, basis: BasisCash,
+			want: []MonthlyReport{
+				{Bulan: "2025-02", Pendapatan: 400000, Pengeluaran: 200000, NetProfit: 200000, TagihanBelum: 1},
+			},
+		},
+		{
+			name: "bulan tanpa data", year: 2024, from: 11, to: 12, basis: BasisAccrual,
+			want: []MonthlyReport{kosong("2024-11"), kosong("2024-12")},
+		},
+		{
+			name: "setahun penuh", year: 2025, from: 1, to: 12, basis: BasisCash,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := buildMonthlyReport(tc.year, tc.from, tc.to, tc.basis)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tc.want == nil {
+				if len(got) != 12 || got[0].Bulan != "2025-01" || got[11].Bulan != "2025-12" {
+					t.Fatalf("got %d bulan (%v), want 2025-01..2025-12", len(got), got)
+				}
+				return
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("got  %+v\nwant %+v", got, tc.want)
+			}
+		})
+	}
+}
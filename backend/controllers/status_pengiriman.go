@@ -0,0 +1,270 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"kos-muhandis/backend/database"
+	"kos-muhandis/backend/models"
+	"kos-muhandis/backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// peringkatStatus - Urutan status pengiriman; callback hanya boleh memajukan status
+var peringkatStatus = map[string]int{
+	services.DeliveryQueued:    1,
+	services.DeliverySent:      2,
+	services.DeliveryDelivered: 3,
+	services.DeliveryRead:      4,
+}
+
+// WhatsAppStatusWebhook - Callback status pesan WhatsApp keluar (publik), mis. StatusCallback Twilio
+func WhatsAppStatusWebhook(c *gin.Context) {
+	provider, err := services.NewWhatsAppProvider()
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		return
+	}
+	if c.Param("provider") != provider.Name() {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Unknown provider"})
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, 1<<20))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read body"})
+		return
+	}
+	webhookURL, ok := urlWebhook(c, provider)
+	if !ok {
+		return
+	}
+	status, response := prosesStatusWhatsApp(provider, webhookURL, body, c.Request.Header)
+	c.JSON(status, response)
+}
+
+// SMSStatusWebhook - Laporan status (DLR) dari SMS gateway (publik)
+func SMSStatusWebhook(c *gin.Context) {
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, 1<<20))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read body"})
+		return
+	}
+	st, err := services.ParseSMSStatus(body, c.Request.Header)
+	if errors.Is(err, services.ErrInvalidSignature) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid signature"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, terapkanStatus("sms", st))
+}
+
+// LaporStatusPush - Service worker melaporkan notifikasi push tampil (delivered) atau diklik
+// (read) lewat receipt_url di payload. Token dari signDokumen("pengiriman", id).
+func LaporStatusPush(c *gin.Context) {
+	id := c.Param("id")
+	if !cekTokenDokumen("pengiriman", id, c.Query("token")) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Invalid or expired token"})
+		return
+	}
+	var input struct {
+		Status string `json:"status" binding:"required,oneof=delivered read"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	var pengiriman models.PengirimanNotifikasi
+	if err := database.DB.First(&pengiriman, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Pengiriman not found"})
+		return
+	}
+	// Pesan gabungan: semua notifikasi dalam kiriman yang sama ke tujuan ini ikut diperbarui
+	daftar := []models.PengirimanNotifikasi{pengiriman}
+	if pengiriman.Kiriman != "" {
+		database.DB.Where("kiriman = ? AND kanal = ? AND tujuan = ?", pengiriman.Kiriman, pengiriman.Kanal, pengiriman.Tujuan).Find(&daftar)
+	}
+	st := services.DeliveryStatus{Status: input.Status, At: time.Now()}
+	updated := 0
+	for i := range daftar {
+		if ubahStatusPengiriman(&daftar[i], st) {
+			perbaruiStatusNotifikasi(daftar[i].NotifikasiID)
+			updated++
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"status": input.Status, "updated": updated})
+}
+
+// SimulasiStatusPengiriman - Kirim callback status bertanda tangan dari provider fake untuk
+// pengiriman WhatsApp. Body: status (sent/delivered/read/failed), error opsional.
+func SimulasiStatusPengiriman(c *gin.Context) {
+	var input struct {
+		Status string `json:"status" binding:"required,oneof=sent delivered read failed"`
+		Error  string `json:"error"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		return
+	}
+	var pengiriman models.PengirimanNotifikasi
+	if err := database.DB.First(&pengiriman, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Pengiriman not found"})
+		return
+	}
+	if pengiriman.Kanal != "whatsapp" || pengiriman.ProviderID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Simulasi hanya untuk pengiriman WhatsApp yang punya id pesan"})
+		return
+	}
+	provider, err := services.NewWhatsAppProvider()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	fake, ok := provider.(services.FakeWhatsApp)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Simulasi hanya tersedia untuk provider fake"})
+		return
+	}
+	body, header := fake.SimulateStatus(pengiriman.ProviderID, input.Status, input.Error)
+	status, response := prosesStatusWhatsApp(fake, "", body, header)
+	c.JSON(status, response)
+}
+
+// prosesStatusWhatsApp - Verifikasi callback status lalu terapkan ke pengiriman dan percakapan
+func prosesStatusWhatsApp(provider services.WhatsAppProvider, webhookURL string, body []byte, header http.Header) (int, gin.H) {
+	st, err := provider.ParseStatus(webhookURL, body, header)
+	if errors.Is(err, services.ErrInvalidSignature) {
+		return http.StatusUnauthorized, gin.H{"error": "Invalid signature"}
+	}
+	if err != nil {
+		return http.StatusBadRequest, gin.H{"error": err.Error()}
+	}
+	response := terapkanStatus("whatsapp", st)
+	if st.MessageID != "" && st.Status != "" {
+		var pesan []models.Percakapan
+		database.DB.Where("arah = ? AND message_id = ?", "keluar", st.MessageID).Find(&pesan)
+		for _, p := range pesan {
+			if !statusMaju(p.Status, st.Status) {
+				continue
+			}
+			updates := map[string]interface{}{"status": st.Status}
+			if st.Error != "" {
+				updates["error"] = st.Error
+			}
+			database.DB.Model(&p).Updates(updates)
+		}
+	}
+	return http.StatusOK, response
+}
+
+// terapkanStatus - Terapkan callback ke semua pengiriman kanal dengan id pesan provider yang sama.
+// Status yang tidak dikenal atau id yang tidak ditemukan tetap dijawab 200 agar provider
+// tidak mengirim ulang.
+func terapkanStatus(kanal string, st services.DeliveryStatus) gin.H {
+	if st.MessageID == "" || st.Status == "" {
+		return gin.H{"message": "Status ignored", "updated": 0}
+	}
+	var daftar []models.PengirimanNotifikasi
+	database.DB.Where("kanal = ? AND provider_id = ?", kanal, st.MessageID).Find(&daftar)
+	updated := 0
+	for i := range daftar {
+		if ubahStatusPengiriman(&daftar[i], st) {
+			perbaruiStatusNotifikasi(daftar[i].NotifikasiID)
+			updated++
+		}
+	}
+	return gin.H{"message_id": st.MessageID, "status": st.Status, "updated": updated}
+}
+
+// ubahStatusPengiriman - Majukan status satu pengiriman beserta waktunya; alasan gagal disimpan
+func ubahStatusPengiriman(p *models.PengirimanNotifikasi, st services.DeliveryStatus) bool {
+	if !statusMaju(p.Status, st.Status) {
+		return false
+	}
+	at := st.At
+	if at.IsZero() {
+		at = time.Now()
+	}
+	p.Status = st.Status
+	switch st.Status {
+	case services.DeliverySent:
+		if p.DikirimPada == nil {
+			p.DikirimPada = &at
+		}
+	case services.DeliveryRead:
+		p.DibacaPada = &at
+		if p.DiterimaPada == nil {
+			p.DiterimaPada = &at
+		}
+	case services.DeliveryDelivered:
+		p.DiterimaPada = &at
+	case services.DeliveryFailed:
+		p.Error = st.Error
+		if p.Error == "" {
+			p.Error = "Provider melaporkan pesan gagal terkirim"
+		}
+	}
+	database.DB.Model(p).Updates(map[string]interface{}{
+		"status":        p.Status,
+		"error":         p.Error,
+		"dikirim_pada":  p.DikirimPada,
+		"diterima_pada": p.DiterimaPada,
+		"dibaca_pada":   p.DibacaPada,
+	})
+	return true
+}
+
+// statusMaju - Status hanya bergerak maju (queued -> sent -> delivered -> read);
+// failed hanya dari queued atau sent
+func statusMaju(lama, baru string) bool {
+	if baru == services.DeliveryFailed {
+		return lama == services.DeliveryQueued || lama == services.DeliverySent
+	}
+	return peringkatStatus[baru] > peringkatStatus[lama]
+}
+
+// perbaruiStatusNotifikasi - Status notifikasi mengikuti pengiriman terbaiknya;
+// failed bila semua pengiriman yang sempat terkirim dilaporkan gagal
+func perbaruiStatusNotifikasi(notifikasiID uint) {
+	var daftar []models.PengirimanNotifikasi
+	database.DB.Where("notifikasi_id = ?", notifikasiID).Find(&daftar)
+	terbaik := ""
+	gagal := false
+	for _, p := range daftar {
+		if peringkatStatus[p.Status] > peringkatStatus[terbaik] {
+			terbaik = p.Status
+		}
+		if p.Status == services.DeliveryFailed {
+			gagal = true
+		}
+	}
+	switch {
+	case peringkatStatus[terbaik] >= peringkatStatus[services.DeliverySent]:
+		database.DB.Model(&models.Notifikasi{}).Where("id = ?", notifikasiID).Update("status", terbaik)
+	case terbaik == "" && gagal:
+		database.DB.Model(&models.Notifikasi{}).Where("id = ?", notifikasiID).Update("status", services.DeliveryFailed)
+	}
+}
+
+// linkStatusPengiriman - receipt_url untuk service worker push, kosong bila PUBLIC_BASE_URL atau
+// JWT_SECRET belum diatur
+func linkStatusPengiriman(pengirimanID uint) string {
+	baseURL := os.Getenv("PUBLIC_BASE_URL")
+	if baseURL == "" {
+		return ""
+	}
+	id := strconv.Itoa(int(pengirimanID))
+	token, err := signDokumen("pengiriman", id)
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("%s/pengiriman/%s/status?token=%s", baseURL, id, token)
+}
@@ -1,6 +1,7 @@
 package controllers
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -8,6 +9,7 @@ import (
 	"kos-muhandis/backend/models"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 func GetTagihan(c *gin.Context) {
@@ -134,8 +136,17 @@ func CreateTagihan(c *gin.Context) {
 		DiterimaOleh: input.DiterimaOleh,
 		TanggalBayar: input.TanggalBayar,
 	}
-	if err := database.DB.Create(&tagihan).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create tagihan"})
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&tagihan).Error; err != nil {
+			return err
+		}
+		return syncPembayaran(tx, tagihan, 0)
+	})
+	if errors.Is(err, errTanpaRekening) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create tagihan"})
 		return
 	}
@@ -149,6 +160,7 @@ func UpdateTagihan(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Tagihan not found"})
 		return
 	}
+	terbayarLama := tagihan.Terbayar
 	var input struct {
 		Status       string `json:"status"`
 		Terbayar     int    `json:"terbayar"`
@@ -186,7 +198,17 @@ func UpdateTagihan(c *gin.Context) {
 	}
 	tagihan.DiterimaOleh = input.DiterimaOleh
 	tagihan.TanggalBayar = input.TanggalBayar
-	if err := database.DB.Save(&tagihan).Error; err != nil {
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&tagihan).Error; err != nil {
+			return err
+		}
+		return syncPembayaran(tx, tagihan, terbayarLama)
+	})
+	if errors.Is(err, errTanpaRekening) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update tagihan"})
 		return
 	}
@@ -195,7 +217,20 @@ func UpdateTagihan(c *gin.Context) {
 
 func DeleteTagihan(c *gin.Context) {
 	id, _ := strconv.Atoi(c.Param("id"))
-	if err := database.DB.Delete(&models.Tagihan{}, id).Error; err != nil {
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&models.Tagihan{}, id).Error; err != nil {
+			return err
+		}
+		// Pembayaran & transaksi pemasukan tagihan ikut terhapus agar laporan tetap seimbang
+		if err := tx.Where("tagihan_id = ?", id).Delete(&models.Pembayaran{}).Error; err != nil {
+			return err
+		}
+		if err := lepasMutasiTransaksi(tx, "tagihan_id = ? AND pembayaran_id IS NOT NULL", id); err != nil {
+			return err
+		}
+		return tx.Where("tagihan_id = ? AND pembayaran_id IS NOT NULL", id).Delete(&models.Transaksi{}).Error
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete tagihan"})
 		return
 	}
@@ -222,7 +257,18 @@ func FixTerbayarMassal(c *gin.Context) {
 		// For "Cicil", assume terbayar is already correct
 
 		if needsUpdate {
-			if err := database.DB.Save(&t).Error; err != nil {
+			err := database.DB.Transaction(func(tx *gorm.DB) error {
+				if err := tx.Save(&t).Error; err != nil {
+					return err
+				}
+				// Selisih dihitung dari riwayat pembayaran, bukan terbayar lama yang memang salah
+				total, err := totalPembayaran(tx, t.ID)
+				if err != nil {
+					return err
+				}
+				return syncPembayaran(tx, t, total)
+			})
+			if err != nil {
 				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update tagihan ID " + strconv.Itoa(int(t.ID))})
 				return
 			}
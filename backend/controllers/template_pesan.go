@@ -0,0 +1,344 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"kos-muhandis/backend/database"
+	"kos-muhandis/backend/models"
+	"kos-muhandis/backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+var tipeTemplate = []string{
+	"H-7", "H-3", "H-1", "OVERDUE", "GABUNGAN", "KONTAK_DARURAT", "PEMILIK", "KWITANSI", "SELAMAT_DATANG", "BUKTI_DITOLAK", "DOKUMEN",
+	"BALASAN_TAGIHAN", "BALASAN_TAGIHAN_KOSONG", "BALASAN_SALDO", "BALASAN_KWITANSI", "BALASAN_KWITANSI_KOSONG", "BALASAN_KWITANSI_MANUAL",
+	"BALASAN_STOP", "BALASAN_START", "BALASAN_BANTUAN", "BALASAN_TIDAK_TERDAFTAR",
+}
+
+var bahasaTemplate = []string{"id", "en"}
+
+// templateBawaan - Isi template per bahasa dan tipe bila belum diubah pengelola
+var templateBawaan = map[string]map[string]string{
+	"id": {
+		"H-7":                     "Halo {{nama}},\n\nPengingat: tagihan {{jenis}} bulan {{bulan}} sebesar {{jumlah_rupiah}} akan jatuh tempo dalam 7 hari ({{jatuh_tempo}}).\n\nTerima kasih.",
+		"H-3":                     "Halo {{nama}},\n\nPerhatian: tagihan {{jenis}} bulan {{bulan}} akan jatuh tempo dalam 3 hari ({{jatuh_tempo}}). Sisa tagihan: {{sisa}}.\n\nTerima kasih.",
+		"H-1":                     "Halo {{nama}},\n\nMendesak: tagihan {{jenis}} bulan {{bulan}} jatuh tempo hari ini. Sisa tagihan: {{sisa}}.\n\nMohon segera melakukan pembayaran. Terima kasih.",
+		"OVERDUE":                 "Halo {{nama}},\n\nTertunggak: tagihan {{jenis}} bulan {{bulan}} sudah lewat jatuh tempo ({{jatuh_tempo}}). Sisa tagihan: {{sisa}}.\n\nMohon segera melakukan pembayaran. Terima kasih.",
+		"GABUNGAN":                "Halo {{nama}},\n\nPengingat: ada beberapa tagihan yang belum lunas:\n{{daftar_tagihan}}\n\nTotal: {{total_sisa}}. Mohon segera melakukan pembayaran. Terima kasih.",
+		"KONTAK_DARURAT":          "Yth. {{kontak_darurat}},\n\nAnda tercatat sebagai kontak darurat {{nama}} (kamar {{kamar}}). Tagihan {{jenis}} bulan {{bulan}} sudah terlambat {{hari_terlambat}} hari dengan sisa {{sisa}}. Mohon bantuannya untuk mengingatkan.\n\nTerima kasih.",
+		"PEMILIK":                 "Eskalasi tagihan: {{nama}} (kamar {{kamar}}) belum melunasi tagihan {{jenis}} bulan {{bulan}}. Terlambat {{hari_terlambat}} hari, sisa {{sisa}}.",
+		"KWITANSI":                "Halo {{nama}},\n\nPembayaran tagihan {{jenis}} bulan {{bulan}} sudah kami terima. Total terbayar: {{terbayar}}, sisa tagihan: {{sisa}}.\n\nTerima kasih.",
+		"SELAMAT_DATANG":          "Halo {{nama}},\n\nSelamat datang di kamar {{kamar}}. Tagihan dikirim setiap bulan lewat WhatsApp; balas TAGIHAN untuk melihat tagihan yang belum lunas.\n\nTerima kasih.",
+		"BUKTI_DITOLAK":           "Halo {{nama}},\n\nBukti transfer {{jumlah_bukti}} untuk tagihan bulan {{bulan}} belum dapat kami terima.\nAlasan: {{alasan}}\n\nSilakan kirim ulang bukti yang benar. Terima kasih.",
+		"DOKUMEN":                 "Halo {{nama}},\n\nBerikut {{dokumen}} tagihan {{jenis}} bulan {{bulan}}.\n\nTerima kasih.",
+		"BALASAN_TAGIHAN":         "Halo {{nama}},\n\nTagihan yang belum lunas:\n{{daftar_tagihan}}\n\nTotal: {{total_sisa}}",
+		"BALASAN_TAGIHAN_KOSONG":  "Halo {{nama}},\n\nTidak ada tagihan yang belum lunas. Terima kasih.",
+		"BALASAN_SALDO":           "Halo {{nama}},\n\nSisa tagihan: {{total_sisa}} ({{jumlah_tagihan}} tagihan)\nPembayaran terakhir: {{pembayaran_terakhir}}",
+		"BALASAN_KWITANSI":        "Halo {{nama}},\n\nBerikut kwitansi {{jenis}} bulan {{bulan}} (terbayar {{terbayar}}).",
+		"BALASAN_KWITANSI_KOSONG": "Halo {{nama}},\n\nBelum ada pembayaran yang bisa dibuatkan kwitansi.",
+		"BALASAN_KWITANSI_MANUAL": "Halo {{nama}},\n\nKwitansi belum bisa dikirim otomatis. Silakan hubungi pengelola kos.",
+		"BALASAN_STOP":            "Halo {{nama}},\n\nAnda tidak akan menerima pengingat dan pesan lain dari kami lagi. Balas START untuk menerima pesan kembali.",
+		"BALASAN_START":           "Halo {{nama}},\n\nAnda akan menerima pengingat tagihan kembali. Terima kasih.",
+		"BALASAN_BANTUAN":         "Halo {{nama}},\n\nKirim salah satu perintah berikut:\nTAGIHAN - daftar tagihan yang belum lunas\nSALDO - total sisa tagihan dan pembayaran terakhir\nKWITANSI - kwitansi pembayaran terakhir (KWITANSI 2026-09 untuk bulan tertentu)\nSTOP - berhenti menerima pesan, START - menerima pesan lagi",
+		"BALASAN_TIDAK_TERDAFTAR": "Maaf, nomor ini belum terdaftar sebagai penyewa. Silakan hubungi pengelola kos.",
+	},
+	"en": {
+		"H-7":                     "Hello {{nama}},\n\nReminder: your {{jenis}} bill for {{bulan}} of {{jumlah_rupiah}} is due in 7 days ({{jatuh_tempo}}).\n\nThank you.",
+		"H-3":                     "Hello {{nama}},\n\nPlease note: your {{jenis}} bill for {{bulan}} is due in 3 days ({{jatuh_tempo}}). Outstanding: {{sisa}}.\n\nThank you.",
+		"H-1":                     "Hello {{nama}},\n\nUrgent: your {{jenis}} bill for {{bulan}} is due today. Outstanding: {{sisa}}.\n\nPlease pay as soon as possible. Thank you.",
+		"OVERDUE":                 "Hello {{nama}},\n\nOverdue: your {{jenis}} bill for {{bulan}} was due on {{jatuh_tempo}}. Outstanding: {{sisa}}.\n\nPlease pay as soon as possible. Thank you.",
+		"GABUNGAN":                "Hello {{nama}},\n\nReminder: you have several unpaid bills:\n{{daftar_tagihan}}\n\nTotal: {{total_sisa}}. Please pay as soon as possible. Thank you.",
+		"KONTAK_DARURAT":          "Dear {{kontak_darurat}},\n\nYou are listed as the emergency contact of {{nama}} (room {{kamar}}). The {{jenis}} bill for {{bulan}} is {{hari_terlambat}} days overdue with {{sisa}} outstanding. Please help remind them.\n\nThank you.",
+		"PEMILIK":                 "Bill escalation: {{nama}} (room {{kamar}}) has not paid the {{jenis}} bill for {{bulan}}. {{hari_terlambat}} days overdue, {{sisa}} outstanding.",
+		"KWITANSI":                "Hello {{nama}},\n\nWe have received your payment for the {{jenis}} bill for {{bulan}}. Total paid: {{terbayar}}, outstanding: {{sisa}}.\n\nThank you.",
+		"SELAMAT_DATANG":          "Hello {{nama}},\n\nWelcome to room {{kamar}}. Bills are sent monthly via WhatsApp; reply TAGIHAN to see your unpaid bills.\n\nThank you.",
+		"BUKTI_DITOLAK":           "Hello {{nama}},\n\nWe could not accept your transfer proof of {{jumlah_bukti}} for the {{bulan}} bill.\nReason: {{alasan}}\n\nPlease send the correct proof again. Thank you.",
+		"DOKUMEN":                 "Hello {{nama}},\n\nAttached is the {{dokumen}} for your {{jenis}} bill for {{bulan}}.\n\nThank you.",
+		"BALASAN_TAGIHAN":         "Hello {{nama}},\n\nUnpaid bills:\n{{daftar_tagihan}}\n\nTotal: {{total_sisa}}",
+		"BALASAN_TAGIHAN_KOSONG":  "Hello {{nama}},\n\nYou have no unpaid bills. Thank you.",
+		"BALASAN_SALDO":           "Hello {{nama}},\n\nOutstanding: {{total_sisa}} ({{jumlah_tagihan}} bills)\nLast payment: {{pembayaran_terakhir}}",
+		"BALASAN_KWITANSI":        "Hello {{nama}},\n\nAttached is the receipt for your {{jenis}} bill for {{bulan}} (paid {{terbayar}}).",
+		"BALASAN_KWITANSI_KOSONG": "Hello {{nama}},\n\nThere is no payment to issue a receipt for yet.",
+		"BALASAN_KWITANSI_MANUAL": "Hello {{nama}},\n\nReceipts cannot be sent automatically yet. Please contact the boarding house manager.",
+		"BALASAN_STOP":            "Hello {{nama}},\n\nYou will no longer receive reminders or other messages from us. Reply START to receive messages again.",
+		"BALASAN_START":           "Hello {{nama}},\n\nYou will receive bill reminders again. Thank you.",
+		"BALASAN_BANTUAN":         "Hello {{nama}},\n\nSend one of the following commands:\nTAGIHAN - unpaid bills\nSALDO - total outstanding and last payment\nKWITANSI - receipt of the last payment (KWITANSI 2026-09 for a specific month)\nSTOP - stop receiving messages, START - receive messages again",
+		"BALASAN_TIDAK_TERDAFTAR": "Sorry, this number is not registered as a tenant. Please contact the boarding house manager.",
+	},
+}
+
+// GetTemplatePesan - Semua template per tipe dan bahasa, beserta variabel yang tersedia.
+// bawaan=true berarti template belum diubah.
+func GetTemplatePesan(c *gin.Context) {
+	var ubahan []models.TemplatePesan
+	if err := database.DB.Find(&ubahan).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch template"})
+		return
+	}
+	byKey := make(map[string]models.TemplatePesan, len(ubahan))
+	for _, t := range ubahan {
+		byKey[t.Tipe+"/"+t.Bahasa] = t
+	}
+
+	var daftar []gin.H
+	for _, tipe := range tipeTemplate {
+		for _, bahasa := range bahasaTemplate {
+			item := gin.H{"tipe": tipe, "bahasa": bahasa, "isi": templateBawaan[bahasa][tipe], "bawaan": true}
+			if t, ok := byKey[tipe+"/"+bahasa]; ok {
+				item["isi"] = t.Isi
+				item["bawaan"] = false
+				item["updated_at"] = t.UpdatedAt
+			}
+			daftar = append(daftar, item)
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"variabel": services.VariabelTemplate, "template": daftar})
+}
+
+// UpdateTemplatePesan - Ubah template satu tipe dan bahasa. Variabel yang tidak dikenal ditolak.
+func UpdateTemplatePesan(c *gin.Context) {
+	tipe, bahasa, ok := paramTemplate(c)
+	if !ok {
+		return
+	}
+	var input struct {
+		Isi string `json:"isi" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if asing := services.VariabelTidakDikenal(input.Isi); len(asing) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Variabel tidak dikenal: " + strings.Join(asing, ", ")})
+		return
+	}
+
+	var template models.TemplatePesan
+	database.DB.Where("tipe = ? AND bahasa = ?", tipe, bahasa).First(&template)
+	template.Tipe = tipe
+	template.Bahasa = bahasa
+	template.Isi = input.Isi
+	if err := database.DB.Save(&template).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save template"})
+		return
+	}
+	c.JSON(http.StatusOK, template)
+}
+
+// ResetTemplatePesan - Kembalikan template satu tipe dan bahasa ke bawaan
+func ResetTemplatePesan(c *gin.Context) {
+	tipe, bahasa, ok := paramTemplate(c)
+	if !ok {
+		return
+	}
+	if err := database.DB.Where("tipe = ? AND bahasa = ?", tipe, bahasa).Delete(&models.TemplatePesan{}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reset template"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"tipe": tipe, "bahasa": bahasa, "isi": templateBawaan[bahasa][tipe], "bawaan": true})
+}
+
+// PreviewTemplatePesan - Render template dengan data tagihan (tagihan_id) atau penyewa (penyewa_id),
+// atau data contoh bila keduanya kosong. isi opsional untuk mencoba template sebelum disimpan;
+// bila kosong dipakai template tersimpan untuk tipe dan bahasa (default bahasa penyewa).
+func PreviewTemplatePesan(c *gin.Context) {
+	var input struct {
+		Tipe      string `json:"tipe" binding:"required"`
+		Bahasa    string `json:"bahasa" binding:"omitempty,oneof=id en"`
+		Isi       string `json:"isi"`
+		TagihanID uint   `json:"tagihan_id"`
+		PenyewaID uint   `json:"penyewa_id"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if templateBawaan["id"][input.Tipe] == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Tipe template tidak dikenal"})
+		return
+	}
+
+	kontak := "Siti Aminah"
+	penyewa := models.Penyewa{Nama: "Budi Santoso", Bahasa: "id", Kamar: &models.Kamar{Nama: "A1"}, KontakDaruratNama: &kontak}
+	tagihan := &models.Tagihan{Bulan: today().Format("2006-01"), Jumlah: 1500000, Terbayar: 500000, JenisTagihan: "Penyewa"}
+	if input.TagihanID != 0 {
+		tagihan = &models.Tagihan{}
+		if err := database.DB.Preload("Penyewa.Kamar").First(tagihan, input.TagihanID).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Tagihan not found"})
+			return
+		}
+		penyewa = tagihan.Penyewa
+	} else if input.PenyewaID != 0 {
+		if err := database.DB.Preload("Kamar").First(&penyewa, input.PenyewaID).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Penyewa not found"})
+			return
+		}
+		tagihan = nil
+	}
+	if input.Bahasa != "" {
+		penyewa.Bahasa = input.Bahasa
+	}
+
+	isi := input.Isi
+	if isi == "" {
+		isi = isiTemplate(input.Tipe, penyewa.Bahasa)
+	}
+	data := dataTemplate(penyewa, tagihan)
+	if input.Tipe == "GABUNGAN" || input.Tipe == "BALASAN_TAGIHAN" {
+		var daftar []models.Tagihan
+		if tagihan != nil {
+			daftar = append(daftar, *tagihan)
+		} else {
+			database.DB.Where("penyewa_id = ? AND terbayar < jumlah", penyewa.ID).Order("bulan ASC, id ASC").Find(&daftar)
+		}
+		data = dataGabungan(penyewa, daftar)
+	}
+	contohVariabel(data, bahasaPenyewa(penyewa), tagihan)
+	c.JSON(http.StatusOK, gin.H{
+		"pesan":         services.RenderTemplate(isi, data),
+		"bahasa":        bahasaPenyewa(penyewa),
+		"variabel":      data,
+		"tidak_dikenal": services.VariabelTidakDikenal(isi),
+	})
+}
+
+// contohVariabel - Isi variabel khusus bukti, dokumen, dan balasan WhatsApp dengan contoh
+// untuk preview, kecuali yang sudah ada
+func contohVariabel(data map[string]string, bahasa string, tagihan *models.Tagihan) {
+	contoh := map[string]string{
+		"alasan":              "Nominal transfer tidak sesuai",
+		"dokumen":             namaDokumen("invoice", bahasa),
+		"jumlah_tagihan":      "1",
+		"pembayaran_terakhir": "-",
+	}
+	if bahasa == "en" {
+		contoh["alasan"] = "Transfer amount does not match"
+	}
+	if tagihan != nil {
+		contoh["jumlah_bukti"] = services.FormatRupiah(tagihan.Jumlah - tagihan.Terbayar)
+		contoh["total_sisa"] = services.FormatRupiah(tagihan.Jumlah - tagihan.Terbayar)
+	}
+	for k, v := range contoh {
+		if _, ok := data[k]; !ok {
+			data[k] = v
+		}
+	}
+}
+
+// paramTemplate - Validasi :tipe dan :bahasa di URL
+func paramTemplate(c *gin.Context) (string, string, bool) {
+	tipe := strings.ToUpper(c.Param("tipe"))
+	bahasa := strings.ToLower(c.Param("bahasa"))
+	if templateBawaan["id"][tipe] == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Tipe template tidak dikenal"})
+		return "", "", false
+	}
+	if templateBawaan[bahasa] == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Bahasa harus id atau en"})
+		return "", "", false
+	}
+	return tipe, bahasa, true
+}
+
+// isiTemplate - Template tersimpan untuk tipe dan bahasa; bila belum ada, template bawaan
+func isiTemplate(tipe, bahasa string) string {
+	if templateBawaan[bahasa] == nil {
+		bahasa = "id"
+	}
+	var template models.TemplatePesan
+	if err := database.DB.Where("tipe = ? AND bahasa = ?", tipe, bahasa).First(&template).Error; err == nil {
+		return template.Isi
+	}
+	return templateBawaan[bahasa][tipe]
+}
+
+// pesanTemplate - Render template tipe tertentu dalam bahasa penyewa.
+// penyewa.Kamar dipakai untuk {{kamar}} bila sudah di-preload.
+func pesanTemplate(tipe string, penyewa models.Penyewa, tagihan *models.Tagihan) string {
+	return renderPesan(tipe, penyewa, dataTemplate(penyewa, tagihan))
+}
+
+// pesanGabungan - Render template GABUNGAN: beberapa tagihan penyewa dalam satu pesan
+func pesanGabungan(penyewa models.Penyewa, tagihan []models.Tagihan) string {
+	return renderPesan("GABUNGAN", penyewa, dataGabungan(penyewa, tagihan))
+}
+
+// renderPesan - Render template tipe tertentu dalam bahasa penyewa dengan variabel yang sudah disusun
+// (dataTemplate ditambah variabel khusus seperti {{alasan}} atau {{dokumen}})
+func renderPesan(tipe string, penyewa models.Penyewa, data map[string]string) string {
+	return services.RenderTemplate(isiTemplate(tipe, bahasaPenyewa(penyewa)), data)
+}
+
+// namaDokumen - Nama jenis dokumen (invoice / kwitansi) untuk {{dokumen}} dalam bahasa penyewa
+func namaDokumen(jenis, bahasa string) string {
+	if bahasa == "en" && jenis == "kwitansi" {
+		return "receipt"
+	}
+	return jenis
+}
+
+// dataGabungan - Variabel penyewa ditambah {{daftar_tagihan}} (satu baris per tagihan) dan {{total_sisa}}
+func dataGabungan(penyewa models.Penyewa, tagihan []models.Tagihan) map[string]string {
+	data := dataTemplate(penyewa, nil)
+	var baris []string
+	total := 0
+	for i := range tagihan {
+		t := dataTemplate(penyewa, &tagihan[i])
+		item := fmt.Sprintf("- %s %s: %s", t["jenis"], t["bulan"], t["sisa"])
+		if t["jatuh_tempo"] != "" {
+			if bahasaPenyewa(penyewa) == "en" {
+				item += " (due " + t["jatuh_tempo"] + ")"
+			} else {
+				item += " (jatuh tempo " + t["jatuh_tempo"] + ")"
+			}
+		}
+		baris = append(baris, item)
+		total += tagihan[i].Jumlah - tagihan[i].Terbayar
+	}
+	data["daftar_tagihan"] = strings.Join(baris, "\n")
+	data["total_sisa"] = services.FormatRupiah(total)
+	return data
+}
+
+// dataTemplate - Nilai variabel template untuk penyewa dan (opsional) tagihannya
+func dataTemplate(penyewa models.Penyewa, tagihan *models.Tagihan) map[string]string {
+	bahasa := bahasaPenyewa(penyewa)
+	data := map[string]string{"nama": penyewa.Nama}
+	if penyewa.Kamar != nil {
+		data["kamar"] = penyewa.Kamar.Nama
+	}
+	if penyewa.KontakDaruratNama != nil {
+		data["kontak_darurat"] = *penyewa.KontakDaruratNama
+	}
+	if tagihan == nil {
+		return data
+	}
+	data["bulan"] = services.NamaBulanBahasa(tagihan.Bulan, bahasa)
+	data["jenis"] = kategoriTagihan(tagihan.JenisTagihan)
+	if bahasa == "en" && data["jenis"] == "Sewa Kamar" {
+		data["jenis"] = "rent"
+	}
+	data["jumlah"] = strconv.Itoa(tagihan.Jumlah)
+	data["jumlah_rupiah"] = services.FormatRupiah(tagihan.Jumlah)
+	data["terbayar"] = services.FormatRupiah(tagihan.Terbayar)
+	data["sisa"] = services.FormatRupiah(tagihan.Jumlah - tagihan.Terbayar)
+	if due, err := jatuhTempo(tagihan.Bulan); err == nil {
+		data["jatuh_tempo"] = services.FormatTanggalBahasa(due, bahasa)
+		data["hari_terlambat"] = strconv.Itoa(max(0, int(today().Sub(due).Hours()/24)))
+	}
+	if tagihan.ID != 0 {
+		data["link_bayar"] = linkBuktiPembayaran(tagihan.ID)
+	}
+	return data
+}
+
+func bahasaPenyewa(penyewa models.Penyewa) string {
+	if penyewa.Bahasa == "en" {
+		return "en"
+	}
+	return "id"
+}
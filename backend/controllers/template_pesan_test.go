@@ -0,0 +1,52 @@
+package controllers
+
+import (
+	"strings"
+	"testing"
+
+	"kos-muhandis/backend/database"
+	"kos-muhandis/backend/services"
+)
+
+func TestTemplateBawaanLengkap(t *testing.T) {
+	for _, tipe := range tipeTemplate {
+		for _, bahasa := range bahasaTemplate {
+			isi := templateBawaan[bahasa][tipe]
+			if isi == "" {
+				t.Errorf("template %s/%s kosong", tipe, bahasa)
+				continue
+			}
+			if asing := services.VariabelTidakDikenal(isi); len(asing) > 0 {
+				t.Errorf("template %s/%s memakai variabel tidak dikenal %v", tipe, bahasa, asing)
+			}
+		}
+	}
+}
+
+func TestBalasPerintahBahasaPenyewa(t *testing.T) {
+	setupTestDB(t)
+	t.Setenv("PUBLIC_BASE_URL", "")
+	penyewa := seedPenyewa(t, "Jane")
+	database.DB.Model(&penyewa).Update("bahasa", "en")
+	penyewa.Bahasa = "en"
+	tagihan := seedTagihan(t, penyewa, "2025-03", 1000000)
+	seedBayar(t, tagihan, 400000, "2025-03-05")
+
+	tests := []struct {
+		perintah string
+		want     []string
+	}{
+		{"TAGIHAN", []string{"Hello Jane,", "Unpaid bills:", "March 2025", "Rp 600.000"}},
+		{"SALDO", []string{"Outstanding: Rp 600.000 (1 bills)", "Last payment: Rp 400.000 (05 March 2025)"}},
+		{"KWITANSI", []string{"Receipts cannot be sent automatically"}},
+		{"BANTUAN", []string{"Send one of the following commands"}},
+	}
+	for _, tt := range tests {
+		balasan, _ := balasPerintah(penyewa, tt.perintah, "")
+		for _, want := range tt.want {
+			if !strings.Contains(balasan, want) {
+				t.Errorf("%s: balasan %q tidak memuat %q", tt.perintah, balasan, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"kos-muhandis/backend/database"
+	"kos-muhandis/backend/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// Test yang memakai database butuh Postgres kosong khusus test, mis.
+//
+//	TEST_DATABASE_URL="host=localhost user=postgres password=postgres dbname=kos_muhandis_test sslmode=disable" go test ./...
+//
+// Tanpa TEST_DATABASE_URL test tersebut di-skip. Nama database wajib mengandung "test" karena
+// setiap test mengosongkan tabel data.
+
+var (
+	testDBOnce sync.Once
+	testDBErr  string
+)
+
+// tabelDataTest - Tabel yang dikosongkan sebelum setiap test; tabel seed (akuns, rekenings,
+// kategoris, template_pesans, langkah_eskalasis) dibiarkan
+var tabelDataTest = []string{
+	"kamars", "penyewas", "tagihans", "pembayarans", "transaksis", "transaksi_lampirans",
+	"notifikasis", "pengiriman_notifikasis", "perbaikans", "perbaikan_fotos", "perubahan_hargas",
+	"pengeluaran_rutins", "vendors", "jurnals", "jurnal_details", "transfers", "mutasi_rekenings",
+	"pembayaran_onlines", "bukti_pembayarans", "percakapans", "push_subscriptions",
+}
+
+func setupTestDB(t *testing.T) {
+	t.Helper()
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+	testDBOnce.Do(func() {
+		db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+		if err != nil {
+			testDBErr = "connect: " + err.Error()
+			return
+		}
+		var nama string
+		db.Raw("SELECT current_database()").Scan(&nama)
+		if !strings.Contains(nama, "test") {
+			testDBErr = "refusing to use database " + nama + ": name must contain \"test\""
+			return
+		}
+		database.DB = db
+		database.Migrate()
+	})
+	if testDBErr != "" {
+		t.Fatal(testDBErr)
+	}
+	gin.SetMode(gin.TestMode)
+	if err := database.DB.Exec("TRUNCATE " + strings.Join(tabelDataTest, ", ") + " RESTART IDENTITY CASCADE").Error; err != nil {
+		t.Fatal("truncate:", err)
+	}
+}
+
+func tanggalTest(t *testing.T, value string) time.Time {
+	t.Helper()
+	d, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+// seedPenyewa - Kamar baru beserta penyewanya
+func seedPenyewa(t *testing.T, nama string) models.Penyewa {
+	t.Helper()
+	kamar := models.Kamar{Nama: "Kamar " + nama, Harga: 1000000, Status: "Terisi"}
+	if err := database.DB.Create(&kamar).Error; err != nil {
+		t.Fatal(err)
+	}
+	penyewa := models.Penyewa{Nama: nama, KamarID: kamar.ID}
+	if err := database.DB.Create(&penyewa).Error; err != nil {
+		t.Fatal(err)
+	}
+	return penyewa
+}
+
+func seedTagihan(t *testing.T, penyewa models.Penyewa, bulan string, jumlah int) models.Tagihan {
+	t.Helper()
+	tagihan := models.Tagihan{
+		PenyewaID: penyewa.ID, KamarID: penyewa.KamarID, Bulan: bulan, Jumlah: jumlah,
+		Status: "Belum Lunas", JenisTagihan: "Penyewa",
+	}
+	if err := database.DB.Omit("TanggalBayar").Create(&tagihan).Error; err != nil {
+		t.Fatal(err)
+	}
+	return tagihan
+}
+
+// seedBayar - Pembayaran lewat jalur yang sama dengan handler (pembayaran + transaksi pemasukan)
+func seedBayar(t *testing.T, tagihan models.Tagihan, jumlah int, tanggal string) models.Pembayaran {
+	t.Helper()
+	var pembayaran models.Pembayaran
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		_, pembayaran, err = bayarTagihan(tx, tagihan.ID, models.Pembayaran{Jumlah: jumlah, Tanggal: tanggalTest(t, tanggal)}, nil)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pembayaran
+}
+
+func seedTransaksi(t *testing.T, jenis string, jumlah int, tanggal string) models.Transaksi {
+	t.Helper()
+	transaksi := models.Transaksi{Jenis: jenis, Kategori: "Lainnya", Jumlah: jumlah, Tanggal: tanggalTest(t, tanggal)}
+	if msg := applyRekening(&transaksi, nil); msg != "" {
+		t.Fatal(msg)
+	}
+	if err := database.DB.Create(&transaksi).Error; err != nil {
+		t.Fatal(err)
+	}
+	return transaksi
+}
+
+// panggilHandler - Jalankan handler dengan request JSON; params dipasang sebagai path param
+func panggilHandler(handler gin.HandlerFunc, method, target string, body interface{}, params ...gin.Param) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	var raw []byte
+	if body != nil {
+		raw, _ = json.Marshal(body)
+	}
+	c.Request = httptest.NewRequest(method, target, bytes.NewReader(raw))
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Params = params
+	handler(c)
+	return w
+}
+
+func decodeJSON(t *testing.T, w *httptest.ResponseRecorder, v interface{}) {
+	t.Helper()
+	if err := json.Unmarshal(w.Body.Bytes(), v); err != nil {
+		t.Fatalf("decode %q: %v", w.Body.String(), err)
+	}
+}
+
+func cekStatus(t *testing.T, w *httptest.ResponseRecorder, want int) {
+	t.Helper()
+	if w.Code != want {
+		t.Fatalf("status = %d, want %d: %s", w.Code, want, w.Body.String())
+	}
+}
@@ -1,23 +1,34 @@
 package controllers
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"kos-muhandis/backend/database"
 	"kos-muhandis/backend/models"
+	"kos-muhandis/backend/services"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+const maxLampiranSize = 10 << 20
+
+var lampiranTypes = map[string]bool{
+	"image/jpeg": true, "image/png": true, "image/webp": true, "image/gif": true, "application/pdf": true,
+}
+
 func GetTransaksi(c *gin.Context) {
 	jenis := c.Query("jenis")
 	kategori := c.Query("kategori")
 	bulan := c.Query("bulan")
 
 	var transaksi []models.Transaksi
-	query := database.DB
+	query := database.DB.Preload("Vendor").Preload("Kamar").Preload("Rekening").Preload("Lampiran")
 
 	if jenis != "" {
 		query = query.Where("jenis = ?", jenis)
@@ -25,6 +36,28 @@ func GetTransaksi(c *gin.Context) {
 	if kategori != "" {
 		query = query.Where("kategori = ?", kategori)
 	}
+	if kategoriID := c.Query("kategori_id"); kategoriID != "" {
+		// Termasuk seluruh sub kategori
+		query = query.Where(`kategori_id IN (
+			WITH RECURSIVE tree AS (
+				SELECT id FROM kategoris WHERE id = ?
+				UNION ALL
+				SELECT k.id FROM kategoris k JOIN tree t ON k.parent_id = t.id WHERE k.deleted_at IS NULL
+			)
+			SELECT id FROM tree)`, kategoriID)
+	}
+	if vendorID := c.Query("vendor_id"); vendorID != "" {
+		query = query.Where("vendor_id = ?", vendorID)
+	}
+	if kamarID := c.Query("kamar_id"); kamarID != "" {
+		query = query.Where("kamar_id = ?", kamarID)
+	}
+	if rekeningID := c.Query("rekening_id"); rekeningID != "" {
+		query = query.Where("rekening_id = ?", rekeningID)
+	}
+	if tagihanID := c.Query("tagihan_id"); tagihanID != "" {
+		query = query.Where("tagihan_id = ?", tagihanID)
+	}
 	if bulan != "" {
 		// Filter by month (format: 2006-01)
 		query = query.Where("EXTRACT(YEAR FROM tanggal)::TEXT || '-' || LPAD(EXTRACT(MONTH FROM tanggal)::TEXT, 2, '0') = ?", bulan)
@@ -39,10 +72,16 @@ func GetTransaksi(c *gin.Context) {
 
 func CreateTransaksi(c *gin.Context) {
 	var input struct {
-		Jenis    string `json:"jenis" binding:"required"`
-		Kategori string `json:"kategori" binding:"required"`
-		Jumlah   int    `json:"jumlah" binding:"required"`
-		Tanggal  string `json:"tanggal" binding:"required"`
+		Jenis      string `json:"jenis" binding:"required"`
+		Kategori   string `json:"kategori"`
+		KategoriID *uint  `json:"kategori_id"`
+		VendorID   *uint  `json:"vendor_id"`
+		KamarID    *uint  `json:"kamar_id"`
+		Keterangan string `json:"keterangan"`
+		RekeningID *uint  `json:"rekening_id"` // default rekening kas
+		TagihanID  *uint  `json:"tagihan_id"`  // pemasukan sekaligus pembayaran tagihan ini
+		Jumlah     int    `json:"jumlah" binding:"required"`
+		Tanggal    string `json:"tanggal" binding:"required"`
 	}
 	if err := c.ShouldBindJSON(&input); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -54,12 +93,53 @@ func CreateTransaksi(c *gin.Context) {
 		return
 	}
 	transaksi := models.Transaksi{
-		Jenis:    input.Jenis,
-		Kategori: input.Kategori,
-		Jumlah:   input.Jumlah,
-		Tanggal:  tanggal,
+		Jenis:      input.Jenis,
+		Jumlah:     input.Jumlah,
+		Tanggal:    tanggal,
+		Keterangan: input.Keterangan,
+	}
+
+	kategori := input.Kategori
+	if input.TagihanID != nil {
+		if !strings.EqualFold(input.Jenis, "pemasukan") || input.Jumlah <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Pembayaran tagihan harus berupa pemasukan dengan jumlah positif"})
+			return
+		}
+		var tagihan models.Tagihan
+		if err := database.DB.First(&tagihan, *input.TagihanID).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Tagihan not found"})
+			return
+		}
+		if input.KategoriID == nil && strings.TrimSpace(kategori) == "" {
+			kategori = kategoriTagihan(tagihan.JenisTagihan)
+		}
+	}
+	if msg := applyTransaksiRefs(&transaksi, input.KategoriID, kategori, input.VendorID, input.KamarID); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+	if msg := applyRekening(&transaksi, input.RekeningID); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&transaksi).Error; err != nil {
+			return err
+		}
+		if input.TagihanID == nil {
+			return nil
+		}
+		_, _, err := bayarTagihan(tx, *input.TagihanID, models.Pembayaran{
+			Jumlah: transaksi.Jumlah, Tanggal: transaksi.Tanggal, Keterangan: transaksi.Keterangan,
+		}, &transaksi)
+		return err
+	})
+	if errors.Is(err, errOverpayment) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
-	if err := database.DB.Create(&transaksi).Error; err != nil {
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create transaksi"})
 		return
 	}
@@ -74,22 +154,32 @@ func UpdateTransaksi(c *gin.Context) {
 		return
 	}
 	var input struct {
-		Jenis    string `json:"jenis"`
-		Kategori string `json:"kategori"`
-		Jumlah   int    `json:"jumlah"`
-		Tanggal  string `json:"tanggal"`
+		Jenis      string  `json:"jenis"`
+		Kategori   string  `json:"kategori"`
+		KategoriID *uint   `json:"kategori_id"`
+		VendorID   *uint   `json:"vendor_id"`
+		KamarID    *uint   `json:"kamar_id"`
+		Keterangan *string `json:"keterangan"`
+		RekeningID *uint   `json:"rekening_id"`
+		Jumlah     int     `json:"jumlah"`
+		Tanggal    string  `json:"tanggal"`
 	}
 	if err := c.ShouldBindJSON(&input); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 	if input.Jenis != "" {
+		if transaksi.PembayaranID != nil && !strings.EqualFold(input.Jenis, "pemasukan") {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Transaksi pembayaran tagihan harus berjenis pemasukan"})
+			return
+		}
 		transaksi.Jenis = input.Jenis
 	}
-	if input.Kategori != "" {
-		transaksi.Kategori = input.Kategori
-	}
 	if input.Jumlah != 0 {
+		if transaksi.PembayaranID != nil && input.Jumlah < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Pembayaran tagihan harus berupa pemasukan dengan jumlah positif"})
+			return
+		}
 		transaksi.Jumlah = input.Jumlah
 	}
 	if input.Tanggal != "" {
@@ -100,18 +190,240 @@ func UpdateTransaksi(c *gin.Context) {
 		}
 		transaksi.Tanggal = tanggal
 	}
-	if err := database.DB.Save(&transaksi).Error; err != nil {
+	if input.Keterangan != nil {
+		transaksi.Keterangan = *input.Keterangan
+	}
+
+	kategoriID, kategori := input.KategoriID, input.Kategori
+	if kategoriID == nil && kategori == "" {
+		kategoriID, kategori = transaksi.KategoriID, transaksi.Kategori
+	}
+	vendorID, kamarID := transaksi.VendorID, transaksi.KamarID
+	if input.VendorID != nil {
+		vendorID = input.VendorID
+	}
+	if input.KamarID != nil {
+		kamarID = input.KamarID
+	}
+	if msg := applyTransaksiRefs(&transaksi, kategoriID, kategori, vendorID, kamarID); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+	if input.RekeningID != nil {
+		if msg := applyRekening(&transaksi, input.RekeningID); msg != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+			return
+		}
+	}
+
+	// Jumlah/tanggal/rekening transaksi pembayaran tagihan ikut mengubah pembayaran & terbayar tagihannya
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if transaksi.PembayaranID != nil {
+			if err := ubahPembayaran(tx, transaksi); err != nil {
+				return err
+			}
+		}
+		return tx.Save(&transaksi).Error
+	})
+	if errors.Is(err, errOverpayment) || errors.Is(err, errTerbayarNegatif) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update transaksi"})
 		return
 	}
 	c.JSON(http.StatusOK, transaksi)
 }
 
+// DeleteTransaksi - Hapus transaksi. Transaksi pembayaran tagihan ikut membatalkan pembayarannya.
 func DeleteTransaksi(c *gin.Context) {
 	id, _ := strconv.Atoi(c.Param("id"))
-	if err := database.DB.Delete(&models.Transaksi{}, id).Error; err != nil {
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var transaksi models.Transaksi
+		if err := tx.First(&transaksi, id).Error; err != nil {
+			return err
+		}
+		if transaksi.PembayaranID == nil {
+			if err := lepasMutasiTransaksi(tx, "id = ?", transaksi.ID); err != nil {
+				return err
+			}
+			return tx.Delete(&transaksi).Error
+		}
+		var pembayaran models.Pembayaran
+		if err := tx.First(&pembayaran, *transaksi.PembayaranID).Error; err != nil {
+			return err
+		}
+		return hapusPembayaran(tx, pembayaran)
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Transaksi not found"})
+		return
+	}
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete transaksi"})
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"message": "Transaksi deleted"})
 }
+
+// LinkTransaksiTagihan - Tautkan pemasukan manual ke tagihan: transaksi dicatat sebagai
+// pembayaran tagihan tersebut tanpa membuat transaksi baru. Body: {"tagihan_id": 1}
+func LinkTransaksiTagihan(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	var input struct {
+		TagihanID uint `json:"tagihan_id" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var transaksi models.Transaksi
+	var tagihan models.Tagihan
+	var pembayaran models.Pembayaran
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&transaksi, id).Error; err != nil {
+			return errTransaksiNotFound
+		}
+		if !strings.EqualFold(transaksi.Jenis, "pemasukan") || transaksi.Jumlah <= 0 || transaksi.PembayaranID != nil {
+			return errTautTransaksi
+		}
+		var err error
+		tagihan, pembayaran, err = bayarTagihan(tx, input.TagihanID, models.Pembayaran{
+			Jumlah: transaksi.Jumlah, Tanggal: transaksi.Tanggal, Keterangan: transaksi.Keterangan,
+		}, &transaksi)
+		return err
+	})
+	if errors.Is(err, errTransaksiNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Transaksi not found"})
+		return
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Tagihan not found"})
+		return
+	}
+	if errors.Is(err, errTautTransaksi) || errors.Is(err, errOverpayment) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to link transaksi"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"transaksi": transaksi, "pembayaran": pembayaran, "tagihan": tagihan})
+}
+
+var (
+	errTransaksiNotFound = errors.New("Transaksi not found")
+	errTautTransaksi     = errors.New("Hanya pemasukan berjumlah positif yang belum bertaut yang bisa ditautkan")
+)
+
+// applyTransaksiRefs - Validasi vendor & kamar, lalu tentukan kategori transaksi. Tanpa kategori,
+// kategori default vendor dipakai. Teks kategori yang cocok dengan nama/alias kategori terdaftar
+// ditautkan dan diseragamkan. Mengembalikan pesan error bila input tidak valid.
+func applyTransaksiRefs(transaksi *models.Transaksi, kategoriID *uint, kategori string, vendorID, kamarID *uint) string {
+	if vendorID != nil && *vendorID == 0 {
+		vendorID = nil
+	}
+	if kamarID != nil && *kamarID == 0 {
+		kamarID = nil
+	}
+
+	if vendorID != nil {
+		var vendor models.Vendor
+		if err := database.DB.First(&vendor, *vendorID).Error; err != nil {
+			return "Vendor not found"
+		}
+		if kategoriID == nil && strings.TrimSpace(kategori) == "" {
+			kategoriID = vendor.KategoriID
+		}
+	}
+	if kamarID != nil {
+		if err := database.DB.First(&models.Kamar{}, *kamarID).Error; err != nil {
+			return "Kamar not found"
+		}
+	}
+	transaksi.VendorID = vendorID
+	transaksi.KamarID = kamarID
+
+	resolved, err := resolveKategori(transaksi.Jenis, kategoriID, kategori)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return "Kategori not found"
+	}
+	if err != nil {
+		return err.Error()
+	}
+	if resolved != nil {
+		transaksi.KategoriID = &resolved.ID
+		transaksi.Kategori = resolved.Nama
+		return ""
+	}
+	if strings.TrimSpace(kategori) == "" {
+		return "Kategori is required"
+	}
+	transaksi.KategoriID = nil
+	transaksi.Kategori = strings.TrimSpace(kategori)
+	return ""
+}
+
+// UploadLampiranTransaksi - Upload nota/bukti transaksi (multipart: file, gambar atau PDF)
+func UploadLampiranTransaksi(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	var transaksi models.Transaksi
+	if err := database.DB.First(&transaksi, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Transaksi not found"})
+		return
+	}
+
+	upload, ok := readUpload(c, maxLampiranSize, lampiranTypes)
+	if !ok {
+		return
+	}
+	key, err := storeUpload("transaksi", transaksi.ID, upload)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store file"})
+		return
+	}
+
+	lampiran := models.TransaksiLampiran{
+		TransaksiID: transaksi.ID,
+		NamaFile:    upload.NamaFile,
+		ContentType: upload.ContentType,
+		Ukuran:      len(upload.Data),
+		StorageKey:  key,
+	}
+	if err := database.DB.Create(&lampiran).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save lampiran"})
+		return
+	}
+	c.JSON(http.StatusCreated, lampiran)
+}
+
+// GetLampiranTransaksi - Download lampiran transaksi
+func GetLampiranTransaksi(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	var lampiran models.TransaksiLampiran
+	if err := database.DB.First(&lampiran, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Lampiran not found"})
+		return
+	}
+	serveUpload(c, lampiran.StorageKey, lampiran.NamaFile, lampiran.ContentType)
+}
+
+// DeleteLampiranTransaksi - Hapus lampiran beserta file di storage
+func DeleteLampiranTransaksi(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	var lampiran models.TransaksiLampiran
+	if err := database.DB.First(&lampiran, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Lampiran not found"})
+		return
+	}
+	if err := database.DB.Delete(&lampiran).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete lampiran"})
+		return
+	}
+	// File yang gagal dihapus hanya menjadi sampah di storage, data sudah terhapus
+	_ = services.NewStorage().Delete(lampiran.StorageKey)
+	c.JSON(http.StatusOK, gin.H{"message": "Lampiran deleted"})
+}
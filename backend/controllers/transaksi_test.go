@@ -0,0 +1,47 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+
+	"kos-muhandis/backend/database"
+	"kos-muhandis/backend/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestUpdateTransaksiPembayaranJumlah(t *testing.T) {
+	setupTestDB(t)
+	penyewa := seedPenyewa(t, "Joko")
+	tagihan := seedTagihan(t, penyewa, "2025-01", 1000000)
+	pembayaran := seedBayar(t, tagihan, 400000, "2025-01-10")
+	var transaksi models.Transaksi
+	if err := database.DB.Where("pembayaran_id = ?", pembayaran.ID).First(&transaksi).Error; err != nil {
+		t.Fatal(err)
+	}
+	param := gin.Param{Key: "id", Value: strconv.Itoa(int(transaksi.ID))}
+
+	tests := []struct {
+		name     string
+		jumlah   int
+		status   int
+		terbayar int
+	}{
+		{"negatif ditolak", -100, http.StatusBadRequest, 400000},
+		{"nol berarti tidak diubah", 0, http.StatusOK, 400000},
+		{"positif mengubah pembayaran", 600000, http.StatusOK, 600000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := panggilHandler(UpdateTransaksi, http.MethodPut, "/transaksi/"+param.Value, gin.H{"jumlah": tt.jumlah}, param)
+			cekStatus(t, w, tt.status)
+			var p models.Pembayaran
+			database.DB.First(&p, pembayaran.ID)
+			database.DB.First(&tagihan, tagihan.ID)
+			if p.Jumlah != tt.terbayar || tagihan.Terbayar != tt.terbayar {
+				t.Errorf("pembayaran %d, terbayar %d; want %d", p.Jumlah, tagihan.Terbayar, tt.terbayar)
+			}
+		})
+	}
+}
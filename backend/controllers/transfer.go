@@ -0,0 +1,109 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"kos-muhandis/backend/database"
+	"kos-muhandis/backend/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GetTransfer - Daftar transfer antar rekening. Query: rekening_id, start_date, end_date
+func GetTransfer(c *gin.Context) {
+	query := database.DB.Preload("DariRekening").Preload("KeRekening")
+	if rekeningID := c.Query("rekening_id"); rekeningID != "" {
+		query = query.Where("dari_rekening_id = ? OR ke_rekening_id = ?", rekeningID, rekeningID)
+	}
+	if startDate := c.Query("start_date"); startDate != "" {
+		query = query.Where("tanggal >= ?", startDate)
+	}
+	if endDate := c.Query("end_date"); endDate != "" {
+		query = query.Where("tanggal <= ?", endDate)
+	}
+	var transfer []models.Transfer
+	if err := query.Order("tanggal DESC, id DESC").Find(&transfer).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch transfer"})
+		return
+	}
+	c.JSON(http.StatusOK, transfer)
+}
+
+// CreateTransfer - Pindahkan dana antar rekening (e.g. setor kas ke BCA, tarik settlement QRIS).
+// biaya (admin bank) mengurangi rekening asal dan dicatat sebagai beban.
+func CreateTransfer(c *gin.Context) {
+	var input struct {
+		DariRekeningID uint   `json:"dari_rekening_id" binding:"required"`
+		KeRekeningID   uint   `json:"ke_rekening_id" binding:"required"`
+		Jumlah         int    `json:"jumlah" binding:"required"`
+		Biaya          int    `json:"biaya"`
+		Tanggal        string `json:"tanggal"`
+		Keterangan     string `json:"keterangan"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if input.Jumlah <= 0 || input.Biaya < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Jumlah must be greater than zero"})
+		return
+	}
+	if input.DariRekeningID == input.KeRekeningID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Rekening asal dan tujuan harus berbeda"})
+		return
+	}
+	tanggal, err := paymentDate(input.Tanggal)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format"})
+		return
+	}
+	for _, id := range []uint{input.DariRekeningID, input.KeRekeningID} {
+		if _, msg := pilihRekening(&id); msg != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+			return
+		}
+	}
+
+	transfer := models.Transfer{
+		DariRekeningID: input.DariRekeningID,
+		KeRekeningID:   input.KeRekeningID,
+		Jumlah:         input.Jumlah,
+		Biaya:          input.Biaya,
+		Tanggal:        tanggal,
+		Keterangan:     strings.TrimSpace(input.Keterangan),
+	}
+	if err := database.DB.Create(&transfer).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create transfer"})
+		return
+	}
+	database.DB.Preload("DariRekening").Preload("KeRekening").First(&transfer, transfer.ID)
+	c.JSON(http.StatusCreated, transfer)
+}
+
+// DeleteTransfer - Hapus transfer; baris mutasi bank yang sudah dicocokkan dikembalikan ke Belum Cocok
+func DeleteTransfer(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&models.Transfer{}, id).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.MutasiRekening{}).Where("transfer_id = ?", id).
+			Updates(map[string]interface{}{"transfer_id": nil, "status": "Belum Cocok"}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.Transfer{}, id).Error
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Transfer not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete transfer"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Transfer deleted"})
+}
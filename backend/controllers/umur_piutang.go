@@ -0,0 +1,265 @@
+package controllers
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"kos-muhandis/backend/database"
+	"kos-muhandis/backend/models"
+	"kos-muhandis/backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AgingBuckets - Sisa piutang per kelompok umur (hari setelah jatuh tempo)
+type AgingBuckets struct {
+	Current   int `json:"current"` // belum jatuh tempo
+	Hari1_30  int `json:"hari_1_30"`
+	Hari31_60 int `json:"hari_31_60"`
+	Hari61_90 int `json:"hari_61_90"`
+	Hari90    int `json:"hari_90_plus"`
+	Total     int `json:"total"`
+}
+
+func (b *AgingBuckets) add(hariTerlambat, sisa int) {
+	switch {
+	case hariTerlambat <= 0:
+		b.Current += sisa
+	case hariTerlambat <= 30:
+		b.Hari1_30 += sisa
+	case hariTerlambat <= 60:
+		b.Hari31_60 += sisa
+	case hariTerlambat <= 90:
+		b.Hari61_90 += sisa
+	default:
+		b.Hari90 += sisa
+	}
+	b.Total += sisa
+}
+
+// PiutangTagihan - Satu tagihan yang belum lunas beserta umurnya
+type PiutangTagihan struct {
+	TagihanID     uint   `json:"tagihan_id"`
+	Bulan         string `json:"bulan"`
+	JenisTagihan  string `json:"jenis_tagihan"`
+	Jumlah        int    `json:"jumlah"`
+	Terbayar      int    `json:"terbayar"`
+	Sisa          int    `json:"sisa"`
+	Status        string `json:"status"`
+	JatuhTempo    string `json:"jatuh_tempo"`
+	HariTerlambat int    `json:"hari_terlambat"`
+	Kelompok      string `json:"kelompok"`
+}
+
+// PiutangPenyewa - Ringkasan umur piutang satu penyewa
+type PiutangPenyewa struct {
+	PenyewaID     uint             `json:"penyewa_id"`
+	Nama          string           `json:"nama"`
+	NoHP          string           `json:"no_hp"`
+	JumlahTagihan int              `json:"jumlah_tagihan"`
+	TerlamaHari   int              `json:"terlama_hari"`
+	Buckets       AgingBuckets     `json:"buckets"`
+	Tagihan       []PiutangTagihan `json:"tagihan,omitempty"`
+}
+
+// jatuhTempo - Tagihan jatuh tempo di hari terakhir bulan periodenya
+func jatuhTempo(bulan string) (time.Time, error) {
+	tagihanDate, err := time.Parse("2006-01", bulan[:min(len(bulan), 7)])
+	if err != nil {
+		return time.Time{}, err
+	}
+	return tagihanDate.AddDate(0, 1, -1), nil
+}
+
+func kelompokUmur(hariTerlambat int) string {
+	switch {
+	case hariTerlambat <= 0:
+		return "current"
+	case hariTerlambat <= 30:
+		return "1-30"
+	case hariTerlambat <= 60:
+		return "31-60"
+	case hariTerlambat <= 90:
+		return "61-90"
+	default:
+		return "90+"
+	}
+}
+
+// buildUmurPiutang - Kelompokkan sisa tagihan per penyewa sesuai keadaan pada tanggal asOf:
+// terbayar dihitung dari pembayaran sampai asOf, dan tagihan yang periodenya dimulai setelah
+// asOf tidak ikut.
+func buildUmurPiutang(asOf time.Time, penyewaID uint) ([]PiutangPenyewa, AgingBuckets, error) {
+	terbayarSQL := "(SELECT COALESCE(SUM(p.jumlah), 0) FROM pembayarans p WHERE p.tagihan_id = tagihans.id AND p.deleted_at IS NULL AND p.tanggal <= ?)"
+	var tagihanList []models.Tagihan
+	query := database.DB.Preload("Penyewa").
+		Where("LEFT(bulan, 7) <= ?", asOf.Format("2006-01")).
+		Where("jumlah > "+terbayarSQL, asOf)
+	if penyewaID != 0 {
+		query = query.Where("penyewa_id = ?", penyewaID)
+	}
+	if err := query.Order("bulan ASC").Find(&tagihanList).Error; err != nil {
+		return nil, AgingBuckets{}, err
+	}
+
+	terbayar := make(map[uint]int)
+	if len(tagihanList) > 0 {
+		ids := make([]uint, len(tagihanList))
+		for i, t := range tagihanList {
+			ids[i] = t.ID
+		}
+		var bayar []struct {
+			TagihanID uint
+			Total     int
+		}
+		if err := database.DB.Model(&models.Pembayaran{}).Select("tagihan_id, SUM(jumlah) AS total").
+			Where("tagihan_id IN ? AND tanggal <= ?", ids, asOf).Group("tagihan_id").Scan(&bayar).Error; err != nil {
+			return nil, AgingBuckets{}, err
+		}
+		for _, b := range bayar {
+			terbayar[b.TagihanID] = b.Total
+		}
+	}
+
+	var total AgingBuckets
+	byPenyewa := make(map[uint]*PiutangPenyewa)
+	var order []uint
+	for _, t := range tagihanList {
+		due, err := jatuhTempo(t.Bulan)
+		if err != nil {
+			continue
+		}
+		hari := int(asOf.Sub(due).Hours() / 24)
+		t.Terbayar = terbayar[t.ID]
+		t.Status = statusFromTerbayar(t.Jumlah, t.Terbayar)
+		sisa := t.Jumlah - t.Terbayar
+
+		p, ok := byPenyewa[t.PenyewaID]
+		if !ok {
+			p = &PiutangPenyewa{PenyewaID: t.PenyewaID, Nama: t.Penyewa.Nama, NoHP: stringValue(t.Penyewa.NoHP)}
+			byPenyewa[t.PenyewaID] = p
+			order = append(order, t.PenyewaID)
+		}
+		p.JumlahTagihan++
+		p.Buckets.add(hari, sisa)
+		if hari > p.TerlamaHari {
+			p.TerlamaHari = hari
+		}
+		p.Tagihan = append(p.Tagihan, PiutangTagihan{
+			TagihanID:     t.ID,
+			Bulan:         t.Bulan,
+			JenisTagihan:  t.JenisTagihan,
+			Jumlah:        t.Jumlah,
+			Terbayar:      t.Terbayar,
+			Sisa:          sisa,
+			Status:        t.Status,
+			JatuhTempo:    due.Format("2006-01-02"),
+			HariTerlambat: max(hari, 0),
+			Kelompok:      kelompokUmur(hari),
+		})
+		total.add(hari, sisa)
+	}
+
+	result := make([]PiutangPenyewa, 0, len(order))
+	for _, id := range order {
+		result = append(result, *byPenyewa[id])
+	}
+	// Piutang terbesar dan terlama di atas
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].Buckets.Total != result[j].Buckets.Total {
+			return result[i].Buckets.Total > result[j].Buckets.Total
+		}
+		return result[i].TerlamaHari > result[j].TerlamaHari
+	})
+	return result, total, nil
+}
+
+// asOfDate - Query tanggal (YYYY-MM-DD) sebagai tanggal acuan, default hari ini
+func asOfDate(c *gin.Context) (time.Time, bool) {
+	value := c.Query("tanggal")
+	if value == "" {
+		return today(), true
+	}
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tanggal format"})
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// GetUmurPiutang - Laporan umur piutang per penyewa dan total
+// Query: tanggal (acuan, default hari ini), format (csv|xlsx)
+func GetUmurPiutang(c *gin.Context) {
+	asOf, ok := asOfDate(c)
+	if !ok {
+		return
+	}
+	penyewa, total, err := buildUmurPiutang(asOf, 0)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tagihan"})
+		return
+	}
+
+	table := services.ExportTable{
+		Title: "Umur Piutang",
+		Columns: []services.ExportColumn{
+			{Header: "Penyewa"}, {Header: "No HP"}, {Header: "Jumlah Tagihan"},
+			{Header: "Current", Rupiah: true}, {Header: "1-30 Hari", Rupiah: true}, {Header: "31-60 Hari", Rupiah: true},
+			{Header: "61-90 Hari", Rupiah: true}, {Header: "> 90 Hari", Rupiah: true}, {Header: "Total", Rupiah: true},
+		},
+	}
+	for i := range penyewa {
+		p := penyewa[i]
+		table.AddRow(p.Nama, p.NoHP, p.JumlahTagihan, p.Buckets.Current, p.Buckets.Hari1_30,
+			p.Buckets.Hari31_60, p.Buckets.Hari61_90, p.Buckets.Hari90, p.Buckets.Total)
+		// Detail tagihan hanya lewat drill-down
+		penyewa[i].Tagihan = nil
+	}
+	table.AddRow("TOTAL", "", "", total.Current, total.Hari1_30, total.Hari31_60, total.Hari61_90, total.Hari90, total.Total)
+	if respondExport(c, "umur-piutang-"+asOf.Format("20060102"), table) {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"tanggal": asOf.Format("2006-01-02"),
+		"total":   total,
+		"penyewa": penyewa,
+	})
+}
+
+// GetUmurPiutangPenyewa - Drill-down umur piutang satu penyewa sampai ke tagihan
+func GetUmurPiutangPenyewa(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid penyewa ID"})
+		return
+	}
+	asOf, ok := asOfDate(c)
+	if !ok {
+		return
+	}
+
+	var penyewa models.Penyewa
+	if err := database.DB.First(&penyewa, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Penyewa not found"})
+		return
+	}
+
+	result, _, err := buildUmurPiutang(asOf, uint(id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tagihan"})
+		return
+	}
+	detail := PiutangPenyewa{PenyewaID: penyewa.ID, Nama: penyewa.Nama, NoHP: stringValue(penyewa.NoHP), Tagihan: []PiutangTagihan{}}
+	if len(result) > 0 {
+		detail = result[0]
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"tanggal": asOf.Format("2006-01-02"),
+		"penyewa": detail,
+	})
+}
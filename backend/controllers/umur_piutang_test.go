@@ -0,0 +1,43 @@
+package controllers
+
+import "testing"
+
+func TestBuildUmurPiutangAsOf(t *testing.T) {
+	setupTestDB(t)
+	penyewa := seedPenyewa(t, "Andi")
+	jan := seedTagihan(t, penyewa, "2025-01", 1000000)
+	seedTagihan(t, penyewa, "2025-02", 1000000)
+	seedBayar(t, jan, 400000, "2025-01-20")
+	seedBayar(t, jan, 600000, "2025-02-05")
+
+	tests := []struct {
+		name     string
+		asOf     string
+		total    int
+		terbayar []int
+	}{
+		{"sebelum pembayaran", "2025-01-10", 1000000, []int{0}},
+		{"setelah cicilan pertama", "2025-01-31", 600000, []int{400000}},
+		{"januari lunas, februari terbuka", "2025-02-10", 1000000, []int{0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, total, err := buildUmurPiutang(tanggalTest(t, tt.asOf), 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if total.Total != tt.total {
+				t.Errorf("total = %d, want %d", total.Total, tt.total)
+			}
+			if len(rows) != 1 || len(rows[0].Tagihan) != len(tt.terbayar) {
+				t.Fatalf("rows = %+v", rows)
+			}
+			for i, want := range tt.terbayar {
+				got := rows[0].Tagihan[i]
+				if got.Terbayar != want || got.Sisa != got.Jumlah-want {
+					t.Errorf("tagihan %s: terbayar %d sisa %d, want terbayar %d", got.Bulan, got.Terbayar, got.Sisa, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package controllers
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"kos-muhandis/backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+var imageTypes = map[string]bool{"image/jpeg": true, "image/png": true, "image/webp": true, "image/gif": true}
+
+// uploadedFile - File multipart yang sudah dibaca dan dicek jenisnya
+type uploadedFile struct {
+	NamaFile    string
+	ContentType string
+	Data        []byte
+}
+
+// readUpload - Baca field multipart "file", tolak bila terlalu besar atau jenisnya tidak diizinkan.
+// Jenis file dideteksi dari isi, bukan dari nama file.
+func readUpload(c *gin.Context, maxSize int64, allowed map[string]bool) (uploadedFile, bool) {
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "File is required"})
+		return uploadedFile{}, false
+	}
+	if fileHeader.Size > maxSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("File too large (max %d MB)", maxSize>>20)})
+		return uploadedFile{}, false
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read file"})
+		return uploadedFile{}, false
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read file"})
+		return uploadedFile{}, false
+	}
+
+	contentType := http.DetectContentType(data)
+	if !allowed[contentType] {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "File type " + contentType + " is not allowed"})
+		return uploadedFile{}, false
+	}
+	return uploadedFile{
+		NamaFile:    strings.ReplaceAll(filepath.Base(fileHeader.Filename), `"`, ""),
+		ContentType: contentType,
+		Data:        data,
+	}, true
+}
+
+// storeUpload - Simpan file ke storage dengan key "<folder>/<id>/<timestamp><ext>"
+func storeUpload(folder string, id uint, f uploadedFile) (string, error) {
+	key := fmt.Sprintf("%s/%d/%d%s", folder, id, time.Now().UnixNano(), strings.ToLower(filepath.Ext(f.NamaFile)))
+	return key, services.NewStorage().Put(key, f.Data, f.ContentType)
+}
+
+// serveUpload - Kirim file dari storage ke client
+func serveUpload(c *gin.Context, key, namaFile, contentType string) {
+	data, err := services.NewStorage().Get(key)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
+		return
+	}
+	c.Header("Content-Disposition", `inline; filename="`+namaFile+`"`)
+	c.Data(http.StatusOK, contentType, data)
+}
@@ -0,0 +1,141 @@
+package controllers
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"kos-muhandis/backend/database"
+	"kos-muhandis/backend/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+var pngTest = append([]byte("\x89PNG\r\n\x1a\n"), make([]byte, 32)...)
+
+// pakaiLocalStorage - Arahkan NewStorage ke direktori sementara
+func pakaiLocalStorage(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("STORAGE_DRIVER", "")
+	t.Setenv("UPLOAD_DIR", dir)
+	return dir
+}
+
+// panggilUpload - Jalankan handler dengan body multipart berisi field "file"
+func panggilUpload(t *testing.T, handler gin.HandlerFunc, namaFile string, data []byte, params ...gin.Param) *httptest.ResponseRecorder {
+	t.Helper()
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	part, err := mw.CreateFormFile("file", namaFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	part.Write(data)
+	mw.Close()
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/upload", &body)
+	c.Request.Header.Set("Content-Type", mw.FormDataContentType())
+	c.Params = params
+	handler(c)
+	return w
+}
+
+func TestUploadLocalStorage(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	dir := pakaiLocalStorage(t)
+
+	var key string
+	simpan := func(c *gin.Context) {
+		upload, ok := readUpload(c, 1<<10, imageTypes)
+		if !ok {
+			return
+		}
+		var err error
+		if key, err = storeUpload("bukti", 3, upload); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusCreated, gin.H{"nama_file": upload.NamaFile})
+	}
+
+	tests := []struct {
+		name     string
+		namaFile string
+		data     []byte
+		status   int
+	}{
+		{"png", "Foto.PNG", pngTest, http.StatusCreated},
+		{"bukan gambar", "foto.png", []byte("bukan gambar"), http.StatusBadRequest},
+		{"terlalu besar", "besar.png", append(append([]byte{}, pngTest...), make([]byte, 2<<10)...), http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := panggilUpload(t, simpan, tt.namaFile, tt.data)
+			cekStatus(t, w, tt.status)
+		})
+	}
+
+	if !strings.HasPrefix(key, "bukti/3/") || !strings.HasSuffix(key, ".png") {
+		t.Fatalf("key = %q", key)
+	}
+	stored, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(key)))
+	if err != nil || !bytes.Equal(stored, pngTest) {
+		t.Fatalf("stored file = %q, %v", stored, err)
+	}
+	if entries, _ := os.ReadDir(filepath.Join(dir, "bukti", "3")); len(entries) != 1 {
+		t.Errorf("rejected uploads were stored: %d files", len(entries))
+	}
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	serveUpload(c, key, "Foto.PNG", "image/png")
+	cekStatus(t, w, http.StatusOK)
+	if !bytes.Equal(w.Body.Bytes(), pngTest) || w.Header().Get("Content-Type") != "image/png" {
+		t.Errorf("served %q (%s)", w.Body.Bytes(), w.Header().Get("Content-Type"))
+	}
+
+	w = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(w)
+	serveUpload(c, "bukti/3/../../rahasia", "x", "image/png")
+	cekStatus(t, w, http.StatusNotFound)
+}
+
+func TestUploadFotoPerbaikan(t *testing.T) {
+	setupTestDB(t)
+	dir := pakaiLocalStorage(t)
+	penyewa := seedPenyewa(t, "Citra")
+	perbaikan := models.Perbaikan{KamarID: penyewa.KamarID, Judul: "Lampu mati"}
+	if err := database.DB.Create(&perbaikan).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	w := panggilUpload(t, UploadFotoPerbaikan, "lampu.png", pngTest, gin.Param{Key: "id", Value: strconv.Itoa(int(perbaikan.ID))})
+	cekStatus(t, w, http.StatusCreated)
+	var foto models.PerbaikanFoto
+	decodeJSON(t, w, &foto)
+	database.DB.First(&foto, foto.ID)
+	if !strings.HasPrefix(foto.StorageKey, "perbaikan/"+strconv.Itoa(int(perbaikan.ID))+"/") {
+		t.Fatalf("storage key = %q", foto.StorageKey)
+	}
+	if _, err := os.Stat(filepath.Join(dir, filepath.FromSlash(foto.StorageKey))); err != nil {
+		t.Fatal("file not in UPLOAD_DIR:", err)
+	}
+
+	w = panggilHandler(GetFotoPerbaikan, http.MethodGet, "/perbaikan-foto", nil, gin.Param{Key: "id", Value: strconv.Itoa(int(foto.ID))})
+	cekStatus(t, w, http.StatusOK)
+	if !bytes.Equal(w.Body.Bytes(), pngTest) {
+		t.Errorf("served %q", w.Body.Bytes())
+	}
+
+	w = panggilUpload(t, UploadFotoPerbaikan, "lampu.png", pngTest, gin.Param{Key: "id", Value: "9999"})
+	cekStatus(t, w, http.StatusNotFound)
+}
@@ -0,0 +1,105 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"kos-muhandis/backend/database"
+	"kos-muhandis/backend/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func GetVendor(c *gin.Context) {
+	query := database.DB.Order("nama ASC")
+	if q := c.Query("q"); q != "" {
+		query = query.Where("nama ILIKE ?", "%"+q+"%")
+	}
+	var vendor []models.Vendor
+	if err := query.Find(&vendor).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch vendor"})
+		return
+	}
+	c.JSON(http.StatusOK, vendor)
+}
+
+func CreateVendor(c *gin.Context) {
+	var input models.Vendor
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if input.Nama == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Nama is required"})
+		return
+	}
+	if input.KategoriID != nil {
+		if err := database.DB.First(&models.Kategori{}, *input.KategoriID).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Kategori not found"})
+			return
+		}
+	}
+	vendor := models.Vendor{
+		Nama:       input.Nama,
+		Kontak:     input.Kontak,
+		NoHP:       input.NoHP,
+		Alamat:     input.Alamat,
+		KategoriID: input.KategoriID,
+		Keterangan: input.Keterangan,
+	}
+	if err := database.DB.Create(&vendor).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create vendor"})
+		return
+	}
+	c.JSON(http.StatusCreated, vendor)
+}
+
+func UpdateVendor(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	var vendor models.Vendor
+	if err := database.DB.First(&vendor, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Vendor not found"})
+		return
+	}
+	var input models.Vendor
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if input.Nama != "" {
+		vendor.Nama = input.Nama
+	}
+	if input.Kontak != "" {
+		vendor.Kontak = input.Kontak
+	}
+	if input.NoHP != "" {
+		vendor.NoHP = input.NoHP
+	}
+	if input.Alamat != "" {
+		vendor.Alamat = input.Alamat
+	}
+	if input.Keterangan != "" {
+		vendor.Keterangan = input.Keterangan
+	}
+	if input.KategoriID != nil {
+		if err := database.DB.First(&models.Kategori{}, *input.KategoriID).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Kategori not found"})
+			return
+		}
+		vendor.KategoriID = input.KategoriID
+	}
+	if err := database.DB.Save(&vendor).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update vendor"})
+		return
+	}
+	c.JSON(http.StatusOK, vendor)
+}
+
+func DeleteVendor(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	if err := database.DB.Delete(&models.Vendor{}, id).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete vendor"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Vendor deleted"})
+}
@@ -1,21 +1,32 @@
 package controllers
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
+	"os"
+	"strings"
+	"time"
 
 	"kos-muhandis/backend/database"
 	"kos-muhandis/backend/models"
+	"kos-muhandis/backend/services"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
-// SendWhatsAppReminder - Send WhatsApp reminder to penyewa
+// SendWhatsAppReminder - Send WhatsApp reminder to penyewa. message opsional dan boleh memakai
+// variabel template ({{nama}}, {{sisa}}, ...); bila kosong dipakai template tipe (default sesuai
+// jatuh tempo tagihan) dalam bahasa penyewa.
 func SendWhatsAppReminder(c *gin.Context) {
 	var input struct {
 		PenyewaID uint   `json:"penyewa_id" binding:"required"`
 		TagihanID uint   `json:"tagihan_id" binding:"required"`
-		Message   string `json:"message" binding:"required"`
+		Message   string `json:"message"`
+		Tipe      string `json:"tipe" binding:"omitempty,oneof=H-7 H-3 H-1 OVERDUE"`
+		Paksa     bool   `json:"paksa"` // lewati jam tenang dan batas harian
 	}
 
 	if err := c.ShouldBindJSON(&input); err != nil {
@@ -25,7 +36,7 @@ func SendWhatsAppReminder(c *gin.Context) {
 
 	// Get penyewa info
 	var penyewa models.Penyewa
-	if err := database.DB.First(&penyewa, input.PenyewaID).Error; err != nil {
+	if err := database.DB.Preload("Kamar").First(&penyewa, input.PenyewaID).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Penyewa not found"})
 		return
 	}
@@ -37,44 +48,49 @@ func SendWhatsAppReminder(c *gin.Context) {
 		return
 	}
 
+	if tagihanLunas(tagihan) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Tagihan sudah lunas"})
+		return
+	}
+
 	// Validate phone number
 	if penyewa.NoHP == nil || *penyewa.NoHP == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Penyewa doesn't have phone number"})
 		return
 	}
 
-	// Format phone number (add +62 if needed)
-	phoneNumber := *penyewa.NoHP
-	if phoneNumber[0:1] == "0" {
-		phoneNumber = "+62" + phoneNumber[1:]
-	} else if phoneNumber[0:3] != "+62" {
-		phoneNumber = "+62" + phoneNumber
+	if err := izinPengingat(penyewa, input.Paksa); err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
 	}
 
-	// Build message dengan informasi tagihan
-	fullMessage := fmt.Sprintf(
-		"Halo %s,\n\n%s\n\nTagihan Bulan: %s\nJumlah: Rp %d\n\nMohon segera melakukan pembayaran.\n\nTerima kasih.",
-		penyewa.Nama,
-		input.Message,
-		tagihan.Bulan,
-		tagihan.Jumlah,
-	)
+	// Format phone number (add +62 if needed)
+	phoneNumber := formatPhoneNumber(*penyewa.NoHP)
+
+	// Build message dari template
+	tipe := input.Tipe
+	if tipe == "" {
+		tipe = tipePengingat(tagihan.Bulan)
+	}
+	fullMessage := services.RenderTemplate(input.Message, dataTemplate(penyewa, &tagihan))
+	if input.Message == "" {
+		fullMessage = pesanTemplate(tipe, penyewa, &tagihan)
+	}
 
 	// Send WhatsApp message (using Twilio)
 	// Note: Ini adalah implementasi dasar, Anda perlu setup Twilio account
 	result := SendViaWhatsApp(phoneNumber, fullMessage)
 
 	if result.Success {
-		// Update notification status
-		var notif models.Notifikasi
-		if err := database.DB.Where("tagihan_id = ? AND penyewa_id = ?", input.TagihanID, input.PenyewaID).
-			First(&notif).Error; err == nil {
-			database.DB.Model(&notif).Update("status", "sent")
+		if err := catatKirimanManual(penyewa, tagihan, tipe, fullMessage, phoneNumber, result.MessageID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "WhatsApp sent but failed to record notifikasi"})
+			return
 		}
 
 		c.JSON(http.StatusOK, gin.H{
 			"message": "WhatsApp reminder sent successfully",
 			"phone":   phoneNumber,
+			"text":    fullMessage,
 		})
 	} else {
 		c.JSON(http.StatusInternalServerError, gin.H{
@@ -83,47 +99,129 @@ func SendWhatsAppReminder(c *gin.Context) {
 	}
 }
 
-// SendBroadcastReminder - Send broadcast reminder to all penyewa with due bills
+// catatKirimanManual - Catat pengingat manual sebagai kiriman ke penyewa agar ikut dihitung batas
+// harian. Notifikasi penerima penyewa untuk tagihan itu dipakai (tipe yang sama didahulukan) atau
+// dibuat bila belum ada.
+func catatKirimanManual(penyewa models.Penyewa, tagihan models.Tagihan, tipe, pesan, tujuan, providerID string) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		cari := func(notif *models.Notifikasi) error {
+			return tx.Where("tagihan_id = ? AND penerima = ?", tagihan.ID, "penyewa").
+				Order(clause.OrderBy{Expression: clause.Expr{SQL: "tipe = ? DESC, id DESC", Vars: []interface{}{tipe}}}).
+				Take(notif).Error
+		}
+		var notif models.Notifikasi
+		err := cari(&notif)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			baru := models.Notifikasi{
+				PenyewaID: penyewa.ID, TagihanID: tagihan.ID, Tipe: tipe, Penerima: "penyewa",
+				Status: "pending", Message: pesan,
+			}
+			if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&baru).Error; err != nil {
+				return err
+			}
+			err = cari(&notif)
+		}
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		if err := tx.Create(&models.PengirimanNotifikasi{
+			NotifikasiID: notif.ID,
+			Kiriman:      idKiriman(penyewa.ID),
+			Kanal:        "whatsapp",
+			Tujuan:       tujuan,
+			Status:       services.DeliverySent,
+			ProviderID:   providerID,
+			DikirimPada:  &now,
+		}).Error; err != nil {
+			return err
+		}
+		return tx.Model(&notif).Updates(map[string]interface{}{"status": services.DeliverySent, "sent_at": now}).Error
+	})
+}
+
+// tipePengingat - Tipe template pengingat terdekat sesuai sisa hari ke jatuh tempo
+func tipePengingat(bulan string) string {
+	due, err := jatuhTempo(bulan)
+	if err != nil {
+		return "H-1"
+	}
+	hari := int(due.Sub(today()).Hours() / 24)
+	switch {
+	case hari < 0:
+		return "OVERDUE"
+	case hari <= 1:
+		return "H-1"
+	case hari <= 3:
+		return "H-3"
+	default:
+		return "H-7"
+	}
+}
+
+// SendBroadcastReminder - Send broadcast reminder untuk semua notifikasi pending dari langkah
+// eskalasi aktif. Beberapa tagihan satu penyewa digabung menjadi satu pesan. Saat jam tenang, melewati batas harian,
+// atau penyewa sudah STOP, notifikasi tetap pending dan dilewati.
 func SendBroadcastReminder(c *gin.Context) {
 	var notifikasiList []models.Notifikasi
-	database.DB.Where("status = ? AND tipe IN ?", "pending", []string{"H-1", "OVERDUE"}).
-		Preload("Penyewa").
+	database.DB.Where("status = ? AND tipe IN (?)", "pending",
+		database.DB.Model(&models.LangkahEskalasi{}).Select("tipe").Where("aktif = ?", true)).
+		Preload("Penyewa.Kamar").
 		Preload("Tagihan").
+		Order("penyewa_id, id").
 		Find(&notifikasiList)
 
-	successCount := 0
-	failCount := 0
-
-	for _, notif := range notifikasiList {
-		phoneNumber := ""
-		if notif.Penyewa.NoHP != nil {
-			phoneNumber = *notif.Penyewa.NoHP
-		}
-		if phoneNumber == "" {
-			failCount++
+	// Notifikasi untuk penyewa digabung per penyewa; kontak darurat dan pemilik dikirim satu per satu
+	var kiriman [][]*models.Notifikasi
+	perPenyewa := make(map[uint]int)
+	for i := range notifikasiList {
+		notif := &notifikasiList[i]
+		if notif.Penerima != "" && notif.Penerima != "penyewa" {
+			kiriman = append(kiriman, []*models.Notifikasi{notif})
 			continue
 		}
-
-		if phoneNumber[0:1] == "0" {
-			phoneNumber = "+62" + phoneNumber[1:]
-		} else if phoneNumber[0:3] != "+62" {
-			phoneNumber = "+62" + phoneNumber
+		if idx, ok := perPenyewa[notif.PenyewaID]; ok {
+			kiriman[idx] = append(kiriman[idx], notif)
+			continue
 		}
+		perPenyewa[notif.PenyewaID] = len(kiriman)
+		kiriman = append(kiriman, []*models.Notifikasi{notif})
+	}
 
-		result := SendViaWhatsApp(phoneNumber, notif.Message)
-		if result.Success {
-			database.DB.Model(&notif).Update("status", "sent")
+	successCount := 0
+	failCount := 0
+	ditunda := 0
+	berhenti := 0
+	lunas := 0
+
+	for _, daftar := range kiriman {
+		// Kirim lewat kanal penerima berurutan (default WhatsApp)
+		_, err := kirimNotifikasi(daftar, false)
+		switch {
+		case err == nil:
 			successCount++
-		} else {
+		case errors.Is(err, errBerhentiLangganan):
+			berhenti++
+		case errors.Is(err, errTagihanLunas):
+			lunas++
+		case errors.Is(err, errJamTenang), errors.Is(err, errBatasHarian):
+			ditunda++
+		default:
 			failCount++
 		}
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Broadcast reminder completed",
-		"success": successCount,
-		"failed":  failCount,
-		"total":   len(notifikasiList),
+		"message":    "Broadcast reminder completed",
+		"success":    successCount,
+		"failed":     failCount,
+		"ditunda":    ditunda,
+		"berhenti":   berhenti,
+		"lunas":      lunas,
+		"total":      len(kiriman),
+		"notifikasi": len(notifikasiList),
+		"jam_tenang": dalamJamTenang(time.Now()),
 	})
 }
 
@@ -134,40 +232,42 @@ type WhatsAppResponse struct {
 	Error     string
 }
 
-// SendViaWhatsApp - Helper function to send WhatsApp message via Twilio
-// IMPORTANT: Setup environment variables untuk Twilio credentials
-// TWILIO_ACCOUNT_SID, TWILIO_AUTH_TOKEN, TWILIO_WHATSAPP_NUMBER
+// SendViaWhatsApp - Kirim pesan WhatsApp. Dengan WHATSAPP_PROVIDER=twilio pesan dikirim lewat
+// Twilio (TWILIO_ACCOUNT_SID, TWILIO_AUTH_TOKEN, TWILIO_PHONE_NUMBER) dan MessageID berisi SID
+// Twilio; provider lain (fake) tidak mengirim apa pun dan mengembalikan id mock-<nanodetik>.
 func SendViaWhatsApp(toNumber, message string) WhatsAppResponse {
-	// TODO: Implement Twilio WhatsApp API integration
-	// For now, returning mock success
-	//
-	// Example implementation:
-	// accountSID := os.Getenv("TWILIO_ACCOUNT_SID")
-	// authToken := os.Getenv("TWILIO_AUTH_TOKEN")
-	// fromNumber := os.Getenv("TWILIO_WHATSAPP_NUMBER") // format: whatsapp:+62...
-	// toWhatsApp := "whatsapp:" + toNumber
-	//
-	// urlStr := "https://api.twilio.com/2010-04-01/Accounts/" + accountSID + "/Messages.json"
-	//
-	// v := url.Values{}
-	// v.Set("From", fromNumber)
-	// v.Set("To", toWhatsApp)
-	// v.Set("Body", message)
-	//
-	// req, _ := http.NewRequest("POST", urlStr, strings.NewReader(v.Encode()))
-	// req.SetBasicAuth(accountSID, authToken)
-	// req.Header.Add("Accept", "application/json")
-	// req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
-	//
-	// client := &http.Client{}
-	// resp, err := client.Do(req)
-
-	// Mock implementation untuk testing
-	return WhatsAppResponse{
-		Success:   true,
-		MessageID: "mock-msg-id",
-		Error:     "",
+	return SendViaWhatsAppMedia(toNumber, message, "")
+}
+
+// SendViaWhatsAppMedia - Kirim pesan WhatsApp dengan lampiran (PDF invoice/kwitansi)
+// Twilio mengambil lampiran dari MediaUrl, jadi mediaURL harus bisa diakses publik
+func SendViaWhatsAppMedia(toNumber, message, mediaURL string) WhatsAppResponse {
+	if os.Getenv("WHATSAPP_PROVIDER") != "twilio" {
+		return WhatsAppResponse{
+			Success:   true,
+			MessageID: fmt.Sprintf("mock-%d", time.Now().UnixNano()),
+		}
+	}
+	provider, err := services.NewTwilioWhatsApp()
+	if err != nil {
+		return WhatsAppResponse{Success: false, Error: err.Error()}
+	}
+	sid, err := provider.Send(toNumber, message, mediaURL)
+	if err != nil {
+		return WhatsAppResponse{Success: false, Error: err.Error()}
+	}
+	return WhatsAppResponse{Success: true, MessageID: sid}
+}
+
+// formatPhoneNumber - Normalisasi nomor HP ke format +62
+func formatPhoneNumber(phoneNumber string) string {
+	if strings.HasPrefix(phoneNumber, "0") {
+		return "+62" + phoneNumber[1:]
+	}
+	if !strings.HasPrefix(phoneNumber, "+62") {
+		return "+62" + phoneNumber
 	}
+	return phoneNumber
 }
 
 // GetWhatsAppSettings - Get WhatsApp settings untuk penyewa
@@ -181,10 +281,11 @@ func GetWhatsAppSettings(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"penyewa_id": penyewa.ID,
-		"nama":       penyewa.Nama,
-		"no_hp":      penyewa.NoHP,
-		"has_phone":  penyewa.NoHP != nil && *penyewa.NoHP != "",
+		"penyewa_id":    penyewa.ID,
+		"nama":          penyewa.Nama,
+		"no_hp":         penyewa.NoHP,
+		"has_phone":     penyewa.NoHP != nil && *penyewa.NoHP != "",
+		"berhenti_pada": penyewa.BerhentiPada,
 	})
 }
 
@@ -244,3 +345,28 @@ func TestWhatsAppMessage(c *gin.Context) {
 		})
 	}
 }
+
+// SendWelcomeWhatsApp - Kirim pesan selamat datang (template SELAMAT_DATANG) ke penyewa
+func SendWelcomeWhatsApp(c *gin.Context) {
+	var penyewa models.Penyewa
+	if err := database.DB.Preload("Kamar").First(&penyewa, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Penyewa not found"})
+		return
+	}
+	if penyewa.NoHP == nil || *penyewa.NoHP == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Penyewa doesn't have phone number"})
+		return
+	}
+	if err := izinPesan(penyewa); err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	phoneNumber := formatPhoneNumber(*penyewa.NoHP)
+	message := pesanTemplate("SELAMAT_DATANG", penyewa, nil)
+	result := SendViaWhatsApp(phoneNumber, message)
+	if !result.Success {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send WhatsApp: " + result.Error})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Welcome message sent successfully", "phone": phoneNumber, "text": message})
+}
@@ -0,0 +1,346 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"kos-muhandis/backend/database"
+	"kos-muhandis/backend/models"
+	"kos-muhandis/backend/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// WhatsAppWebhook - Webhook pesan WhatsApp masuk (publik). Tanda tangan diverifikasi oleh
+// provider; pengirim dicocokkan ke penyewa lewat nomor HP lalu perintahnya dibalas.
+func WhatsAppWebhook(c *gin.Context) {
+	provider, err := services.NewWhatsAppProvider()
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		return
+	}
+	if c.Param("provider") != provider.Name() {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Unknown provider"})
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, 1<<20))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read body"})
+		return
+	}
+	webhookURL, ok := urlWebhook(c, provider)
+	if !ok {
+		return
+	}
+	status, response := prosesPesanMasuk(provider, webhookURL, body, c.Request.Header)
+	c.JSON(status, response)
+}
+
+// urlWebhook - URL publik webhook yang dipanggil provider. Tanda tangan Twilio dihitung dari URL ini,
+// jadi tanpa PUBLIC_BASE_URL semua webhook Twilio akan ditolak; balas 503 agar salah konfigurasi terlihat.
+func urlWebhook(c *gin.Context, provider services.WhatsAppProvider) (string, bool) {
+	baseURL := strings.TrimRight(os.Getenv("PUBLIC_BASE_URL"), "/")
+	if baseURL == "" && provider.Name() == "twilio" {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "PUBLIC_BASE_URL is not configured; it is required to verify Twilio webhook signatures"})
+		return "", false
+	}
+	return baseURL + c.Request.URL.RequestURI(), true
+}
+
+// SimulasiWhatsAppMasuk - Kirim webhook bertanda tangan dari provider fake seolah penyewa
+// mengirim pesan. Body: from (nomor HP), pesan.
+func SimulasiWhatsAppMasuk(c *gin.Context) {
+	var input struct {
+		From  string `json:"from" binding:"required"`
+		Pesan string `json:"pesan" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		return
+	}
+	provider, err := services.NewWhatsAppProvider()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	fake, ok := provider.(services.FakeWhatsApp)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Simulasi hanya tersedia untuk provider fake"})
+		return
+	}
+	body, header := fake.Simulate(input.From, input.Pesan)
+	status, response := prosesPesanMasuk(fake, "", body, header)
+	c.JSON(status, response)
+}
+
+// GetPercakapan - Riwayat pesan WhatsApp terbaru (filter: penyewa_id, no_hp,
+// tanpa_penyewa=true untuk nomor tak terdaftar, limit default 100)
+func GetPercakapan(c *gin.Context) {
+	query := database.DB.Preload("Penyewa")
+	if penyewaID := c.Query("penyewa_id"); penyewaID != "" {
+		query = query.Where("penyewa_id = ?", penyewaID)
+	}
+	if noHP := c.Query("no_hp"); noHP != "" {
+		query = query.Where("no_hp = ?", nomorWhatsApp(noHP))
+	}
+	if c.Query("tanpa_penyewa") == "true" {
+		query = query.Where("penyewa_id IS NULL")
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	if err != nil || limit <= 0 {
+		limit = 100
+	}
+	var pesan []models.Percakapan
+	if err := query.Order("created_at DESC, id DESC").Limit(limit).Find(&pesan).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch percakapan"})
+		return
+	}
+	c.JSON(http.StatusOK, pesan)
+}
+
+// GetPercakapanPenyewa - Thread percakapan WhatsApp satu penyewa, urut waktu
+func GetPercakapanPenyewa(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	var pesan []models.Percakapan
+	if err := database.DB.Where("penyewa_id = ?", id).Order("created_at ASC, id ASC").Find(&pesan).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch percakapan"})
+		return
+	}
+	c.JSON(http.StatusOK, pesan)
+}
+
+// BalasPercakapan - Pengelola membalas penyewa; pesan dicatat di thread penyewa
+func BalasPercakapan(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	var input struct {
+		Pesan string `json:"pesan" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		return
+	}
+	var penyewa models.Penyewa
+	if err := database.DB.First(&penyewa, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Penyewa not found"})
+		return
+	}
+	if penyewa.NoHP == nil || *penyewa.NoHP == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Penyewa doesn't have phone number"})
+		return
+	}
+	if err := izinPesan(penyewa); err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	pesan := kirimPercakapan(&penyewa.ID, nomorWhatsApp(*penyewa.NoHP), input.Pesan, "", "")
+	if pesan.Status != "sent" {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send WhatsApp: " + pesan.Error})
+		return
+	}
+	c.JSON(http.StatusCreated, pesan)
+}
+
+// prosesPesanMasuk - Verifikasi webhook, catat pesan masuk, lalu balas perintahnya.
+// Pesan yang dikirim ulang provider (message id sama) dijawab 200 tanpa dibalas lagi.
+func prosesPesanMasuk(provider services.WhatsAppProvider, webhookURL string, body []byte, header http.Header) (int, gin.H) {
+	inbound, err := provider.ParseInbound(webhookURL, body, header)
+	if errors.Is(err, services.ErrInvalidSignature) {
+		return http.StatusUnauthorized, gin.H{"error": "Invalid signature"}
+	}
+	if err != nil || inbound.From == "" {
+		return http.StatusBadRequest, gin.H{"error": "Invalid payload"}
+	}
+
+	if inbound.MessageID != "" {
+		var count int64
+		database.DB.Model(&models.Percakapan{}).
+			Where("provider = ? AND message_id = ? AND arah = ?", provider.Name(), inbound.MessageID, "masuk").
+			Count(&count)
+		if count > 0 {
+			return http.StatusOK, gin.H{"message": "Pesan sudah diproses"}
+		}
+	}
+
+	noHP := nomorWhatsApp(inbound.From)
+	penyewa, dikenal := penyewaDariNomor(noHP)
+	var penyewaID *uint
+	if dikenal {
+		penyewaID = &penyewa.ID
+	}
+	perintah, argumen := bacaPerintah(inbound.Body)
+	masuk := models.Percakapan{
+		PenyewaID: penyewaID,
+		NoHP:      noHP,
+		Arah:      "masuk",
+		Pesan:     inbound.Body,
+		Perintah:  perintah,
+		Provider:  provider.Name(),
+		MessageID: inbound.MessageID,
+		Status:    "received",
+	}
+	if !inbound.ReceivedAt.IsZero() {
+		masuk.CreatedAt = inbound.ReceivedAt
+	}
+	if err := database.DB.Create(&masuk).Error; err != nil {
+		// Index unik (provider, message_id): webhook ganda yang datang bersamaan
+		return http.StatusOK, gin.H{"message": "Pesan sudah diproses"}
+	}
+
+	var balasan, mediaURL string
+	if !dikenal {
+		balasan = renderPesan("BALASAN_TIDAK_TERDAFTAR", models.Penyewa{}, map[string]string{})
+	} else {
+		balasan, mediaURL = balasPerintah(penyewa, perintah, argumen)
+	}
+	keluar := kirimPercakapan(penyewaID, noHP, balasan, mediaURL, perintah)
+	return http.StatusOK, gin.H{"message": "Pesan diterima", "masuk": masuk, "balasan": keluar}
+}
+
+// bacaPerintah - Kata pertama pesan sebagai perintah (huruf besar) dan sisanya sebagai argumen
+func bacaPerintah(pesan string) (string, string) {
+	kata := strings.Fields(pesan)
+	if len(kata) == 0 {
+		return "BANTUAN", ""
+	}
+	perintah := strings.ToUpper(kata[0])
+	switch perintah {
+	case "TAGIHAN", "SALDO", "KWITANSI":
+		return perintah, strings.Join(kata[1:], " ")
+	case "STOP", "BERHENTI", "UNSUBSCRIBE":
+		return "STOP", ""
+	case "START", "MULAI":
+		return "START", ""
+	}
+	return "BANTUAN", ""
+}
+
+// balasPerintah - Susun balasan untuk perintah penyewa (template BALASAN_* dalam bahasa penyewa),
+// beserta link lampiran bila ada. Balasan perintah tetap dikirim setelah STOP karena penyewa sendiri yang meminta.
+func balasPerintah(penyewa models.Penyewa, perintah, argumen string) (string, string) {
+	data := dataTemplate(penyewa, nil)
+	switch perintah {
+	case "STOP":
+		database.DB.Model(&models.Penyewa{}).Where("id = ? AND berhenti_pada IS NULL", penyewa.ID).Update("berhenti_pada", time.Now())
+		return renderPesan("BALASAN_STOP", penyewa, data), ""
+
+	case "START":
+		database.DB.Model(&models.Penyewa{}).Where("id = ?", penyewa.ID).Update("berhenti_pada", nil)
+		return renderPesan("BALASAN_START", penyewa, data), ""
+
+	case "TAGIHAN":
+		var tagihan []models.Tagihan
+		database.DB.Where("penyewa_id = ? AND terbayar < jumlah", penyewa.ID).Order("bulan ASC, id ASC").Find(&tagihan)
+		if len(tagihan) == 0 {
+			return renderPesan("BALASAN_TAGIHAN_KOSONG", penyewa, data), ""
+		}
+		return renderPesan("BALASAN_TAGIHAN", penyewa, dataGabungan(penyewa, tagihan)), ""
+
+	case "SALDO":
+		var ringkasan struct {
+			Jumlah int
+			Sisa   int
+		}
+		database.DB.Model(&models.Tagihan{}).
+			Select("COUNT(*) AS jumlah, COALESCE(SUM(jumlah - terbayar), 0) AS sisa").
+			Where("penyewa_id = ? AND terbayar < jumlah", penyewa.ID).
+			Scan(&ringkasan)
+		data["total_sisa"] = services.FormatRupiah(ringkasan.Sisa)
+		data["jumlah_tagihan"] = strconv.Itoa(ringkasan.Jumlah)
+		data["pembayaran_terakhir"] = "-"
+		var terakhir models.Pembayaran
+		if err := database.DB.Joins("JOIN tagihans ON tagihans.id = pembayarans.tagihan_id").
+			Where("tagihans.penyewa_id = ?", penyewa.ID).
+			Order("pembayarans.tanggal DESC, pembayarans.id DESC").
+			First(&terakhir).Error; err == nil {
+			data["pembayaran_terakhir"] = services.FormatRupiah(terakhir.Jumlah) + " (" + services.FormatTanggalBahasa(terakhir.Tanggal, bahasaPenyewa(penyewa)) + ")"
+		}
+		return renderPesan("BALASAN_SALDO", penyewa, data), ""
+
+	case "KWITANSI":
+		query := database.DB.Where("penyewa_id = ? AND terbayar > 0", penyewa.ID)
+		if argumen != "" {
+			query = query.Where("bulan = ?", argumen)
+		}
+		var tagihan models.Tagihan
+		if err := query.Order("bulan DESC, id DESC").First(&tagihan).Error; err != nil {
+			return renderPesan("BALASAN_KWITANSI_KOSONG", penyewa, data), ""
+		}
+		baseURL := os.Getenv("PUBLIC_BASE_URL")
+		id := strconv.Itoa(int(tagihan.ID))
+		token, err := signDokumen("kwitansi", id)
+		if baseURL == "" || err != nil {
+			return renderPesan("BALASAN_KWITANSI_MANUAL", penyewa, data), ""
+		}
+		mediaURL := fmt.Sprintf("%s/dokumen/kwitansi/%s?token=%s", baseURL, id, token)
+		return renderPesan("BALASAN_KWITANSI", penyewa, dataTemplate(penyewa, &tagihan)), mediaURL
+	}
+	return renderPesan("BALASAN_BANTUAN", penyewa, data), ""
+}
+
+// kirimPercakapan - Kirim pesan WhatsApp (dengan lampiran bila mediaURL diisi) dan catat di thread
+func kirimPercakapan(penyewaID *uint, noHP, pesan, mediaURL, perintah string) models.Percakapan {
+	var result WhatsAppResponse
+	if mediaURL != "" {
+		result = SendViaWhatsAppMedia(noHP, pesan, mediaURL)
+	} else {
+		result = SendViaWhatsApp(noHP, pesan)
+	}
+	keluar := models.Percakapan{
+		PenyewaID: penyewaID,
+		NoHP:      noHP,
+		Arah:      "keluar",
+		Pesan:     pesan,
+		Perintah:  perintah,
+		MessageID: result.MessageID,
+		Status:    "sent",
+	}
+	if !result.Success {
+		keluar.Status = "failed"
+		keluar.Error = result.Error
+	}
+	database.DB.Create(&keluar)
+	return keluar
+}
+
+// penyewaDariNomor - Penyewa dengan nomor HP yang sama; penyewa yang belum keluar didahulukan
+func penyewaDariNomor(noHP string) (models.Penyewa, bool) {
+	var daftar []models.Penyewa
+	database.DB.Where("no_hp IS NOT NULL AND no_hp <> ''").Order("id DESC").Find(&daftar)
+	var cocok *models.Penyewa
+	for i := range daftar {
+		p := &daftar[i]
+		if nomorWhatsApp(*p.NoHP) != noHP {
+			continue
+		}
+		if p.TanggalKeluar == nil || !p.TanggalKeluar.Before(today()) {
+			return *p, true
+		}
+		if cocok == nil {
+			cocok = p
+		}
+	}
+	if cocok == nil {
+		return models.Penyewa{}, false
+	}
+	return *cocok, true
+}
+
+// nomorWhatsApp - Normalisasi nomor HP ke format +62..., mengabaikan spasi dan tanda baca
+func nomorWhatsApp(nomor string) string {
+	digits := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, nomor)
+	if strings.HasPrefix(digits, "62") {
+		return "+" + digits
+	}
+	return formatPhoneNumber(digits)
+}
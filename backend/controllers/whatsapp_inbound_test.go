@@ -0,0 +1,18 @@
+package controllers
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestWhatsAppWebhookTwilioTanpaPublicBaseURL(t *testing.T) {
+	t.Setenv("WHATSAPP_PROVIDER", "twilio")
+	t.Setenv("TWILIO_AUTH_TOKEN", "12345")
+	t.Setenv("PUBLIC_BASE_URL", "")
+	for _, handler := range []gin.HandlerFunc{WhatsAppWebhook, WhatsAppStatusWebhook} {
+		w := panggilHandler(handler, http.MethodPost, "/webhook/whatsapp/twilio", nil, gin.Param{Key: "provider", Value: "twilio"})
+		cekStatus(t, w, http.StatusServiceUnavailable)
+	}
+}
@@ -0,0 +1,57 @@
+package controllers
+
+import (
+	"net/http"
+	"testing"
+
+	"kos-muhandis/backend/database"
+	"kos-muhandis/backend/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestSendWhatsAppReminderBatasHarian(t *testing.T) {
+	setupTestDB(t)
+	t.Setenv("NOTIF_JAM_TENANG", "off")
+	t.Setenv("NOTIF_BATAS_HARIAN", "2")
+	penyewa := seedPenyewa(t, "Kiki")
+	database.DB.Model(&penyewa).Update("no_hp", "081234567890")
+	tagihan := seedTagihan(t, penyewa, "2025-01", 1000000)
+	lunas := seedTagihan(t, penyewa, "2025-02", 1000000)
+	seedBayar(t, lunas, 1000000, "2025-02-05")
+
+	// Notifikasi kontak darurat tidak boleh dipakai untuk mencatat pengingat ke penyewa
+	darurat := models.Notifikasi{PenyewaID: penyewa.ID, TagihanID: tagihan.ID, Tipe: "DARURAT", Penerima: "kontak_darurat", Status: "pending"}
+	if err := database.DB.Create(&darurat).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	kirim := func(tagihanID uint) int {
+		w := panggilHandler(SendWhatsAppReminder, http.MethodPost, "/whatsapp/send",
+			gin.H{"penyewa_id": penyewa.ID, "tagihan_id": tagihanID, "tipe": "OVERDUE"})
+		return w.Code
+	}
+	for i, want := range []int{http.StatusOK, http.StatusOK, http.StatusConflict} {
+		if got := kirim(tagihan.ID); got != want {
+			t.Fatalf("kirim %d: status %d, want %d", i+1, got, want)
+		}
+	}
+	if got := kirim(lunas.ID); got != http.StatusBadRequest {
+		t.Errorf("tagihan lunas: status %d, want 400", got)
+	}
+
+	if got := kirimanHariIni(penyewa.ID); got != 2 {
+		t.Errorf("kirimanHariIni = %d, want 2", got)
+	}
+	var notif models.Notifikasi
+	if err := database.DB.Where("tagihan_id = ? AND penerima = ?", tagihan.ID, "penyewa").First(&notif).Error; err != nil {
+		t.Fatal("notifikasi penyewa not created:", err)
+	}
+	if notif.Tipe != "OVERDUE" || notif.Status != "sent" || notif.SentAt == nil {
+		t.Errorf("notifikasi penyewa = %+v", notif)
+	}
+	database.DB.First(&darurat, darurat.ID)
+	if darurat.Status != "pending" {
+		t.Errorf("notifikasi kontak darurat changed to %s", darurat.Status)
+	}
+}
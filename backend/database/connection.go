@@ -64,10 +64,19 @@ func Connect() {
 
 	log.Println("Database connection successful")
 
+	Migrate()
+}
+
+// Migrate - Buat/ubah tabel, seed data bawaan dan jalankan migrasi data satu kali.
+// Aman dijalankan berulang; dipakai juga oleh test yang memakai database.
+func Migrate() {
+	var err error
+
 	// Handle transaksi table migration - drop and recreate if needed
 	if DB.Migrator().HasTable(&models.Transaksi{}) {
-		// Check if old tagihan_id column exists
-		if DB.Migrator().HasColumn(&models.Transaksi{}, "tagihan_id") {
+		// Check if old tagihan_id column exists (struktur lama belum punya pembayaran_id;
+		// tagihan_id yang sekarang selalu ditambahkan bersama pembayaran_id)
+		if DB.Migrator().HasColumn(&models.Transaksi{}, "tagihan_id") && !DB.Migrator().HasColumn(&models.Transaksi{}, "pembayaran_id") {
 			log.Println("Old transaksi table structure detected, recreating table...")
 			err = DB.Migrator().DropTable(&models.Transaksi{})
 			if err != nil {
@@ -185,5 +194,764 @@ func Connect() {
 		log.Fatal("Failed to create notifikasis table:", err)
 	}
 
+	err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS pembayarans (
+			id SERIAL PRIMARY KEY,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			deleted_at TIMESTAMP NULL,
+			tagihan_id INTEGER NOT NULL,
+			penyewa_id INTEGER NOT NULL,
+			jumlah INTEGER NOT NULL,
+			tanggal DATE NOT NULL,
+			diterima_oleh VARCHAR(255) NULL,
+			keterangan TEXT NULL
+		)
+	`).Error
+	if err != nil {
+		log.Fatal("Failed to create pembayarans table:", err)
+	}
+
+	err = DB.Exec(`ALTER TABLE penyewas ADD COLUMN IF NOT EXISTS tanggal_keluar DATE NULL`).Error
+	if err != nil {
+		log.Fatal("Failed to add penyewas.tanggal_keluar column:", err)
+	}
+
+	err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS perubahan_hargas (
+			id SERIAL PRIMARY KEY,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			deleted_at TIMESTAMP NULL,
+			kamar_id INTEGER NOT NULL,
+			harga_baru INTEGER NOT NULL,
+			berlaku_mulai DATE NOT NULL,
+			keterangan TEXT NULL
+		)
+	`).Error
+	if err != nil {
+		log.Fatal("Failed to create perubahan_hargas table:", err)
+	}
+
+	err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS pengeluaran_rutins (
+			id SERIAL PRIMARY KEY,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			deleted_at TIMESTAMP NULL,
+			nama VARCHAR(255) NOT NULL,
+			kategori VARCHAR(255) NOT NULL,
+			jumlah INTEGER NOT NULL,
+			interval_bulan INTEGER DEFAULT 1,
+			mulai DATE NOT NULL,
+			selesai DATE NULL
+		)
+	`).Error
+	if err != nil {
+		log.Fatal("Failed to create pengeluaran_rutins table:", err)
+	}
+
+	err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS perbaikans (
+			id SERIAL PRIMARY KEY,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			deleted_at TIMESTAMP NULL,
+			kamar_id INTEGER NOT NULL,
+			judul VARCHAR(255) NOT NULL,
+			deskripsi TEXT NULL,
+			pelapor VARCHAR(255) NULL,
+			penyewa_id INTEGER NULL,
+			dilaporkan_oleh VARCHAR(255) NULL,
+			prioritas VARCHAR(255) DEFAULT 'Sedang',
+			status VARCHAR(255) DEFAULT 'Dilaporkan',
+			petugas VARCHAR(255) NULL,
+			biaya INTEGER DEFAULT 0,
+			transaksi_id INTEGER NULL,
+			catatan TEXT NULL,
+			tanggal_mulai DATE NULL,
+			tanggal_selesai DATE NULL
+		)
+	`).Error
+	if err != nil {
+		log.Fatal("Failed to create perbaikans table:", err)
+	}
+
+	err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS perbaikan_fotos (
+			id SERIAL PRIMARY KEY,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			perbaikan_id INTEGER NOT NULL,
+			nama_file VARCHAR(255) NULL,
+			content_type VARCHAR(255) NULL,
+			storage_key VARCHAR(255) NOT NULL
+		)
+	`).Error
+	if err != nil {
+		log.Fatal("Failed to create perbaikan_fotos table:", err)
+	}
+
+	err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS kategoris (
+			id SERIAL PRIMARY KEY,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			deleted_at TIMESTAMP NULL,
+			nama VARCHAR(255) NOT NULL,
+			jenis VARCHAR(255) NOT NULL,
+			parent_id INTEGER NULL,
+			alias TEXT NULL
+		)
+	`).Error
+	if err != nil {
+		log.Fatal("Failed to create kategoris table:", err)
+	}
+
+	err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS vendors (
+			id SERIAL PRIMARY KEY,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			deleted_at TIMESTAMP NULL,
+			nama VARCHAR(255) NOT NULL,
+			kontak VARCHAR(255) NULL,
+			no_hp VARCHAR(255) NULL,
+			alamat TEXT NULL,
+			kategori_id INTEGER NULL,
+			keterangan TEXT NULL
+		)
+	`).Error
+	if err != nil {
+		log.Fatal("Failed to create vendors table:", err)
+	}
+
+	err = DB.Exec(`
+		ALTER TABLE transaksis
+			ADD COLUMN IF NOT EXISTS kategori_id INTEGER NULL,
+			ADD COLUMN IF NOT EXISTS vendor_id INTEGER NULL,
+			ADD COLUMN IF NOT EXISTS kamar_id INTEGER NULL,
+			ADD COLUMN IF NOT EXISTS keterangan TEXT NULL
+	`).Error
+	if err != nil {
+		log.Fatal("Failed to add transaksis kategori/vendor/kamar columns:", err)
+	}
+
+	err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS transaksi_lampirans (
+			id SERIAL PRIMARY KEY,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			transaksi_id INTEGER NOT NULL,
+			nama_file VARCHAR(255) NULL,
+			content_type VARCHAR(255) NULL,
+			ukuran INTEGER DEFAULT 0,
+			storage_key VARCHAR(255) NOT NULL
+		)
+	`).Error
+	if err != nil {
+		log.Fatal("Failed to create transaksi_lampirans table:", err)
+	}
+
+	err = DB.Exec(`
+		ALTER TABLE transaksis
+			ADD COLUMN IF NOT EXISTS pembayaran_id INTEGER NULL,
+			ADD COLUMN IF NOT EXISTS tagihan_id INTEGER NULL
+	`).Error
+	if err != nil {
+		log.Fatal("Failed to add transaksis pembayaran columns:", err)
+	}
+
+	err = DB.Exec(`
+		CREATE UNIQUE INDEX IF NOT EXISTS idx_transaksis_pembayaran
+		ON transaksis (pembayaran_id) WHERE pembayaran_id IS NOT NULL AND deleted_at IS NULL
+	`).Error
+	if err != nil {
+		log.Fatal("Failed to create transaksis pembayaran index:", err)
+	}
+
+	err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS akuns (
+			id SERIAL PRIMARY KEY,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			deleted_at TIMESTAMP NULL,
+			kode VARCHAR(20) NOT NULL,
+			nama VARCHAR(255) NOT NULL,
+			tipe VARCHAR(20) NOT NULL,
+			sistem BOOLEAN DEFAULT FALSE
+		)
+	`).Error
+	if err != nil {
+		log.Fatal("Failed to create akuns table:", err)
+	}
+
+	err = DB.Exec(`
+		CREATE UNIQUE INDEX IF NOT EXISTS idx_akuns_kode ON akuns (kode) WHERE deleted_at IS NULL
+	`).Error
+	if err != nil {
+		log.Fatal("Failed to create akuns kode index:", err)
+	}
+
+	// Akun bawaan jurnal otomatis (kode dipakai di query jurnal)
+	err = DB.Exec(`
+		INSERT INTO akuns (kode, nama, tipe, sistem)
+		SELECT v.kode, v.nama, v.tipe, TRUE
+		FROM (VALUES
+			('1101', 'Kas', 'aset'),
+			('1102', 'Bank', 'aset'),
+			('1201', 'Piutang Sewa', 'aset'),
+			('2101', 'Deposit Penyewa', 'kewajiban'),
+			('3101', 'Modal Pemilik', 'ekuitas'),
+			('4101', 'Pendapatan Sewa', 'pendapatan'),
+			('4102', 'Pendapatan Lain-lain', 'pendapatan'),
+			('5101', 'Beban Operasional', 'beban')
+		) AS v(kode, nama, tipe)
+		WHERE NOT EXISTS (SELECT 1 FROM akuns a WHERE a.kode = v.kode AND a.deleted_at IS NULL)
+	`).Error
+	if err != nil {
+		log.Fatal("Failed to seed akuns:", err)
+	}
+
+	err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS jurnals (
+			id SERIAL PRIMARY KEY,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			deleted_at TIMESTAMP NULL,
+			tanggal DATE NOT NULL,
+			keterangan TEXT NULL
+		)
+	`).Error
+	if err != nil {
+		log.Fatal("Failed to create jurnals table:", err)
+	}
+
+	err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS jurnal_details (
+			id SERIAL PRIMARY KEY,
+			jurnal_id INTEGER NOT NULL,
+			akun_id INTEGER NOT NULL,
+			debit INTEGER DEFAULT 0,
+			kredit INTEGER DEFAULT 0
+		)
+	`).Error
+	if err != nil {
+		log.Fatal("Failed to create jurnal_details table:", err)
+	}
+
+	err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS rekenings (
+			id SERIAL PRIMARY KEY,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			deleted_at TIMESTAMP NULL,
+			nama VARCHAR(255) NOT NULL,
+			jenis VARCHAR(20) NOT NULL,
+			no_rekening VARCHAR(100) NULL,
+			atas_nama VARCHAR(255) NULL,
+			akun_id INTEGER NOT NULL,
+			aktif BOOLEAN DEFAULT TRUE
+		)
+	`).Error
+	if err != nil {
+		log.Fatal("Failed to create rekenings table:", err)
+	}
+
+	// Rekening bawaan, hanya saat tabel masih kosong
+	err = DB.Exec(`
+		INSERT INTO akuns (kode, nama, tipe, sistem)
+		SELECT '1103', 'QRIS Settlement', 'aset', FALSE
+		WHERE NOT EXISTS (SELECT 1 FROM rekenings)
+			AND NOT EXISTS (SELECT 1 FROM akuns WHERE kode = '1103' AND deleted_at IS NULL)
+	`).Error
+	if err != nil {
+		log.Fatal("Failed to seed QRIS akun:", err)
+	}
+
+	err = DB.Exec(`
+		INSERT INTO rekenings (nama, jenis, akun_id)
+		SELECT v.nama, v.jenis, a.id
+		FROM (VALUES ('Kas Kecil', 'kas', '1101', 1), ('BCA', 'bank', '1102', 2), ('QRIS Settlement', 'qris', '1103', 3))
+			AS v(nama, jenis, kode, urutan)
+		JOIN akuns a ON a.kode = v.kode AND a.deleted_at IS NULL
+		WHERE NOT EXISTS (SELECT 1 FROM rekenings)
+		ORDER BY v.urutan
+	`).Error
+	if err != nil {
+		log.Fatal("Failed to seed rekenings:", err)
+	}
+
+	err = DB.Exec(`
+		ALTER TABLE transaksis ADD COLUMN IF NOT EXISTS rekening_id INTEGER NULL
+	`).Error
+	if err != nil {
+		log.Fatal("Failed to add transaksis rekening_id column:", err)
+	}
+
+	err = DB.Exec(`
+		ALTER TABLE pembayarans ADD COLUMN IF NOT EXISTS rekening_id INTEGER NULL
+	`).Error
+	if err != nil {
+		log.Fatal("Failed to add pembayarans rekening_id column:", err)
+	}
+
+	err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS transfers (
+			id SERIAL PRIMARY KEY,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			deleted_at TIMESTAMP NULL,
+			dari_rekening_id INTEGER NOT NULL,
+			ke_rekening_id INTEGER NOT NULL,
+			jumlah INTEGER NOT NULL,
+			biaya INTEGER DEFAULT 0,
+			tanggal DATE NOT NULL,
+			keterangan TEXT NULL
+		)
+	`).Error
+	if err != nil {
+		log.Fatal("Failed to create transfers table:", err)
+	}
+
+	err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS mutasi_rekenings (
+			id SERIAL PRIMARY KEY,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			rekening_id INTEGER NOT NULL,
+			tanggal DATE NOT NULL,
+			keterangan TEXT NULL,
+			referensi VARCHAR(255) NULL,
+			jumlah INTEGER NOT NULL,
+			saldo INTEGER NULL,
+			hash VARCHAR(64) NOT NULL,
+			status VARCHAR(20) DEFAULT 'Belum Cocok',
+			transaksi_id INTEGER NULL,
+			transfer_id INTEGER NULL
+		)
+	`).Error
+	if err != nil {
+		log.Fatal("Failed to create mutasi_rekenings table:", err)
+	}
+
+	err = DB.Exec(`
+		CREATE UNIQUE INDEX IF NOT EXISTS idx_mutasi_rekenings_hash ON mutasi_rekenings (rekening_id, hash)
+	`).Error
+	if err != nil {
+		log.Fatal("Failed to create mutasi_rekenings hash index:", err)
+	}
+
+	err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS pembayaran_onlines (
+			id SERIAL PRIMARY KEY,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			tagihan_id INTEGER NOT NULL,
+			gateway VARCHAR(50) NOT NULL,
+			metode VARCHAR(20) NULL,
+			order_id VARCHAR(100) NOT NULL,
+			referensi VARCHAR(255) NULL,
+			jumlah INTEGER NOT NULL,
+			status VARCHAR(20) DEFAULT 'Menunggu',
+			payment_url TEXT NULL,
+			nomor_va VARCHAR(50) NULL,
+			qr_string TEXT NULL,
+			rekening_id INTEGER NULL,
+			kedaluwarsa TIMESTAMP NULL,
+			dibayar_pada TIMESTAMP NULL,
+			pembayaran_id INTEGER NULL,
+			catatan TEXT NULL
+		)
+	`).Error
+	if err != nil {
+		log.Fatal("Failed to create pembayaran_onlines table:", err)
+	}
+
+	err = DB.Exec(`
+		CREATE UNIQUE INDEX IF NOT EXISTS idx_pembayaran_onlines_order ON pembayaran_onlines (gateway, order_id)
+	`).Error
+	if err != nil {
+		log.Fatal("Failed to create pembayaran_onlines order index:", err)
+	}
+
+	// QRIS statis merchant per rekening, kode unik nominal dan mutasi pelunasnya
+	err = DB.Exec(`
+		ALTER TABLE rekenings ADD COLUMN IF NOT EXISTS qris_statis TEXT NULL
+	`).Error
+	if err != nil {
+		log.Fatal("Failed to add rekenings qris_statis column:", err)
+	}
+
+	err = DB.Exec(`
+		ALTER TABLE pembayaran_onlines ADD COLUMN IF NOT EXISTS kode_unik INTEGER DEFAULT 0
+	`).Error
+	if err != nil {
+		log.Fatal("Failed to add pembayaran_onlines kode_unik column:", err)
+	}
+
+	err = DB.Exec(`
+		ALTER TABLE pembayaran_onlines ADD COLUMN IF NOT EXISTS mutasi_id INTEGER NULL
+	`).Error
+	if err != nil {
+		log.Fatal("Failed to add pembayaran_onlines mutasi_id column:", err)
+	}
+
+	err = DB.Exec(`
+		ALTER TABLE pembayaran_onlines ADD COLUMN IF NOT EXISTS kode_transaksi_id INTEGER NULL
+	`).Error
+	if err != nil {
+		log.Fatal("Failed to add pembayaran_onlines kode_transaksi_id column:", err)
+	}
+
+	err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS bukti_pembayarans (
+			id SERIAL PRIMARY KEY,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			tagihan_id INTEGER NOT NULL,
+			penyewa_id INTEGER NOT NULL,
+			jumlah INTEGER NOT NULL,
+			tanggal_transfer DATE NOT NULL,
+			keterangan TEXT NULL,
+			nama_file VARCHAR(255) NULL,
+			content_type VARCHAR(100) NULL,
+			ukuran INTEGER DEFAULT 0,
+			storage_key VARCHAR(500) NOT NULL,
+			status VARCHAR(20) DEFAULT 'Menunggu',
+			alasan_tolak TEXT NULL,
+			diverifikasi_oleh VARCHAR(255) NULL,
+			diverifikasi_pada TIMESTAMP NULL,
+			pembayaran_id INTEGER NULL
+		)
+	`).Error
+	if err != nil {
+		log.Fatal("Failed to create bukti_pembayarans table:", err)
+	}
+
+	err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS percakapans (
+			id SERIAL PRIMARY KEY,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			penyewa_id INTEGER NULL,
+			no_hp VARCHAR(30) NOT NULL,
+			arah VARCHAR(10) NOT NULL,
+			pesan TEXT NULL,
+			perintah VARCHAR(20) NULL,
+			provider VARCHAR(20) NULL,
+			message_id VARCHAR(100) NULL,
+			status VARCHAR(20) NULL,
+			error TEXT NULL
+		)
+	`).Error
+	if err != nil {
+		log.Fatal("Failed to create percakapans table:", err)
+	}
+
+	// Webhook yang dikirim ulang provider tidak dicatat dua kali
+	err = DB.Exec(`
+		CREATE UNIQUE INDEX IF NOT EXISTS idx_percakapans_message ON percakapans (provider, message_id)
+		WHERE arah = 'masuk' AND message_id <> ''
+	`).Error
+	if err != nil {
+		log.Fatal("Failed to create percakapans index:", err)
+	}
+
+	err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS template_pesans (
+			id SERIAL PRIMARY KEY,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			tipe VARCHAR(30) NOT NULL,
+			bahasa VARCHAR(5) NOT NULL,
+			isi TEXT NOT NULL
+		)
+	`).Error
+	if err != nil {
+		log.Fatal("Failed to create template_pesans table:", err)
+	}
+
+	err = DB.Exec(`
+		CREATE UNIQUE INDEX IF NOT EXISTS idx_template_pesans_tipe ON template_pesans (tipe, bahasa)
+	`).Error
+	if err != nil {
+		log.Fatal("Failed to create template_pesans index:", err)
+	}
+
+	// Bahasa pesan penyewa (id, en)
+	err = DB.Exec(`ALTER TABLE penyewas ADD COLUMN IF NOT EXISTS bahasa VARCHAR(5) NOT NULL DEFAULT 'id'`).Error
+	if err != nil {
+		log.Fatal("Failed to add penyewas.bahasa column:", err)
+	}
+
+	// Kanal notifikasi penyewa berurutan sebagai fallback, e.g. "whatsapp,email"
+	err = DB.Exec(`ALTER TABLE penyewas ADD COLUMN IF NOT EXISTS kanal_notifikasi VARCHAR(100) NOT NULL DEFAULT 'whatsapp'`).Error
+	if err != nil {
+		log.Fatal("Failed to add penyewas.kanal_notifikasi column:", err)
+	}
+
+	err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS push_subscriptions (
+			id SERIAL PRIMARY KEY,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			penyewa_id INTEGER NOT NULL,
+			endpoint TEXT NOT NULL,
+			p256dh VARCHAR(255) NOT NULL,
+			auth VARCHAR(255) NOT NULL,
+			user_agent VARCHAR(500) NULL
+		)
+	`).Error
+	if err != nil {
+		log.Fatal("Failed to create push_subscriptions table:", err)
+	}
+
+	err = DB.Exec(`
+		CREATE UNIQUE INDEX IF NOT EXISTS idx_push_subscriptions_endpoint ON push_subscriptions (endpoint)
+	`).Error
+	if err != nil {
+		log.Fatal("Failed to create push_subscriptions index:", err)
+	}
+
+	err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS pengiriman_notifikasis (
+			id SERIAL PRIMARY KEY,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			notifikasi_id INTEGER NOT NULL,
+			kanal VARCHAR(20) NOT NULL,
+			tujuan TEXT NULL,
+			status VARCHAR(20) NULL,
+			provider_id VARCHAR(255) NULL,
+			error TEXT NULL,
+			dikirim_pada TIMESTAMP NULL
+		)
+	`).Error
+	if err != nil {
+		log.Fatal("Failed to create pengiriman_notifikasis table:", err)
+	}
+
+	// Status pengiriman dari callback provider
+	err = DB.Exec(`ALTER TABLE pengiriman_notifikasis ADD COLUMN IF NOT EXISTS diterima_pada TIMESTAMP NULL`).Error
+	if err != nil {
+		log.Fatal("Failed to add pengiriman_notifikasis.diterima_pada column:", err)
+	}
+
+	err = DB.Exec(`ALTER TABLE pengiriman_notifikasis ADD COLUMN IF NOT EXISTS dibaca_pada TIMESTAMP NULL`).Error
+	if err != nil {
+		log.Fatal("Failed to add pengiriman_notifikasis.dibaca_pada column:", err)
+	}
+
+	err = DB.Exec(`ALTER TABLE notifikasis ADD COLUMN IF NOT EXISTS dilihat_pada TIMESTAMP NULL`).Error
+	if err != nil {
+		log.Fatal("Failed to add notifikasis.dilihat_pada column:", err)
+	}
+
+	// Jam tenang, batas harian, dan STOP
+	err = DB.Exec(`ALTER TABLE penyewas ADD COLUMN IF NOT EXISTS berhenti_pada TIMESTAMP NULL`).Error
+	if err != nil {
+		log.Fatal("Failed to add penyewas.berhenti_pada column:", err)
+	}
+
+	err = DB.Exec(`ALTER TABLE pengiriman_notifikasis ADD COLUMN IF NOT EXISTS kiriman VARCHAR(100) NOT NULL DEFAULT ''`).Error
+	if err != nil {
+		log.Fatal("Failed to add pengiriman_notifikasis.kiriman column:", err)
+	}
+
+	// Urutan eskalasi pengingat tagihan
+	err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS langkah_eskalasis (
+			id SERIAL PRIMARY KEY,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			tipe VARCHAR(30) NOT NULL,
+			hari INTEGER NOT NULL,
+			penerima VARCHAR(20) NOT NULL DEFAULT 'penyewa',
+			kanal VARCHAR(100) NOT NULL DEFAULT '',
+			template VARCHAR(30) NOT NULL DEFAULT '',
+			isi TEXT NULL,
+			aktif BOOLEAN NOT NULL DEFAULT TRUE
+		)
+	`).Error
+	if err != nil {
+		log.Fatal("Failed to create langkah_eskalasis table:", err)
+	}
+
+	err = DB.Exec(`
+		CREATE UNIQUE INDEX IF NOT EXISTS idx_langkah_eskalasis_tipe ON langkah_eskalasis (tipe)
+	`).Error
+	if err != nil {
+		log.Fatal("Failed to create langkah_eskalasis index:", err)
+	}
+
+	// Langkah bawaan sama dengan pengingat lama (H-7, H-3, H-1, OVERDUE), hanya saat tabel masih kosong
+	err = DB.Exec(`
+		INSERT INTO langkah_eskalasis (tipe, hari, penerima, template)
+		SELECT v.tipe, v.hari, 'penyewa', v.tipe
+		FROM (VALUES ('H-7', -7), ('H-3', -3), ('H-1', 0), ('OVERDUE', 1)) AS v(tipe, hari)
+		WHERE NOT EXISTS (SELECT 1 FROM langkah_eskalasis)
+	`).Error
+	if err != nil {
+		log.Fatal("Failed to seed langkah_eskalasis:", err)
+	}
+
+	err = DB.Exec(`
+		ALTER TABLE notifikasis
+			ADD COLUMN IF NOT EXISTS penerima VARCHAR(20) NOT NULL DEFAULT 'penyewa',
+			ADD COLUMN IF NOT EXISTS kanal VARCHAR(100) NOT NULL DEFAULT ''
+	`).Error
+	if err != nil {
+		log.Fatal("Failed to add notifikasis eskalasi columns:", err)
+	}
+
+	err = DB.Exec(`
+		ALTER TABLE penyewas
+			ADD COLUMN IF NOT EXISTS kontak_darurat_nama VARCHAR(255) NULL,
+			ADD COLUMN IF NOT EXISTS kontak_darurat_no_hp VARCHAR(255) NULL,
+			ADD COLUMN IF NOT EXISTS kontak_darurat_email VARCHAR(255) NULL
+	`).Error
+	if err != nil {
+		log.Fatal("Failed to add penyewas kontak darurat columns:", err)
+	}
+
+	// Satu notifikasi per tagihan dan tipe: duplikat lama dihapus (soft delete), yang pertama dipertahankan
+	err = DB.Exec(`
+		UPDATE notifikasis SET deleted_at = CURRENT_TIMESTAMP
+		WHERE deleted_at IS NULL AND id NOT IN (
+			SELECT MIN(id) FROM notifikasis WHERE deleted_at IS NULL GROUP BY tagihan_id, tipe
+		)
+	`).Error
+	if err != nil {
+		log.Fatal("Failed to remove duplicate notifikasis:", err)
+	}
+
+	err = DB.Exec(`
+		CREATE UNIQUE INDEX IF NOT EXISTS idx_notifikasis_tagihan_tipe
+		ON notifikasis (tagihan_id, tipe) WHERE deleted_at IS NULL
+	`).Error
+	if err != nil {
+		log.Fatal("Failed to create notifikasis tagihan index:", err)
+	}
+
+	// Penerimaan kas dari tagihan: riwayat pembayaran, ditambah sisa terbayar tagihan lama
+	// (sebelum ada tabel pembayarans) yang diberi tanggal tanggal_bayar / updated_at.
+	err = DB.Exec(`
+		CREATE OR REPLACE VIEW penerimaan_tagihan AS
+		SELECT p.tagihan_id, p.penyewa_id, LEFT(t.bulan, 7) AS bulan, p.tanggal, p.jumlah, p.id AS pembayaran_id
+		FROM pembayarans p
+		JOIN tagihans t ON t.id = p.tagihan_id
+		WHERE p.deleted_at IS NULL AND t.deleted_at IS NULL
+		UNION ALL
+		SELECT t.id, t.penyewa_id, LEFT(t.bulan, 7), COALESCE(t.tanggal_bayar, t.updated_at::date),
+			(CASE WHEN t.status = 'Lunas' THEN t.jumlah ELSE t.terbayar END) - COALESCE(p.total, 0), NULL
+		FROM tagihans t
+		LEFT JOIN (
+			SELECT tagihan_id, SUM(jumlah) AS total FROM pembayarans WHERE deleted_at IS NULL GROUP BY tagihan_id
+		) p ON p.tagihan_id = t.id
+		WHERE t.deleted_at IS NULL
+			AND (CASE WHEN t.status = 'Lunas' THEN t.jumlah ELSE t.terbayar END) > COALESCE(p.total, 0)
+	`).Error
+	if err != nil {
+		log.Fatal("Failed to create penerimaan_tagihan view:", err)
+	}
+
+	// Migrasi data satu kali (dicatat di skema_migrasis); setelahnya handler pembayaran yang menjaga
+	// setiap penerimaan punya baris pembayaran dan transaksi pemasukan.
+	err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS skema_migrasis (
+			nama VARCHAR(100) PRIMARY KEY,
+			dijalankan_pada TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)
+	`).Error
+	if err != nil {
+		log.Fatal("Failed to create skema_migrasis table:", err)
+	}
+
+	// Sisa terbayar tagihan lama dijadikan baris pembayaran, lalu setiap pembayaran yang belum
+	// bertaut diberi transaksi pemasukan
+	err = migrasiSekali("pembayaran_lama_bertaut", `
+		INSERT INTO pembayarans (created_at, updated_at, tagihan_id, penyewa_id, jumlah, tanggal, keterangan)
+		SELECT NOW(), NOW(), tagihan_id, penyewa_id, jumlah, tanggal, 'Saldo pembayaran lama'
+		FROM penerimaan_tagihan
+		WHERE pembayaran_id IS NULL
+	`, `
+		INSERT INTO transaksis (created_at, updated_at, jenis, kategori, kategori_id, kamar_id, keterangan,
+			pembayaran_id, tagihan_id, jumlah, tanggal)
+		SELECT NOW(), NOW(), 'pemasukan', x.kategori,
+			(SELECT k.id FROM kategoris k
+				WHERE k.deleted_at IS NULL AND LOWER(k.jenis) = 'pemasukan' AND LOWER(k.nama) = LOWER(x.kategori)
+				ORDER BY k.id LIMIT 1),
+			x.kamar_id, x.keterangan, x.pembayaran_id, x.tagihan_id, x.jumlah, x.tanggal
+		FROM (
+			SELECT p.id AS pembayaran_id, t.id AS tagihan_id, t.kamar_id, p.jumlah, p.tanggal,
+				CASE WHEN COALESCE(NULLIF(t.jenis_tagihan, ''), 'Penyewa') = 'Penyewa' THEN 'Sewa Kamar'
+					ELSE t.jenis_tagihan END AS kategori,
+				'Pembayaran ' || COALESCE(NULLIF(NULLIF(t.jenis_tagihan, ''), 'Penyewa'), 'Sewa Kamar') || ' ' ||
+					LEFT(t.bulan, 7) || ' - ' || COALESCE(py.nama, '') AS keterangan
+			FROM pembayarans p
+			JOIN tagihans t ON t.id = p.tagihan_id
+			LEFT JOIN penyewas py ON py.id = p.penyewa_id
+			WHERE p.deleted_at IS NULL AND t.deleted_at IS NULL
+				AND NOT EXISTS (
+					SELECT 1 FROM transaksis tr WHERE tr.pembayaran_id = p.id AND tr.deleted_at IS NULL
+				)
+		) x
+	`)
+	if err != nil {
+		log.Fatal("Failed to backfill legacy pembayarans:", err)
+	}
+
+	// Transaksi & pembayaran lama (sebelum ada rekening) dicatat di rekening Kas Kecil bawaan (akun 1101),
+	// sekali saja dan hanya bila rekening itu tunggal. Setelahnya rekening wajib diisi saat menulis.
+	err = migrasiSekali("rekening_kas_transaksi_lama", `
+		UPDATE transaksis SET rekening_id = k.id
+		FROM (
+			SELECT MIN(r.id) AS id
+			FROM rekenings r
+			JOIN akuns a ON a.id = r.akun_id AND a.deleted_at IS NULL
+			WHERE r.jenis = 'kas' AND r.deleted_at IS NULL AND a.kode = '1101'
+			HAVING COUNT(*) = 1
+		) k
+		WHERE transaksis.rekening_id IS NULL
+	`, `
+		UPDATE pembayarans p SET rekening_id = t.rekening_id
+		FROM transaksis t
+		WHERE t.pembayaran_id = p.id AND p.rekening_id IS NULL
+	`)
+	if err != nil {
+		log.Fatal("Failed to backfill rekening_id:", err)
+	}
+
+	// Status read lama berasal dari klik "tandai dibaca" pengelola, bukan laporan provider: pindahkan ke
+	// dilihat_pada dan kembalikan status ke sent/pending
+	err = migrasiSekali("notifikasi_dibaca_pengelola", `
+		UPDATE notifikasis n
+		SET dilihat_pada = COALESCE(n.dilihat_pada, n.updated_at),
+			status = CASE WHEN n.sent_at IS NULL THEN 'pending' ELSE 'sent' END
+		WHERE n.status = 'read'
+			AND NOT EXISTS (
+				SELECT 1 FROM pengiriman_notifikasis p WHERE p.notifikasi_id = n.id AND p.status = 'read'
+			)
+	`)
+	if err != nil {
+		log.Fatal("Failed to migrate notifikasi read status:", err)
+	}
+
 	log.Println("Database connected and migrated successfully")
 }
+
+// migrasiSekali - Jalankan migrasi data dalam satu transaksi bila nama belum tercatat di skema_migrasis.
+// Baris penanda disisipkan lebih dulu sehingga server lain yang start bersamaan menunggu lalu melewatinya.
+func migrasiSekali(nama string, queries ...string) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Exec(`INSERT INTO skema_migrasis (nama) VALUES (?) ON CONFLICT DO NOTHING`, nama)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		for _, query := range queries {
+			if err := tx.Exec(query).Error; err != nil {
+				return err
+			}
+		}
+		log.Printf("Data migration %s applied", nama)
+		return nil
+	})
+}
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Akun - Bagan akun (chart of accounts) untuk jurnal double-entry
+type Akun struct {
+	ID        uint           `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+	Kode      string         `json:"kode" gorm:"not null"`
+	Nama      string         `json:"nama" gorm:"not null"`
+	Tipe      string         `json:"tipe" gorm:"not null"` // aset, kewajiban, ekuitas, pendapatan, beban
+	Sistem    bool           `json:"sistem"`               // akun bawaan yang dipakai jurnal otomatis
+}
+
+// Jurnal - Jurnal umum manual (penyesuaian, modal awal, dll)
+type Jurnal struct {
+	ID         uint           `json:"id" gorm:"primaryKey"`
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+	DeletedAt  gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+	Tanggal    time.Time      `json:"tanggal" gorm:"not null"`
+	Keterangan string         `json:"keterangan"`
+	Detail     []JurnalDetail `json:"detail" gorm:"foreignKey:JurnalID"`
+}
+
+// JurnalDetail - Satu baris debit/kredit jurnal manual
+type JurnalDetail struct {
+	ID       uint  `json:"id" gorm:"primaryKey"`
+	JurnalID uint  `json:"jurnal_id" gorm:"not null"`
+	AkunID   uint  `json:"akun_id" gorm:"not null"`
+	Akun     *Akun `json:"akun,omitempty" gorm:"foreignKey:AkunID"`
+	Debit    int   `json:"debit"`
+	Kredit   int   `json:"kredit"`
+}
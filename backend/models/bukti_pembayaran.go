@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// BuktiPembayaran - Bukti transfer yang diunggah penyewa, menunggu verifikasi pengelola.
+// Saat disetujui, pembayaran tagihan dicatat dan PembayaranID diisi.
+type BuktiPembayaran struct {
+	ID               uint       `json:"id" gorm:"primaryKey"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+	TagihanID        uint       `json:"tagihan_id" gorm:"not null"`
+	Tagihan          *Tagihan   `json:"tagihan,omitempty" gorm:"foreignKey:TagihanID"`
+	PenyewaID        uint       `json:"penyewa_id" gorm:"not null"`
+	Penyewa          *Penyewa   `json:"penyewa,omitempty" gorm:"foreignKey:PenyewaID"`
+	Jumlah           int        `json:"jumlah" gorm:"not null"` // nominal menurut penyewa
+	TanggalTransfer  time.Time  `json:"tanggal_transfer" gorm:"not null"`
+	Keterangan       string     `json:"keterangan"` // catatan penyewa, e.g. bank pengirim
+	NamaFile         string     `json:"nama_file"`
+	ContentType      string     `json:"content_type"`
+	Ukuran           int        `json:"ukuran"`
+	StorageKey       string     `json:"-"`
+	Status           string     `json:"status" gorm:"default:'Menunggu'"` // Menunggu, Disetujui, Ditolak
+	AlasanTolak      string     `json:"alasan_tolak,omitempty"`
+	DiverifikasiOleh string     `json:"diverifikasi_oleh,omitempty"`
+	DiverifikasiPada *time.Time `json:"diverifikasi_pada"`
+	PembayaranID     *uint      `json:"pembayaran_id"`
+}
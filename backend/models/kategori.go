@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Kategori - Kategori pemasukan/pengeluaran bertingkat (parent -> sub kategori)
+type Kategori struct {
+	ID        uint           `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+	Nama      string         `json:"nama" gorm:"not null"`
+	Jenis     string         `json:"jenis" gorm:"not null"` // pemasukan, pengeluaran
+	ParentID  *uint          `json:"parent_id"`
+	Alias     string         `json:"alias"` // nama lain dipisah koma, e.g. "PLN,listrik token"
+	Children  []Kategori     `json:"children,omitempty" gorm:"-"`
+}
@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// LangkahEskalasi - Satu langkah urutan pengingat tagihan. Setiap langkah membuat notifikasi
+// bertipe Tipe saat tagihan belum lunas dan sudah mencapai Hari relatif terhadap jatuh tempo.
+type LangkahEskalasi struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Tipe      string    `json:"tipe" gorm:"not null"` // tipe notifikasi, e.g. "H-5", "OVERDUE+7"
+	Hari      int       `json:"hari"`                 // -5 = 5 hari sebelum jatuh tempo, 0 = hari jatuh tempo, 7 = terlambat 7 hari
+	Penerima  string    `json:"penerima"`             // penyewa, kontak_darurat, pemilik
+	Kanal     string    `json:"kanal"`                // urutan kanal, e.g. "sms,email"; kosong = preferensi penyewa / whatsapp
+	Template  string    `json:"template"`             // tipe template pesan
+	Isi       string    `json:"isi" gorm:"type:text"` // isi khusus langkah ini, mengganti template bila diisi
+	Aktif     bool      `json:"aktif"`
+}
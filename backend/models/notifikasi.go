@@ -7,18 +7,21 @@ import (
 )
 
 type Notifikasi struct {
-	ID        uint           `json:"id" gorm:"primaryKey"`
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
-	DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"index"`
-	PenyewaID uint           `json:"penyewa_id" gorm:"not null"`
-	Penyewa   Penyewa        `gorm:"foreignKey:PenyewaID"`
-	TagihanID uint           `json:"tagihan_id" gorm:"not null"`
-	Tagihan   Tagihan        `gorm:"foreignKey:TagihanID"`
-	Tipe      string         `json:"tipe" gorm:"not null"`            // "H-7", "H-3", "H-1", "OVERDUE"
-	Status    string         `json:"status" gorm:"default:'pending'"` // pending, sent, read
-	Message   string         `json:"message" gorm:"type:text"`
-	SentAt    *time.Time     `json:"sent_at"`
+	ID          uint           `json:"id" gorm:"primaryKey"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+	PenyewaID   uint           `json:"penyewa_id" gorm:"not null"`
+	Penyewa     Penyewa        `gorm:"foreignKey:PenyewaID"`
+	TagihanID   uint           `json:"tagihan_id" gorm:"not null"`
+	Tagihan     Tagihan        `gorm:"foreignKey:TagihanID"`
+	Tipe        string         `json:"tipe" gorm:"not null"`              // tipe langkah eskalasi: "H-7", "H-3", "H-1", "OVERDUE", ...
+	Penerima    string         `json:"penerima" gorm:"default:'penyewa'"` // penyewa, kontak_darurat, pemilik
+	Kanal       string         `json:"kanal"`                             // urutan kanal langkah; kosong = preferensi penyewa
+	Status      string         `json:"status" gorm:"default:'pending'"`   // pending, queued, sent, delivered, read, failed
+	Message     string         `json:"message" gorm:"type:text"`
+	SentAt      *time.Time     `json:"sent_at"`
+	DilihatPada *time.Time     `json:"dilihat_pada"` // ditandai sudah dilihat oleh pengelola
 }
 
 type NotifikasiResponse struct {
@@ -27,9 +30,11 @@ type NotifikasiResponse struct {
 	PenyewaNama string     `json:"penyewa_nama"`
 	TagihanID   uint       `json:"tagihan_id"`
 	Tipe        string     `json:"tipe"`
+	Penerima    string     `json:"penerima"`
 	Status      string     `json:"status"`
 	Message     string     `json:"message"`
 	SentAt      *time.Time `json:"sent_at"`
+	DilihatPada *time.Time `json:"dilihat_pada"`
 	Bulan       string     `json:"bulan"`
 	Jumlah      int        `json:"jumlah"`
 	CreatedAt   time.Time  `json:"created_at"`
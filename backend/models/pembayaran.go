@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Pembayaran - Riwayat pembayaran per tagihan (satu baris per setoran/cicilan)
+type Pembayaran struct {
+	ID           uint           `json:"id" gorm:"primaryKey"`
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+	DeletedAt    gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+	TagihanID    uint           `json:"tagihan_id" gorm:"not null"`
+	PenyewaID    uint           `json:"penyewa_id" gorm:"not null"`
+	Jumlah       int            `json:"jumlah" gorm:"not null"` // negatif untuk koreksi
+	Tanggal      time.Time      `json:"tanggal" gorm:"not null"`
+	DiterimaOleh string         `json:"diterima_oleh,omitempty"`
+	Keterangan   string         `json:"keterangan,omitempty"`
+	RekeningID   *uint          `json:"rekening_id,omitempty"` // rekening penerima
+}
@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// PembayaranOnline - Permintaan bayar tagihan lewat payment gateway (VA, QRIS, payment link).
+// Saat webhook lunas diterima (atau mutasi QRIS statis cocok), tagihan dibayar dan PembayaranID diisi.
+type PembayaranOnline struct {
+	ID              uint       `json:"id" gorm:"primaryKey"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+	TagihanID       uint       `json:"tagihan_id" gorm:"not null"`
+	Tagihan         *Tagihan   `json:"tagihan,omitempty" gorm:"foreignKey:TagihanID"`
+	Gateway         string     `json:"gateway" gorm:"not null"`
+	Metode          string     `json:"metode"` // va, qris, link
+	OrderID         string     `json:"order_id" gorm:"not null"`
+	Referensi       string     `json:"referensi"` // id transaksi di gateway
+	Jumlah          int        `json:"jumlah" gorm:"not null"`
+	KodeUnik        int        `json:"kode_unik"`                        // QRIS statis: tambahan 3 digit agar nominal unik, termasuk dalam jumlah
+	Status          string     `json:"status" gorm:"default:'Menunggu'"` // Menunggu, Lunas, Kedaluwarsa, Gagal, Perlu Dicek
+	PaymentURL      string     `json:"payment_url"`
+	NomorVA         string     `json:"nomor_va"`
+	QRString        string     `json:"qr_string"`
+	RekeningID      *uint      `json:"rekening_id"` // rekening penampung dana gateway
+	Kedaluwarsa     *time.Time `json:"kedaluwarsa"`
+	DibayarPada     *time.Time `json:"dibayar_pada"`
+	PembayaranID    *uint      `json:"pembayaran_id"`
+	MutasiID        *uint      `json:"mutasi_id"`         // mutasi rekening koran yang melunasi (QRIS statis)
+	KodeTransaksiID *uint      `json:"kode_transaksi_id"` // transaksi pemasukan untuk kode unik
+	Catatan         string     `json:"catatan,omitempty"`
+}
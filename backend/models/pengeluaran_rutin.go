@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// PengeluaranRutin - Pengeluaran berulang yang sudah diketahui (listrik, gaji penjaga, internet)
+type PengeluaranRutin struct {
+	ID            uint           `json:"id" gorm:"primaryKey"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+	DeletedAt     gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+	Nama          string         `json:"nama" gorm:"not null"`
+	Kategori      string         `json:"kategori" gorm:"not null"`
+	Jumlah        int            `json:"jumlah" gorm:"not null"`
+	IntervalBulan int            `json:"interval_bulan" gorm:"default:1"` // 1 = bulanan, 3 = triwulan, 12 = tahunan
+	Mulai         time.Time      `json:"mulai" gorm:"not null"`
+	Selesai       *time.Time     `json:"selesai"`
+}
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// PengirimanNotifikasi - Percobaan kirim satu notifikasi lewat satu kanal. Kanal dicoba
+// berurutan sesuai preferensi penyewa sampai ada yang berhasil.
+type PengirimanNotifikasi struct {
+	ID           uint       `json:"id" gorm:"primaryKey"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+	NotifikasiID uint       `json:"notifikasi_id" gorm:"not null"`
+	Kiriman      string     `json:"kiriman"`               // id kiriman; satu pesan gabungan berbagi id yang sama
+	Kanal        string     `json:"kanal" gorm:"not null"` // whatsapp, email, sms, push
+	Tujuan       string     `json:"tujuan"`                // nomor HP, email, atau endpoint push
+	Status       string     `json:"status"`                // queued, sent, delivered, read, failed, skipped
+	ProviderID   string     `json:"provider_id,omitempty"` // id pesan dari provider, dicocokkan dengan callback status
+	Error        string     `json:"error,omitempty"`       // alasan gagal dari kanal atau callback provider
+	DikirimPada  *time.Time `json:"dikirim_pada"`
+	DiterimaPada *time.Time `json:"diterima_pada"` // delivered menurut provider
+	DibacaPada   *time.Time `json:"dibaca_pada"`   // read menurut provider / service worker push
+}
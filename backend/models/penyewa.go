@@ -7,15 +7,22 @@ import (
 )
 
 type Penyewa struct {
-	ID           uint           `json:"id" gorm:"primaryKey"`
-	CreatedAt    time.Time      `json:"created_at"`
-	UpdatedAt    time.Time      `json:"updated_at"`
-	DeletedAt    gorm.DeletedAt `json:"deleted_at" gorm:"index"`
-	Nama         string         `json:"nama" gorm:"not null"`
-	Email        *string        `json:"email"`
-	NoHP         *string        `json:"no_hp"`
-	Alamat       *string        `json:"alamat"`
-	KamarID      uint           `json:"kamar_id" gorm:"not null"`
-	TanggalMasuk *time.Time     `json:"tanggal_masuk"`
-	Kamar        *Kamar         `gorm:"foreignKey:KamarID"`
+	ID                 uint           `json:"id" gorm:"primaryKey"`
+	CreatedAt          time.Time      `json:"created_at"`
+	UpdatedAt          time.Time      `json:"updated_at"`
+	DeletedAt          gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+	Nama               string         `json:"nama" gorm:"not null"`
+	Email              *string        `json:"email"`
+	NoHP               *string        `json:"no_hp"`
+	Alamat             *string        `json:"alamat"`
+	KamarID            uint           `json:"kamar_id" gorm:"not null"`
+	TanggalMasuk       *time.Time     `json:"tanggal_masuk"`
+	TanggalKeluar      *time.Time     `json:"tanggal_keluar"`                             // akhir kontrak / rencana keluar
+	Bahasa             string         `json:"bahasa" gorm:"default:'id'"`                 // bahasa pesan: id, en
+	KanalNotifikasi    string         `json:"kanal_notifikasi" gorm:"default:'whatsapp'"` // urutan fallback, e.g. "whatsapp,email"
+	BerhentiPada       *time.Time     `json:"berhenti_pada"`                              // penyewa membalas STOP; nil = masih menerima pesan
+	KontakDaruratNama  *string        `json:"kontak_darurat_nama"`                        // penjamin / kontak darurat, penerima eskalasi
+	KontakDaruratNoHP  *string        `json:"kontak_darurat_no_hp"`
+	KontakDaruratEmail *string        `json:"kontak_darurat_email"`
+	Kamar              *Kamar         `gorm:"foreignKey:KamarID"`
 }
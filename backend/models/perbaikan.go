@@ -0,0 +1,41 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Perbaikan - Tiket perbaikan/maintenance kamar
+type Perbaikan struct {
+	ID             uint            `json:"id" gorm:"primaryKey"`
+	CreatedAt      time.Time       `json:"created_at"`
+	UpdatedAt      time.Time       `json:"updated_at"`
+	DeletedAt      gorm.DeletedAt  `json:"deleted_at" gorm:"index"`
+	KamarID        uint            `json:"kamar_id" gorm:"not null"`
+	Kamar          *Kamar          `json:"kamar,omitempty" gorm:"foreignKey:KamarID"`
+	Judul          string          `json:"judul" gorm:"not null"`
+	Deskripsi      string          `json:"deskripsi"`
+	Pelapor        string          `json:"pelapor"`    // penyewa, staf
+	PenyewaID      *uint           `json:"penyewa_id"` // diisi bila dilaporkan penyewa
+	DilaporkanOleh string          `json:"dilaporkan_oleh"`
+	Prioritas      string          `json:"prioritas" gorm:"default:'Sedang'"`  // Rendah, Sedang, Tinggi, Darurat
+	Status         string          `json:"status" gorm:"default:'Dilaporkan'"` // Dilaporkan, Dikerjakan, Menunggu, Selesai, Dibatalkan
+	Petugas        string          `json:"petugas"`
+	Biaya          int             `json:"biaya" gorm:"default:0"`
+	TransaksiID    *uint           `json:"transaksi_id"` // pengeluaran yang dibuat saat tiket selesai
+	Catatan        string          `json:"catatan"`
+	TanggalMulai   *time.Time      `json:"tanggal_mulai"`
+	TanggalSelesai *time.Time      `json:"tanggal_selesai"`
+	Foto           []PerbaikanFoto `json:"foto,omitempty" gorm:"foreignKey:PerbaikanID"`
+}
+
+// PerbaikanFoto - Foto kerusakan / hasil perbaikan
+type PerbaikanFoto struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	CreatedAt   time.Time `json:"created_at"`
+	PerbaikanID uint      `json:"perbaikan_id" gorm:"not null"`
+	NamaFile    string    `json:"nama_file"`
+	ContentType string    `json:"content_type"`
+	StorageKey  string    `json:"-"`
+}
@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// Percakapan - Satu pesan WhatsApp masuk/keluar, dikelompokkan per penyewa sebagai thread.
+// PenyewaID kosong bila nomor pengirim tidak terdaftar.
+type Percakapan struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time `json:"created_at"`
+	PenyewaID *uint     `json:"penyewa_id"`
+	Penyewa   *Penyewa  `json:"penyewa,omitempty" gorm:"foreignKey:PenyewaID"`
+	NoHP      string    `json:"no_hp" gorm:"not null"`
+	Arah      string    `json:"arah" gorm:"not null"` // masuk, keluar
+	Pesan     string    `json:"pesan" gorm:"type:text"`
+	Perintah  string    `json:"perintah,omitempty"`   // perintah yang dikenali: TAGIHAN, SALDO, KWITANSI, BANTUAN
+	Provider  string    `json:"provider,omitempty"`   // provider webhook pesan masuk
+	MessageID string    `json:"message_id,omitempty"` // id pesan dari provider
+	Status    string    `json:"status"`               // received; keluar: sent, delivered, read, failed
+	Error     string    `json:"error,omitempty"`
+}
@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// PerubahanHarga - Perubahan harga kamar yang dijadwalkan berlaku mulai tanggal tertentu
+type PerubahanHarga struct {
+	ID           uint           `json:"id" gorm:"primaryKey"`
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+	DeletedAt    gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+	KamarID      uint           `json:"kamar_id" gorm:"not null"`
+	HargaBaru    int            `json:"harga_baru" gorm:"not null"`
+	BerlakuMulai time.Time      `json:"berlaku_mulai" gorm:"not null"`
+	Keterangan   string         `json:"keterangan,omitempty"`
+}
@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// PushSubscription - Langganan web push browser penyewa
+type PushSubscription struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	PenyewaID uint      `json:"penyewa_id" gorm:"not null"`
+	Endpoint  string    `json:"endpoint" gorm:"type:text;not null"`
+	P256dh    string    `json:"-"`
+	Auth      string    `json:"-"`
+	UserAgent string    `json:"user_agent"`
+}
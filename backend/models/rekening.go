@@ -0,0 +1,59 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Rekening - Akun kas/bank tempat uang disimpan (Kas Kecil, BCA, QRIS settlement, dll)
+type Rekening struct {
+	ID         uint           `json:"id" gorm:"primaryKey"`
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+	DeletedAt  gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+	Nama       string         `json:"nama" gorm:"not null"`
+	Jenis      string         `json:"jenis" gorm:"not null"` // kas, bank, qris
+	NoRekening string         `json:"no_rekening"`
+	AtasNama   string         `json:"atas_nama"`
+	AkunID     uint           `json:"akun_id" gorm:"not null"` // akun aset di bagan akun
+	Akun       *Akun          `json:"akun,omitempty" gorm:"foreignKey:AkunID"`
+	QRISStatis string         `json:"qris_statis,omitempty"` // payload QRIS statis merchant (jenis qris)
+	Aktif      bool           `json:"aktif" gorm:"default:true"`
+	Saldo      int            `json:"saldo" gorm:"-"`
+}
+
+// Transfer - Pemindahan dana antar rekening, biaya admin dicatat sebagai beban rekening asal
+type Transfer struct {
+	ID             uint           `json:"id" gorm:"primaryKey"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+	DeletedAt      gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+	DariRekeningID uint           `json:"dari_rekening_id" gorm:"not null"`
+	DariRekening   *Rekening      `json:"dari_rekening,omitempty" gorm:"foreignKey:DariRekeningID"`
+	KeRekeningID   uint           `json:"ke_rekening_id" gorm:"not null"`
+	KeRekening     *Rekening      `json:"ke_rekening,omitempty" gorm:"foreignKey:KeRekeningID"`
+	Jumlah         int            `json:"jumlah" gorm:"not null"`
+	Biaya          int            `json:"biaya"`
+	Tanggal        time.Time      `json:"tanggal" gorm:"not null"`
+	Keterangan     string         `json:"keterangan"`
+}
+
+// MutasiRekening - Baris mutasi dari rekening koran bank (import CSV/XLSX) untuk rekonsiliasi
+type MutasiRekening struct {
+	ID          uint       `json:"id" gorm:"primaryKey"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	RekeningID  uint       `json:"rekening_id" gorm:"not null"`
+	Tanggal     time.Time  `json:"tanggal" gorm:"not null"`
+	Keterangan  string     `json:"keterangan"`
+	Referensi   string     `json:"referensi"`
+	Jumlah      int        `json:"jumlah" gorm:"not null"` // positif = masuk, negatif = keluar
+	Saldo       *int       `json:"saldo"`
+	Hash        string     `json:"-"`
+	Status      string     `json:"status" gorm:"default:'Belum Cocok'"` // Belum Cocok, Cocok, Diabaikan
+	TransaksiID *uint      `json:"transaksi_id"`
+	Transaksi   *Transaksi `json:"transaksi,omitempty" gorm:"foreignKey:TransaksiID"`
+	TransferID  *uint      `json:"transfer_id"`
+	Transfer    *Transfer  `json:"transfer,omitempty" gorm:"foreignKey:TransferID"`
+}
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// TemplatePesan - Template pesan yang diubah pengelola untuk satu tipe notifikasi dan bahasa.
+// Tipe tanpa baris di tabel ini memakai template bawaan.
+type TemplatePesan struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Tipe      string    `json:"tipe" gorm:"not null"`   // H-7, H-3, H-1, OVERDUE, KWITANSI, SELAMAT_DATANG
+	Bahasa    string    `json:"bahasa" gorm:"not null"` // id, en
+	Isi       string    `json:"isi" gorm:"type:text;not null"`
+}
@@ -7,12 +7,34 @@ import (
 )
 
 type Transaksi struct {
-	ID        uint           `json:"id" gorm:"primaryKey"`
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
-	DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"index"`
-	Jenis     string         `json:"jenis" gorm:"not null"` // pemasukan, pengeluaran
-	Kategori  string         `json:"kategori" gorm:"not null"`
-	Jumlah    int            `json:"jumlah" gorm:"not null"`
-	Tanggal   time.Time      `json:"tanggal" gorm:"not null"`
+	ID           uint                `json:"id" gorm:"primaryKey"`
+	CreatedAt    time.Time           `json:"created_at"`
+	UpdatedAt    time.Time           `json:"updated_at"`
+	DeletedAt    gorm.DeletedAt      `json:"deleted_at" gorm:"index"`
+	Jenis        string              `json:"jenis" gorm:"not null"` // pemasukan, pengeluaran
+	Kategori     string              `json:"kategori" gorm:"not null"`
+	KategoriID   *uint               `json:"kategori_id"`
+	VendorID     *uint               `json:"vendor_id"`
+	Vendor       *Vendor             `json:"vendor,omitempty" gorm:"foreignKey:VendorID"`
+	KamarID      *uint               `json:"kamar_id"`
+	Kamar        *Kamar              `json:"kamar,omitempty" gorm:"foreignKey:KamarID"`
+	Keterangan   string              `json:"keterangan"`
+	RekeningID   *uint               `json:"rekening_id"`
+	Rekening     *Rekening           `json:"rekening,omitempty" gorm:"foreignKey:RekeningID"`
+	PembayaranID *uint               `json:"pembayaran_id"` // terisi jika berasal dari pembayaran tagihan
+	TagihanID    *uint               `json:"tagihan_id"`
+	Jumlah       int                 `json:"jumlah" gorm:"not null"`
+	Tanggal      time.Time           `json:"tanggal" gorm:"not null"`
+	Lampiran     []TransaksiLampiran `json:"lampiran,omitempty" gorm:"foreignKey:TransaksiID"`
+}
+
+// TransaksiLampiran - Nota / bukti transaksi
+type TransaksiLampiran struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	CreatedAt   time.Time `json:"created_at"`
+	TransaksiID uint      `json:"transaksi_id" gorm:"not null"`
+	NamaFile    string    `json:"nama_file"`
+	ContentType string    `json:"content_type"`
+	Ukuran      int       `json:"ukuran"`
+	StorageKey  string    `json:"-"`
 }
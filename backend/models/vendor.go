@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Vendor - Pemasok / penyedia jasa (PLN, tukang, toko bangunan, dll)
+type Vendor struct {
+	ID         uint           `json:"id" gorm:"primaryKey"`
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+	DeletedAt  gorm.DeletedAt `json:"deleted_at" gorm:"index"`
+	Nama       string         `json:"nama" gorm:"not null"`
+	Kontak     string         `json:"kontak"`
+	NoHP       string         `json:"no_hp"`
+	Alamat     string         `json:"alamat"`
+	KategoriID *uint          `json:"kategori_id"` // kategori default untuk transaksi vendor ini
+	Keterangan string         `json:"keterangan"`
+}
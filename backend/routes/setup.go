@@ -15,6 +15,16 @@ func SetupRoutes(r *gin.Engine) {
 	// Public routes
 	r.POST("/login", controllers.Login)
 	r.POST("/register", controllers.Register)
+	r.GET("/dokumen/:jenis/:id", controllers.DownloadDokumenPublic)
+	r.POST("/webhook/pembayaran/:gateway", controllers.PaymentWebhook)
+	r.POST("/bukti/:id", controllers.UploadBuktiPembayaranPublic)
+	r.POST("/webhook/whatsapp/:provider", controllers.WhatsAppWebhook)
+	r.POST("/webhook/whatsapp/:provider/status", controllers.WhatsAppStatusWebhook)
+	r.POST("/webhook/sms/status", controllers.SMSStatusWebhook)
+	r.POST("/pengiriman/:id/status", controllers.LaporStatusPush)
+	r.GET("/push/vapid-public-key", controllers.GetVAPIDPublicKey)
+	r.POST("/push/:id", controllers.SubscribePush)
+	r.DELETE("/push/:id", controllers.UnsubscribePush)
 
 	// Protected routes
 	protected := r.Group("/")
@@ -40,16 +50,72 @@ func SetupRoutes(r *gin.Engine) {
 		protected.GET("/tagihan", controllers.GetTagihan)
 		protected.GET("/tagihan/filtered", controllers.GetTagihanFiltered)
 		protected.GET("/penyewa/:id/tagihan", controllers.GetTagihanByPenyewa)
+		protected.GET("/penyewa/:id/rekening-koran", controllers.GetRekeningKoran)
 		protected.POST("/tagihan", controllers.CreateTagihan)
 		protected.PUT("/tagihan/:id", controllers.UpdateTagihan)
 		protected.DELETE("/tagihan/:id", controllers.DeleteTagihan)
 		protected.POST("/tagihan/fix-terbayar", controllers.FixTerbayarMassal)
+		protected.GET("/tagihan/:id/invoice", controllers.GetInvoicePDF)
+		protected.GET("/tagihan/:id/kwitansi", controllers.GetKwitansiPDF)
+		protected.GET("/tagihan/:id/pembayaran", controllers.GetPembayaranByTagihan)
+		protected.POST("/tagihan/:id/pembayaran", controllers.CreatePembayaran)
+		protected.DELETE("/pembayaran/:id", controllers.DeletePembayaran)
+		protected.GET("/pembayaran-online", controllers.GetPembayaranOnline)
+		protected.POST("/tagihan/:id/pembayaran-online", controllers.CreatePembayaranOnline)
+		protected.POST("/tagihan/:id/qris", controllers.CreateQRISTagihan)
+		protected.POST("/pembayaran-online/:id/simulasi", controllers.SimulasiPembayaranOnline)
+		protected.GET("/tagihan/:id/link-bukti", controllers.GetLinkBuktiPembayaran)
+		protected.POST("/tagihan/:id/bukti", controllers.CreateBuktiPembayaran)
+		protected.GET("/bukti-pembayaran", controllers.GetBuktiPembayaran)
+		protected.GET("/bukti-pembayaran/:id/file", controllers.GetFileBuktiPembayaran)
+		protected.PUT("/bukti-pembayaran/:id/setujui", controllers.SetujuiBuktiPembayaran)
+		protected.PUT("/bukti-pembayaran/:id/tolak", controllers.TolakBuktiPembayaran)
 
 		// Transaksi
 		protected.GET("/transaksi", controllers.GetTransaksi)
 		protected.POST("/transaksi", controllers.CreateTransaksi)
 		protected.PUT("/transaksi/:id", controllers.UpdateTransaksi)
 		protected.DELETE("/transaksi/:id", controllers.DeleteTransaksi)
+		protected.POST("/transaksi/:id/tagihan", controllers.LinkTransaksiTagihan)
+		protected.POST("/transaksi/:id/lampiran", controllers.UploadLampiranTransaksi)
+		protected.GET("/transaksi-lampiran/:id", controllers.GetLampiranTransaksi)
+		protected.DELETE("/transaksi-lampiran/:id", controllers.DeleteLampiranTransaksi)
+
+		// Kategori & vendor transaksi
+		protected.GET("/kategori", controllers.GetKategori)
+		protected.POST("/kategori", controllers.CreateKategori)
+		protected.PUT("/kategori/:id", controllers.UpdateKategori)
+		protected.DELETE("/kategori/:id", controllers.DeleteKategori)
+		protected.GET("/vendor", controllers.GetVendor)
+		protected.POST("/vendor", controllers.CreateVendor)
+		protected.PUT("/vendor/:id", controllers.UpdateVendor)
+		protected.DELETE("/vendor/:id", controllers.DeleteVendor)
+
+		// Akuntansi (jurnal double-entry)
+		protected.GET("/akun", controllers.GetAkun)
+		protected.POST("/akun", controllers.CreateAkun)
+		protected.PUT("/akun/:id", controllers.UpdateAkun)
+		protected.DELETE("/akun/:id", controllers.DeleteAkun)
+		protected.GET("/jurnal", controllers.GetJurnal)
+		protected.POST("/jurnal", controllers.CreateJurnal)
+		protected.DELETE("/jurnal/:id", controllers.DeleteJurnal)
+
+		// Rekening kas/bank, transfer & rekonsiliasi rekening koran
+		protected.GET("/rekening", controllers.GetRekening)
+		protected.POST("/rekening", controllers.CreateRekening)
+		protected.PUT("/rekening/:id", controllers.UpdateRekening)
+		protected.DELETE("/rekening/:id", controllers.DeleteRekening)
+		protected.GET("/rekening/:id/mutasi", controllers.GetMutasiRekening)
+		protected.GET("/rekening/:id/mutasi-bank", controllers.GetMutasiBank)
+		protected.POST("/rekening/:id/mutasi-bank", controllers.ImportMutasiBank)
+		protected.GET("/rekening/:id/rekonsiliasi", controllers.GetRekonsiliasiBank)
+		protected.POST("/mutasi-bank/:id/cocokkan", controllers.CocokkanMutasiBank)
+		protected.DELETE("/mutasi-bank/:id/cocokkan", controllers.BatalCocokMutasiBank)
+		protected.POST("/mutasi-bank/:id/transaksi", controllers.CatatMutasiBank)
+		protected.POST("/mutasi-bank/:id/abaikan", controllers.AbaikanMutasiBank)
+		protected.GET("/transfer", controllers.GetTransfer)
+		protected.POST("/transfer", controllers.CreateTransfer)
+		protected.DELETE("/transfer/:id", controllers.DeleteTransfer)
 
 		// Users
 		protected.GET("/users", controllers.GetUsers)
@@ -66,12 +132,66 @@ func SetupRoutes(r *gin.Engine) {
 		protected.POST("/notifikasi/check", controllers.CheckAndCreateNotifikasi)
 		protected.PUT("/notifikasi/:id/read", controllers.MarkNotifikasiAsRead)
 		protected.DELETE("/notifikasi/:id", controllers.DeleteNotifikasi)
+		protected.POST("/notifikasi/:id/kirim", controllers.KirimNotifikasi)
+		protected.GET("/notifikasi/:id/pengiriman", controllers.GetPengirimanNotifikasi)
+		protected.POST("/pengiriman/:id/simulasi-status", controllers.SimulasiStatusPengiriman)
+		protected.GET("/penyewa/:id/kanal-notifikasi", controllers.GetKanalNotifikasi)
+		protected.PUT("/penyewa/:id/kanal-notifikasi", controllers.UpdateKanalNotifikasi)
+		protected.GET("/penyewa/:id/link-push", controllers.GetLinkPush)
+
+		// Template pesan
+		protected.GET("/template-pesan", controllers.GetTemplatePesan)
+		protected.POST("/template-pesan/preview", controllers.PreviewTemplatePesan)
+		protected.PUT("/template-pesan/:tipe/:bahasa", controllers.UpdateTemplatePesan)
+		protected.DELETE("/template-pesan/:tipe/:bahasa", controllers.ResetTemplatePesan)
+
+		// Eskalasi pengingat
+		protected.GET("/eskalasi", controllers.GetLangkahEskalasi)
+		protected.POST("/eskalasi", controllers.CreateLangkahEskalasi)
+		protected.PUT("/eskalasi/:id", controllers.UpdateLangkahEskalasi)
+		protected.DELETE("/eskalasi/:id", controllers.DeleteLangkahEskalasi)
 
 		// Reports
 		protected.GET("/report/monthly", controllers.GetMonthlyReport)
 		protected.GET("/report/yearly", controllers.GetYearlyReport)
 		protected.GET("/report/detail", controllers.GetDetailReport)
+		protected.GET("/report/ledger", controllers.GetLedgerReport)
 		protected.GET("/report/cashflow", controllers.GetCashFlowProjection)
+		protected.GET("/report/rekonsiliasi", controllers.GetRekonsiliasiReport)
+		protected.GET("/report/umur-piutang", controllers.GetUmurPiutang)
+		protected.GET("/report/umur-piutang/:id", controllers.GetUmurPiutangPenyewa)
+		protected.GET("/report/okupansi", controllers.GetOccupancyReport)
+		protected.GET("/report/kategori", controllers.GetKategoriReport)
+		protected.GET("/report/neraca-saldo", controllers.GetNeracaSaldo)
+		protected.GET("/report/laba-rugi", controllers.GetLabaRugi)
+		protected.GET("/report/neraca", controllers.GetNeraca)
+
+		// Data proyeksi arus kas
+		protected.GET("/perubahan-harga", controllers.GetPerubahanHarga)
+		protected.POST("/perubahan-harga", controllers.CreatePerubahanHarga)
+		protected.DELETE("/perubahan-harga/:id", controllers.DeletePerubahanHarga)
+		protected.GET("/pengeluaran-rutin", controllers.GetPengeluaranRutin)
+		protected.POST("/pengeluaran-rutin", controllers.CreatePengeluaranRutin)
+		protected.PUT("/pengeluaran-rutin/:id", controllers.UpdatePengeluaranRutin)
+		protected.DELETE("/pengeluaran-rutin/:id", controllers.DeletePengeluaranRutin)
+
+		// Perbaikan kamar
+		protected.GET("/perbaikan", controllers.GetPerbaikan)
+		protected.GET("/perbaikan/:id", controllers.GetPerbaikanByID)
+		protected.POST("/perbaikan", controllers.CreatePerbaikan)
+		protected.PUT("/perbaikan/:id", controllers.UpdatePerbaikan)
+		protected.PUT("/perbaikan/:id/status", controllers.UpdateStatusPerbaikan)
+		protected.POST("/perbaikan/:id/foto", controllers.UploadFotoPerbaikan)
+		protected.GET("/perbaikan-foto/:id", controllers.GetFotoPerbaikan)
+		protected.DELETE("/perbaikan/:id", controllers.DeletePerbaikan)
+
+		// Export
+		protected.GET("/export/tunggakan", controllers.ExportTunggakan)
+		protected.GET("/export/penyewa", controllers.ExportPenyewa)
+
+		// Import
+		protected.GET("/import/:entitas/fields", controllers.GetImportFields)
+		protected.POST("/import/:entitas", controllers.ImportData)
 
 		// WhatsApp Integration
 		protected.POST("/whatsapp/send", controllers.SendWhatsAppReminder)
@@ -79,5 +199,11 @@ func SetupRoutes(r *gin.Engine) {
 		protected.GET("/whatsapp/settings/:id", controllers.GetWhatsAppSettings)
 		protected.PUT("/whatsapp/settings/:id", controllers.UpdateWhatsAppSettings)
 		protected.POST("/whatsapp/test", controllers.TestWhatsAppMessage)
+		protected.POST("/whatsapp/send-dokumen", controllers.SendDokumenWhatsApp)
+		protected.POST("/whatsapp/simulasi-masuk", controllers.SimulasiWhatsAppMasuk)
+		protected.POST("/whatsapp/selamat-datang/:id", controllers.SendWelcomeWhatsApp)
+		protected.GET("/percakapan", controllers.GetPercakapan)
+		protected.GET("/penyewa/:id/percakapan", controllers.GetPercakapanPenyewa)
+		protected.POST("/penyewa/:id/percakapan", controllers.BalasPercakapan)
 	}
 }
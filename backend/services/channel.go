@@ -0,0 +1,241 @@
+package services
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/http"
+	"net/smtp"
+	"os"
+	"strings"
+	"time"
+)
+
+// ErrChannelNotConfigured - Kanal belum diatur di environment
+var ErrChannelNotConfigured = errors.New("channel is not configured")
+
+// Status pengiriman pesan, berurutan queued -> sent -> delivered -> read; failed bisa
+// terjadi setelah sent (mis. nomor tidak aktif)
+const (
+	DeliveryQueued    = "queued"
+	DeliverySent      = "sent"
+	DeliveryDelivered = "delivered"
+	DeliveryRead      = "read"
+	DeliveryFailed    = "failed"
+)
+
+// DeliveryStatus - Isi callback status pengiriman yang sudah diverifikasi
+type DeliveryStatus struct {
+	MessageID string
+	Status    string
+	Error     string
+	At        time.Time
+}
+
+// NormalizeDeliveryStatus - Samakan istilah status provider ke status pengiriman,
+// kosong bila tidak dikenal
+func NormalizeDeliveryStatus(status string) string {
+	switch strings.ToLower(strings.TrimSpace(status)) {
+	case "accepted", "queued", "scheduled", "sending", "pending":
+		return DeliveryQueued
+	case "sent":
+		return DeliverySent
+	case "delivered":
+		return DeliveryDelivered
+	case "read", "seen", "clicked":
+		return DeliveryRead
+	case "failed", "undelivered", "rejected", "expired", "bounced":
+		return DeliveryFailed
+	}
+	return ""
+}
+
+// NotificationMessage - Isi notifikasi yang dikirim lewat satu kanal
+type NotificationMessage struct {
+	Subject    string // judul email / push
+	Body       string
+	URL        string // dibuka saat notifikasi push diklik
+	ReceiptURL string // dipanggil service worker saat push tampil / diklik
+}
+
+// Channel - Kanal pengiriman notifikasi (email, SMS, web push, WhatsApp). to adalah alamat
+// tujuan sesuai kanal: email, nomor HP, atau JSON PushSubscription browser.
+type Channel interface {
+	Name() string
+	// Send mengirim pesan dan mengembalikan id pesan dari provider
+	Send(to string, msg NotificationMessage) (string, error)
+}
+
+// NewChannel - Kanal sesuai nama dan konfigurasi environment:
+// email (SMTP_HOST, SMTP_PORT, SMTP_USERNAME, SMTP_PASSWORD, SMTP_FROM),
+// sms (SMS_API_URL, SMS_API_KEY), push (VAPID_PUBLIC_KEY, VAPID_PRIVATE_KEY, VAPID_SUBJECT).
+func NewChannel(name string) (Channel, error) {
+	switch name {
+	case "email":
+		if os.Getenv("SMTP_HOST") == "" || os.Getenv("SMTP_FROM") == "" {
+			return nil, ErrChannelNotConfigured
+		}
+		port := os.Getenv("SMTP_PORT")
+		if port == "" {
+			port = "587"
+		}
+		return EmailChannel{
+			Host:     os.Getenv("SMTP_HOST"),
+			Port:     port,
+			Username: os.Getenv("SMTP_USERNAME"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+			From:     os.Getenv("SMTP_FROM"),
+		}, nil
+	case "sms":
+		if os.Getenv("SMS_API_URL") == "" {
+			return nil, ErrChannelNotConfigured
+		}
+		return SMSChannel{URL: os.Getenv("SMS_API_URL"), APIKey: os.Getenv("SMS_API_KEY")}, nil
+	case "push":
+		return NewWebPushChannel()
+	default:
+		return nil, fmt.Errorf("unknown channel %q", name)
+	}
+}
+
+// EmailChannel - Email lewat SMTP. STARTTLS dipakai bila server mendukung; tanpa
+// SMTP_USERNAME dikirim tanpa autentikasi (mis. SMTP stub lokal).
+type EmailChannel struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+func (ch EmailChannel) Name() string { return "email" }
+
+func (ch EmailChannel) Send(to string, msg NotificationMessage) (string, error) {
+	if to == "" {
+		return "", errors.New("email address is required")
+	}
+	id := make([]byte, 8)
+	rand.Read(id)
+	messageID := fmt.Sprintf("<%d.%s@%s>", time.Now().UnixNano(), hex.EncodeToString(id), ch.Host)
+
+	var body bytes.Buffer
+	qp := quotedprintable.NewWriter(&body)
+	qp.Write([]byte(msg.Body))
+	if msg.URL != "" {
+		qp.Write([]byte("\n\n" + msg.URL))
+	}
+	qp.Close()
+
+	var data bytes.Buffer
+	fmt.Fprintf(&data, "From: %s\r\n", ch.From)
+	fmt.Fprintf(&data, "To: %s\r\n", to)
+	fmt.Fprintf(&data, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&data, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&data, "Message-ID: %s\r\n", messageID)
+	data.WriteString("MIME-Version: 1.0\r\n")
+	data.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	data.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+	data.Write(body.Bytes())
+
+	var auth smtp.Auth
+	if ch.Username != "" {
+		auth = smtp.PlainAuth("", ch.Username, ch.Password, ch.Host)
+	}
+	from := ch.From
+	if i := strings.LastIndex(from, "<"); i >= 0 {
+		from = strings.TrimSuffix(from[i+1:], ">")
+	}
+	if err := smtp.SendMail(net.JoinHostPort(ch.Host, ch.Port), auth, from, []string{to}, data.Bytes()); err != nil {
+		return "", err
+	}
+	return messageID, nil
+}
+
+// SMSChannel - SMS lewat HTTP API gateway: POST JSON {"to", "message"} dengan
+// Authorization: Bearer SMS_API_KEY, balasan 2xx berisi {"id"} atau {"message_id"}.
+// Laporan status (DLR) dibaca ParseSMSStatus.
+type SMSChannel struct {
+	URL    string
+	APIKey string
+}
+
+func (ch SMSChannel) Name() string { return "sms" }
+
+func (ch SMSChannel) Send(to string, msg NotificationMessage) (string, error) {
+	if to == "" {
+		return "", errors.New("phone number is required")
+	}
+	text := msg.Body
+	if msg.URL != "" {
+		text += "\n" + msg.URL
+	}
+	payload, _ := json.Marshal(map[string]string{"to": to, "message": text})
+	req, err := http.NewRequest(http.MethodPost, ch.URL, bytes.NewReader(payload))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if ch.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+ch.APIKey)
+	}
+	resp, err := (&http.Client{Timeout: 30 * time.Second}).Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if resp.StatusCode/100 != 2 {
+		return "", fmt.Errorf("sms gateway: %s: %s", resp.Status, strings.TrimSpace(string(respBody)))
+	}
+	var result struct {
+		ID        string `json:"id"`
+		MessageID string `json:"message_id"`
+	}
+	json.Unmarshal(respBody, &result)
+	if result.ID != "" {
+		return result.ID, nil
+	}
+	return result.MessageID, nil
+}
+
+// ParseSMSStatus - Callback status dari SMS gateway: JSON {"id", "status", "error"} dengan
+// HMAC-SHA256 hex atas body di header X-Signature memakai SMS_WEBHOOK_SECRET
+func ParseSMSStatus(body []byte, header http.Header) (DeliveryStatus, error) {
+	secret := os.Getenv("SMS_WEBHOOK_SECRET")
+	if secret == "" {
+		return DeliveryStatus{}, errors.New("SMS_WEBHOOK_SECRET is not configured")
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	expected, err := hex.DecodeString(header.Get("X-Signature"))
+	if err != nil || !hmac.Equal(expected, mac.Sum(nil)) {
+		return DeliveryStatus{}, ErrInvalidSignature
+	}
+	var payload struct {
+		ID        string `json:"id"`
+		MessageID string `json:"message_id"`
+		Status    string `json:"status"`
+		Error     string `json:"error"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return DeliveryStatus{}, err
+	}
+	if payload.ID == "" {
+		payload.ID = payload.MessageID
+	}
+	return DeliveryStatus{
+		MessageID: payload.ID,
+		Status:    NormalizeDeliveryStatus(payload.Status),
+		Error:     payload.Error,
+		At:        time.Now(),
+	}, nil
+}
@@ -0,0 +1,114 @@
+package services
+
+import (
+	"io"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"testing"
+)
+
+// smtpStub - Server SMTP minimal tanpa STARTTLS/AUTH; isi DATA pesan pertama (baris diakhiri
+// \n, seperti hasil textproto.ReadDotBytes) dikirim ke channel
+func smtpStub(t *testing.T) (string, string, <-chan string) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	pesan := make(chan string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		tp := textproto.NewConn(conn)
+		tp.PrintfLine("220 stub ESMTP")
+		for {
+			line, err := tp.ReadLine()
+			if err != nil {
+				return
+			}
+			switch cmd := strings.ToUpper(strings.SplitN(line, " ", 2)[0]); cmd {
+			case "EHLO", "HELO", "MAIL", "RCPT", "RSET", "NOOP":
+				tp.PrintfLine("250 OK")
+			case "DATA":
+				tp.PrintfLine("354 go ahead")
+				data, err := tp.ReadDotBytes()
+				if err != nil {
+					return
+				}
+				pesan <- string(data)
+				tp.PrintfLine("250 queued")
+			case "QUIT":
+				tp.PrintfLine("221 bye")
+				return
+			default:
+				tp.PrintfLine("502 unknown command")
+			}
+		}
+	}()
+	host, port, _ := net.SplitHostPort(ln.Addr().String())
+	return host, port, pesan
+}
+
+func TestEmailChannelSend(t *testing.T) {
+	host, port, pesan := smtpStub(t)
+	ch := EmailChannel{Host: host, Port: port, From: "Kos Muhandis <kos@example.com>"}
+	body := "Halo Budi,\n\nTagihan Sewa Kamar bulan Januari 2025 sebesar Rp 1.500.000 jatuh tempo hari ini. " +
+		"Baris ini sengaja dibuat lebih dari tujuh puluh enam karakter agar dipecah = soft line break."
+	id, err := ch.Send("budi@example.com", NotificationMessage{
+		Subject: "Pengingat tagihan – Januari",
+		Body:    body,
+		URL:     "https://kos.example.com/bayar?id=1",
+	})
+	if err != nil {
+		t.Fatal("send:", err)
+	}
+
+	msg, err := mail.ReadMessage(strings.NewReader(<-pesan))
+	if err != nil {
+		t.Fatal("parse message:", err)
+	}
+	headers := map[string]string{
+		"From":                      "Kos Muhandis <kos@example.com>",
+		"To":                        "budi@example.com",
+		"Message-Id":                id,
+		"Mime-Version":              "1.0",
+		"Content-Type":              "text/plain; charset=UTF-8",
+		"Content-Transfer-Encoding": "quoted-printable",
+	}
+	for k, want := range headers {
+		if got := msg.Header.Get(k); got != want {
+			t.Errorf("header %s = %q, want %q", k, got, want)
+		}
+	}
+	if subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject")); err != nil || subject != "Pengingat tagihan – Januari" {
+		t.Errorf("subject = %q (%v)", subject, err)
+	}
+	if _, err := msg.Header.Date(); err != nil {
+		t.Errorf("date header: %v", err)
+	}
+
+	raw, _ := io.ReadAll(msg.Body)
+	if !strings.Contains(string(raw), "bayar?id=3D1") {
+		t.Errorf("body is not quoted-printable encoded: %q", raw)
+	}
+	for _, line := range strings.Split(string(raw), "\n") {
+		if len(line) > 76 {
+			t.Errorf("quoted-printable line longer than 76: %q", line)
+		}
+	}
+	decoded, err := io.ReadAll(quotedprintable.NewReader(strings.NewReader(string(raw))))
+	if err != nil {
+		t.Fatal("decode body:", err)
+	}
+	if want := body + "\n\nhttps://kos.example.com/bayar?id=1"; strings.TrimSuffix(string(decoded), "\n") != want {
+		t.Errorf("body = %q, want %q", decoded, want)
+	}
+}
@@ -0,0 +1,152 @@
+package services
+
+import (
+	"strconv"
+	"strings"
+)
+
+// DokumenItem - Satu baris rincian pada invoice / kwitansi
+type DokumenItem struct {
+	Keterangan string
+	Jumlah     int
+}
+
+// DokumenTagihan - Data yang dibutuhkan untuk mencetak invoice dan kwitansi
+type DokumenTagihan struct {
+	Nomor        string
+	NamaKos      string
+	AlamatKos    string
+	Tanggal      string // tanggal dokumen, "02 Januari 2006"
+	NamaPenyewa  string
+	NoHP         string
+	NamaKamar    string
+	Periode      string // "2025-11"
+	Items        []DokumenItem
+	Total        int
+	Terbayar     int
+	Status       string
+	DiterimaOleh string
+	TanggalBayar string
+}
+
+// Sisa - Sisa tagihan yang belum dibayar
+func (d DokumenTagihan) Sisa() int {
+	if d.Terbayar >= d.Total {
+		return 0
+	}
+	return d.Total - d.Terbayar
+}
+
+// RenderInvoicePDF - Cetak invoice tagihan
+func RenderInvoicePDF(d DokumenTagihan) []byte {
+	pdf := NewPDFDocument()
+	renderKop(pdf, d, "INVOICE")
+
+	pdf.Text(50, 150, 10, true, "Kepada:")
+	pdf.Text(50, 165, 10, false, d.NamaPenyewa)
+	pdf.Text(50, 180, 10, false, "Kamar "+d.NamaKamar)
+	if d.NoHP != "" {
+		pdf.Text(50, 195, 10, false, d.NoHP)
+	}
+	pdf.Text(350, 150, 10, true, "Periode:")
+	pdf.Text(420, 150, 10, false, NamaBulan(d.Periode))
+	pdf.Text(350, 165, 10, true, "Status:")
+	pdf.Text(420, 165, 10, false, d.Status)
+
+	y := renderItems(pdf, d.Items, 225)
+
+	pdf.Text(330, y, 10, true, "Total")
+	pdf.TextRight(545, y, 10, true, FormatRupiah(d.Total))
+	pdf.Text(330, y+15, 10, false, "Terbayar")
+	pdf.TextRight(545, y+15, 10, false, FormatRupiah(d.Terbayar))
+	pdf.Text(330, y+30, 10, true, "Sisa Tagihan")
+	pdf.TextRight(545, y+30, 10, true, FormatRupiah(d.Sisa()))
+
+	y += 60
+	pdf.Text(50, y, 10, true, "Terbilang:")
+	for i, line := range WrapText(capitalize(Terbilang(d.Total)), 10, 420) {
+		pdf.Text(120, y+float64(i)*14, 10, false, line)
+	}
+
+	pdf.Text(50, 760, 9, false, "Mohon lakukan pembayaran sebelum akhir bulan "+NamaBulan(d.Periode)+". Terima kasih.")
+	return pdf.Bytes()
+}
+
+// RenderKwitansiPDF - Cetak kwitansi (bukti pembayaran) untuk jumlah yang sudah dibayar
+func RenderKwitansiPDF(d DokumenTagihan) []byte {
+	pdf := NewPDFDocument()
+	renderKop(pdf, d, "KWITANSI")
+
+	pdf.Text(50, 155, 10, true, "Telah terima dari")
+	pdf.Text(170, 155, 10, false, ": "+d.NamaPenyewa+" (Kamar "+d.NamaKamar+")")
+
+	pdf.Text(50, 175, 10, true, "Uang sejumlah")
+	for i, line := range WrapText(capitalize(Terbilang(d.Terbayar)), 10, 370) {
+		prefix := "  "
+		if i == 0 {
+			prefix = ": "
+		}
+		pdf.Text(170, 175+float64(i)*14, 10, false, prefix+line)
+	}
+
+	pdf.Text(50, 215, 10, true, "Untuk pembayaran")
+	pdf.Text(170, 215, 10, false, ": Tagihan periode "+NamaBulan(d.Periode))
+
+	y := renderItems(pdf, d.Items, 240)
+	pdf.Text(330, y, 10, false, "Total Tagihan")
+	pdf.TextRight(545, y, 10, false, FormatRupiah(d.Total))
+	pdf.Text(330, y+15, 10, false, "Sisa Tagihan")
+	pdf.TextRight(545, y+15, 10, false, FormatRupiah(d.Sisa()))
+
+	y += 50
+	pdf.Rect(50, y, 200, 30)
+	pdf.Text(60, y+20, 14, true, FormatRupiah(d.Terbayar))
+
+	tanggal := d.TanggalBayar
+	if tanggal == "" {
+		tanggal = d.Tanggal
+	}
+	pdf.Text(380, y, 10, false, tanggal)
+	pdf.Text(380, y+15, 10, false, "Penerima,")
+	pdf.Line(380, y+80, 545, y+80)
+	pdf.Text(380, y+95, 10, true, d.DiterimaOleh)
+
+	return pdf.Bytes()
+}
+
+func renderKop(pdf *PDFDocument, d DokumenTagihan, judul string) {
+	pdf.Text(50, 60, 18, true, d.NamaKos)
+	if d.AlamatKos != "" {
+		pdf.Text(50, 78, 9, false, d.AlamatKos)
+	}
+	pdf.TextRight(545, 60, 18, true, judul)
+	pdf.TextRight(545, 78, 9, false, "No. "+d.Nomor)
+	pdf.TextRight(545, 92, 9, false, "Tanggal "+d.Tanggal)
+	pdf.Line(50, 110, 545, 110)
+}
+
+// renderItems - Tabel rincian, mengembalikan posisi y setelah tabel
+func renderItems(pdf *PDFDocument, items []DokumenItem, y float64) float64 {
+	pdf.Line(50, y, 545, y)
+	pdf.Text(55, y+15, 10, true, "No")
+	pdf.Text(85, y+15, 10, true, "Keterangan")
+	pdf.TextRight(540, y+15, 10, true, "Jumlah")
+	pdf.Line(50, y+22, 545, y+22)
+
+	y += 37
+	for i, item := range items {
+		pdf.Text(55, y, 10, false, strconv.Itoa(i+1))
+		pdf.Text(85, y, 10, false, item.Keterangan)
+		pdf.TextRight(540, y, 10, false, FormatRupiah(item.Jumlah))
+		y += 15
+	}
+	pdf.Line(50, y-7, 545, y-7)
+	return y + 10
+}
+
+func capitalize(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
@@ -0,0 +1,187 @@
+package services
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ExportColumn - Definisi kolom untuk export CSV/XLSX
+type ExportColumn struct {
+	Header string
+	Rupiah bool // nilai int ditampilkan sebagai Rupiah
+}
+
+// ExportTable - Tabel data yang siap diexport
+type ExportTable struct {
+	Title   string
+	Columns []ExportColumn
+	Rows    [][]interface{}
+}
+
+// AddRow - Tambah satu baris data
+func (t *ExportTable) AddRow(values ...interface{}) {
+	t.Rows = append(t.Rows, values)
+}
+
+// WriteCSV - Tulis tabel sebagai CSV, kolom Rupiah diformat "Rp 1.500.000". Teks yang bisa
+// dibaca sebagai formula diawali ' (lihat csvText).
+func WriteCSV(w io.Writer, t ExportTable) error {
+	writer := csv.NewWriter(w)
+	headers := make([]string, len(t.Columns))
+	for i, col := range t.Columns {
+		headers[i] = col.Header
+	}
+	if err := writer.Write(headers); err != nil {
+		return err
+	}
+
+	for _, row := range t.Rows {
+		record := make([]string, len(row))
+		for i, value := range row {
+			switch v := value.(type) {
+			case int:
+				if i < len(t.Columns) && t.Columns[i].Rupiah {
+					record[i] = csvText(FormatRupiah(v))
+				} else {
+					record[i] = strconv.Itoa(v)
+				}
+			case int64, float64:
+				record[i] = fmt.Sprint(v)
+			default:
+				record[i] = csvText(fmt.Sprint(v))
+			}
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// csvText - Awali teks dengan ' bila dimulai =, +, -, @, tab atau CR agar tidak dijalankan
+// sebagai formula saat CSV dibuka di spreadsheet (CSV injection)
+func csvText(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+// WriteXLSX - Tulis tabel sebagai workbook XLSX satu sheet.
+// Kolom Rupiah disimpan sebagai angka dengan format "Rp #.##0" agar tetap bisa dijumlah di Excel.
+func WriteXLSX(w io.Writer, t ExportTable) error {
+	sheetName := t.Title
+	if sheetName == "" {
+		sheetName = "Sheet1"
+	}
+	if len(sheetName) > 31 {
+		sheetName = sheetName[:31]
+	}
+
+	var sheet strings.Builder
+	sheet.WriteString(xml.Header)
+	sheet.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+
+	headers := make([]interface{}, len(t.Columns))
+	for i, col := range t.Columns {
+		headers[i] = col.Header
+	}
+	writeXLSXRow(&sheet, 1, headers, t.Columns, true)
+	for i, row := range t.Rows {
+		writeXLSXRow(&sheet, i+2, row, t.Columns, false)
+	}
+	sheet.WriteString(`</sheetData></worksheet>`)
+
+	files := []struct {
+		name    string
+		content string
+	}{
+		{"[Content_Types].xml", xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+			`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+			`<Default Extension="xml" ContentType="application/xml"/>` +
+			`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+			`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+			`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>` +
+			`</Types>`},
+		{"_rels/.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+			`</Relationships>`},
+		{"xl/workbook.xml", xml.Header + `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+			`<sheets><sheet name="` + xmlEscape(sheetName) + `" sheetId="1" r:id="rId1"/></sheets></workbook>`},
+		{"xl/_rels/workbook.xml.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+			`<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>` +
+			`</Relationships>`},
+		{"xl/styles.xml", xml.Header + `<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+			`<numFmts count="1"><numFmt numFmtId="164" formatCode="&quot;Rp &quot;#,##0"/></numFmts>` +
+			`<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>` +
+			`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>` +
+			`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+			`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+			`<cellXfs count="3"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>` +
+			`<xf numFmtId="164" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+			`<xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/></cellXfs>` +
+			`</styleSheet>`},
+		{"xl/worksheets/sheet1.xml", sheet.String()},
+	}
+
+	zw := zip.NewWriter(w)
+	for _, f := range files {
+		fw, err := zw.Create(f.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(fw, f.content); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+func writeXLSXRow(b *strings.Builder, rowNum int, values []interface{}, columns []ExportColumn, header bool) {
+	fmt.Fprintf(b, `<row r="%d">`, rowNum)
+	for i, value := range values {
+		ref := xlsxColumnName(i) + strconv.Itoa(rowNum)
+		style := 0
+		if header {
+			style = 2
+		} else if i < len(columns) && columns[i].Rupiah {
+			style = 1
+		}
+
+		switch v := value.(type) {
+		case int:
+			fmt.Fprintf(b, `<c r="%s" s="%d"><v>%d</v></c>`, ref, style, v)
+		case int64:
+			fmt.Fprintf(b, `<c r="%s" s="%d"><v>%d</v></c>`, ref, style, v)
+		case float64:
+			fmt.Fprintf(b, `<c r="%s" s="%d"><v>%s</v></c>`, ref, style, strconv.FormatFloat(v, 'f', -1, 64))
+		default:
+			fmt.Fprintf(b, `<c r="%s" s="%d" t="inlineStr"><is><t>%s</t></is></c>`, ref, style, xmlEscape(fmt.Sprint(v)))
+		}
+	}
+	b.WriteString(`</row>`)
+}
+
+// xlsxColumnName - 0 -> A, 25 -> Z, 26 -> AA
+func xlsxColumnName(i int) string {
+	name := ""
+	for i >= 0 {
+		name = string(rune('A'+i%26)) + name
+		i = i/26 - 1
+	}
+	return name
+}
+
+func xmlEscape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
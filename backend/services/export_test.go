@@ -0,0 +1,39 @@
+package services
+
+import (
+	"bytes"
+	"encoding/csv"
+	"reflect"
+	"testing"
+)
+
+func TestWriteCSVFormulaInjection(t *testing.T) {
+	table := ExportTable{Columns: []ExportColumn{{Header: "Nama"}, {Header: "Jumlah", Rupiah: true}, {Header: "Hari"}}}
+	table.AddRow("=HYPERLINK(\"http://x\")", 1500000, 3)
+	table.AddRow("+62812", -250000, -2)
+	table.AddRow("-cmd", 0, 0)
+	table.AddRow("@SUM(A1)", 0, 0)
+	table.AddRow("\tTab", 0, 0)
+	table.AddRow("Budi = Ani", 0, 0)
+
+	var buf bytes.Buffer
+	if err := WriteCSV(&buf, table); err != nil {
+		t.Fatal(err)
+	}
+	got, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{
+		{"Nama", "Jumlah", "Hari"},
+		{"'=HYPERLINK(\"http://x\")", "Rp 1.500.000", "3"},
+		{"'+62812", "'-Rp 250.000", "-2"},
+		{"'-cmd", "Rp 0", "0"},
+		{"'@SUM(A1)", "Rp 0", "0"},
+		{"'\tTab", "Rp 0", "0"},
+		{"Budi = Ani", "Rp 0", "0"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("WriteCSV =\n%q\nwant\n%q", got, want)
+	}
+}
@@ -0,0 +1,261 @@
+package services
+
+import (
+	"math"
+	"time"
+)
+
+// ForecastKamar - Kondisi satu kamar sebagai input proyeksi
+type ForecastKamar struct {
+	KamarID       uint
+	Harga         int
+	Perbaikan     bool       // kamar sedang diperbaiki, tidak bisa disewakan
+	Terisi        bool       // ada penyewa aktif
+	KontrakSampai *time.Time // nil = penyewa tanpa tanggal keluar (dianggap lanjut)
+}
+
+// ForecastPerubahanHarga - Perubahan harga kamar yang sudah dijadwalkan
+type ForecastPerubahanHarga struct {
+	KamarID      uint
+	HargaBaru    int
+	BerlakuMulai time.Time
+}
+
+// ForecastPengeluaranRutin - Pengeluaran rutin yang sudah diketahui
+type ForecastPengeluaranRutin struct {
+	Jumlah        int
+	IntervalBulan int        // 1 = tiap bulan, 3 = tiap triwulan, dst
+	Mulai         time.Time  // bulan pertama pengeluaran
+	Selesai       *time.Time // nil = tanpa batas
+}
+
+// ForecastInput - Data historis dan jadwal untuk proyeksi arus kas
+type ForecastInput struct {
+	Mulai            time.Time // bulan pertama proyeksi (tanggal 1)
+	JumlahBulan      int
+	Kamar            []ForecastKamar
+	PerubahanHarga   []ForecastPerubahanHarga
+	PengeluaranRutin []ForecastPengeluaranRutin
+	// Rasio uang diterima / ditagihkan per bulan historis (0..1)
+	TingkatPenagihan []float64
+	// Total pengeluaran per bulan historis, key "2006-01"
+	PengeluaranHistoris map[string]int
+}
+
+// ForecastSkenario - Angka proyeksi untuk satu skenario
+type ForecastSkenario struct {
+	Pendapatan  int `json:"pendapatan"`
+	Pengeluaran int `json:"pengeluaran"`
+	Net         int `json:"net"`
+}
+
+// ForecastBulan - Hasil proyeksi satu bulan
+type ForecastBulan struct {
+	Bulan             string           `json:"bulan"`
+	KamarTerkontrak   int              `json:"kamar_terkontrak"`
+	KamarTidakTerikat int              `json:"kamar_tidak_terikat"`
+	PengeluaranRutin  int              `json:"pengeluaran_rutin"`
+	IndeksMusiman     float64          `json:"indeks_musiman"`
+	Best              ForecastSkenario `json:"best"`
+	Expected          ForecastSkenario `json:"expected"`
+	Worst             ForecastSkenario `json:"worst"`
+}
+
+// ForecastCashFlow - Proyeksi arus kas per bulan dengan skenario best/expected/worst.
+//
+// Pendapatan: kamar dengan kontrak yang masih berjalan pada bulan tersebut dihitung penuh
+// (dengan harga terjadwal), kamar tanpa kontrak dihitung dengan peluang terisi = tingkat
+// hunian saat ini (expected), setengah sisa peluang ditambahkan (best), atau nol (worst).
+// Hasil tagihan dikalikan tingkat penagihan historis: rata-rata (expected), rata-rata ± 1
+// simpangan baku (best/worst).
+//
+// Pengeluaran: pengeluaran rutin yang diketahui ditambah pengeluaran variabel (rata-rata
+// historis dikurangi rutin) yang dikalikan indeks musiman bulan kalender tersebut;
+// best/worst memakai ± koefisien variasi historis.
+func ForecastCashFlow(in ForecastInput) []ForecastBulan {
+	rentable, occupied := 0, 0
+	for _, k := range in.Kamar {
+		if k.Perbaikan {
+			continue
+		}
+		rentable++
+		if k.Terisi {
+			occupied++
+		}
+	}
+	occupancy := 0.0
+	if rentable > 0 {
+		occupancy = float64(occupied) / float64(rentable)
+	}
+	occupancyBest := occupancy + (1-occupancy)/2
+
+	rateMean, rateStd := meanStd(in.TingkatPenagihan)
+	if len(in.TingkatPenagihan) == 0 {
+		rateMean = 1
+	}
+	rateBest := math.Min(1, rateMean+rateStd)
+	rateWorst := math.Max(0, rateMean-rateStd)
+
+	seasonal, variableBase, variation := expensePattern(in.PengeluaranHistoris, in.PengeluaranRutin, in.Mulai)
+
+	var result []ForecastBulan
+	for i := 0; i < in.JumlahBulan; i++ {
+		month := time.Date(in.Mulai.Year(), in.Mulai.Month()+time.Month(i), 1, 0, 0, 0, 0, time.UTC)
+		monthEnd := month.AddDate(0, 1, -1)
+
+		contracted, uncontracted := 0, 0
+		contractedCount, uncontractedCount := 0, 0
+		for _, k := range in.Kamar {
+			if k.Perbaikan {
+				continue
+			}
+			price := hargaPada(k, in.PerubahanHarga, month)
+			if k.Terisi && (k.KontrakSampai == nil || !k.KontrakSampai.Before(monthEnd)) {
+				contracted += price
+				contractedCount++
+			} else {
+				uncontracted += price
+				uncontractedCount++
+			}
+		}
+
+		rutin := pengeluaranRutinPada(in.PengeluaranRutin, month)
+		index := seasonal[month.Month()]
+		variable := float64(variableBase) * index
+
+		bulan := ForecastBulan{
+			Bulan:             month.Format("2006-01"),
+			KamarTerkontrak:   contractedCount,
+			KamarTidakTerikat: uncontractedCount,
+			PengeluaranRutin:  rutin,
+			IndeksMusiman:     math.Round(index*100) / 100,
+			Best: skenario(
+				(float64(contracted)+float64(uncontracted)*occupancyBest)*rateBest,
+				float64(rutin)+variable*math.Max(0, 1-variation)),
+			Expected: skenario(
+				(float64(contracted)+float64(uncontracted)*occupancy)*rateMean,
+				float64(rutin)+variable),
+			Worst: skenario(
+				float64(contracted)*rateWorst,
+				float64(rutin)+variable*(1+variation)),
+		}
+		result = append(result, bulan)
+	}
+	return result
+}
+
+func skenario(pendapatan, pengeluaran float64) ForecastSkenario {
+	p := int(math.Round(pendapatan))
+	e := int(math.Round(pengeluaran))
+	return ForecastSkenario{Pendapatan: p, Pengeluaran: e, Net: p - e}
+}
+
+// hargaPada - Harga kamar yang berlaku pada bulan tertentu setelah perubahan terjadwal
+func hargaPada(k ForecastKamar, changes []ForecastPerubahanHarga, month time.Time) int {
+	price := k.Harga
+	var latest time.Time
+	for _, ch := range changes {
+		if ch.KamarID != k.KamarID || ch.BerlakuMulai.After(month.AddDate(0, 1, -1)) {
+			continue
+		}
+		if ch.BerlakuMulai.After(latest) || latest.IsZero() {
+			latest = ch.BerlakuMulai
+			price = ch.HargaBaru
+		}
+	}
+	return price
+}
+
+func pengeluaranRutinPada(rutin []ForecastPengeluaranRutin, month time.Time) int {
+	total := 0
+	for _, r := range rutin {
+		start := time.Date(r.Mulai.Year(), r.Mulai.Month(), 1, 0, 0, 0, 0, time.UTC)
+		if month.Before(start) || (r.Selesai != nil && month.After(*r.Selesai)) {
+			continue
+		}
+		interval := r.IntervalBulan
+		if interval < 1 {
+			interval = 1
+		}
+		diff := (month.Year()-start.Year())*12 + int(month.Month()-start.Month())
+		if diff%interval == 0 {
+			total += r.Jumlah
+		}
+	}
+	return total
+}
+
+// expensePattern - Hitung indeks musiman per bulan kalender, rata-rata pengeluaran variabel
+// (di luar pengeluaran rutin) dan koefisien variasinya dari data historis.
+func expensePattern(history map[string]int, rutin []ForecastPengeluaranRutin, now time.Time) (map[time.Month]float64, int, float64) {
+	seasonal := make(map[time.Month]float64)
+	for m := time.January; m <= time.December; m++ {
+		seasonal[m] = 1
+	}
+
+	var values []float64
+	byMonth := make(map[time.Month][]float64)
+	for key, total := range history {
+		month, err := time.Parse("2006-01", key)
+		if err != nil {
+			continue
+		}
+		variable := float64(total - pengeluaranRutinPada(rutin, month))
+		if variable < 0 {
+			variable = 0
+		}
+		values = append(values, variable)
+		byMonth[month.Month()] = append(byMonth[month.Month()], variable)
+	}
+	if len(values) == 0 {
+		return seasonal, 0, 0
+	}
+
+	mean, std := meanStd(values)
+	if mean <= 0 {
+		return seasonal, 0, 0
+	}
+	for m, list := range byMonth {
+		monthMean, _ := meanStd(list)
+		seasonal[m] = monthMean / mean
+	}
+
+	// Basis pengeluaran variabel: rata-rata 12 bulan terakhir, dinormalisasi musiman
+	var recent []float64
+	for i := 1; i <= 12; i++ {
+		month := time.Date(now.Year(), now.Month()-time.Month(i), 1, 0, 0, 0, 0, time.UTC)
+		total, ok := history[month.Format("2006-01")]
+		if !ok {
+			continue
+		}
+		variable := float64(total - pengeluaranRutinPada(rutin, month))
+		if variable < 0 {
+			variable = 0
+		}
+		if idx := seasonal[month.Month()]; idx > 0 {
+			variable /= idx
+		}
+		recent = append(recent, variable)
+	}
+	base := mean
+	if len(recent) > 0 {
+		base, _ = meanStd(recent)
+	}
+	return seasonal, int(math.Round(base)), std / mean
+}
+
+func meanStd(values []float64) (float64, float64) {
+	if len(values) == 0 {
+		return 0, 0
+	}
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+	mean := sum / float64(len(values))
+	variance := 0.0
+	for _, v := range values {
+		variance += (v - mean) * (v - mean)
+	}
+	return mean, math.Sqrt(variance / float64(len(values)))
+}
@@ -0,0 +1,111 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestForecastCashFlow(t *testing.T) {
+	bulan := func(s string) time.Time {
+		m, err := time.Parse("2006-01-02", s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return m
+	}
+	akhirJanuari := bulan("2026-01-31")
+	sk := func(pendapatan, pengeluaran int) ForecastSkenario {
+		return ForecastSkenario{Pendapatan: pendapatan, Pengeluaran: pengeluaran, Net: pendapatan - pengeluaran}
+	}
+
+	tests := []struct {
+		name string
+		in   ForecastInput
+		want []ForecastBulan
+	}{
+		{
+			// Hunian 1 dari 2 kamar yang bisa disewa (kamar perbaikan tidak dihitung):
+			// kamar kosong dihitung 50% (expected), 75% (best), 0 (worst)
+			name: "hunian tanpa histori",
+			in: ForecastInput{
+				Mulai: bulan("2026-01-01"), JumlahBulan: 1,
+				Kamar: []ForecastKamar{
+					{KamarID: 1, Harga: 1000000, Terisi: true},
+					{KamarID: 2, Harga: 1000000},
+					{KamarID: 3, Harga: 2000000, Perbaikan: true},
+				},
+			},
+			want: []ForecastBulan{{
+				Bulan: "2026-01", KamarTerkontrak: 1, KamarTidakTerikat: 1, IndeksMusiman: 1,
+				Best: sk(1750000, 0), Expected: sk(1500000, 0), Worst: sk(1000000, 0),
+			}},
+		},
+		{
+			// Tingkat penagihan 0.8 dan 1.0: rata-rata 0.9, simpangan baku 0.1
+			name: "tingkat penagihan",
+			in: ForecastInput{
+				Mulai: bulan("2026-01-01"), JumlahBulan: 1,
+				Kamar:            []ForecastKamar{{KamarID: 1, Harga: 1000000, Terisi: true}},
+				TingkatPenagihan: []float64{0.8, 1.0},
+			},
+			want: []ForecastBulan{{
+				Bulan: "2026-01", KamarTerkontrak: 1, IndeksMusiman: 1,
+				Best: sk(1000000, 0), Expected: sk(900000, 0), Worst: sk(800000, 0),
+			}},
+		},
+		{
+			// Kontrak habis akhir Januari dan harga naik mulai Februari: Februari kamar 1 tidak terikat
+			name: "kontrak berakhir dan perubahan harga",
+			in: ForecastInput{
+				Mulai: bulan("2026-01-01"), JumlahBulan: 2,
+				Kamar: []ForecastKamar{
+					{KamarID: 1, Harga: 1000000, Terisi: true, KontrakSampai: &akhirJanuari},
+					{KamarID: 2, Harga: 1000000},
+				},
+				PerubahanHarga: []ForecastPerubahanHarga{{KamarID: 1, HargaBaru: 1200000, BerlakuMulai: bulan("2026-02-01")}},
+			},
+			want: []ForecastBulan{
+				{
+					Bulan: "2026-01", KamarTerkontrak: 1, KamarTidakTerikat: 1, IndeksMusiman: 1,
+					Best: sk(1750000, 0), Expected: sk(1500000, 0), Worst: sk(1000000, 0),
+				},
+				{
+					Bulan: "2026-02", KamarTidakTerikat: 2, IndeksMusiman: 1,
+					Best: sk(1650000, 0), Expected: sk(1100000, 0), Worst: sk(0, 0),
+				},
+			},
+		},
+		{
+			// Pengeluaran variabel Januari 600rb (2024) dan 400rb (2025): rata-rata 500rb,
+			// koefisien variasi 0.2, basis 12 bulan terakhir 400rb. Pengeluaran rutin
+			// triwulanan 100rb mulai Oktober 2025 jatuh lagi di Januari 2026.
+			name: "pengeluaran rutin dan variabel",
+			in: ForecastInput{
+				Mulai: bulan("2026-01-01"), JumlahBulan: 2,
+				PengeluaranRutin: []ForecastPengeluaranRutin{
+					{Jumlah: 100000, IntervalBulan: 3, Mulai: bulan("2025-10-01")},
+				},
+				PengeluaranHistoris: map[string]int{"2024-01": 600000, "2025-01": 400000},
+			},
+			want: []ForecastBulan{
+				{
+					Bulan: "2026-01", PengeluaranRutin: 100000, IndeksMusiman: 1,
+					Best: sk(0, 420000), Expected: sk(0, 500000), Worst: sk(0, 580000),
+				},
+				{
+					Bulan: "2026-02", IndeksMusiman: 1,
+					Best: sk(0, 320000), Expected: sk(0, 400000), Worst: sk(0, 480000),
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ForecastCashFlow(tt.in)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ForecastCashFlow =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package services
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FormatRupiah - Format angka menjadi "Rp 1.500.000"
+func FormatRupiah(amount int) string {
+	negative := amount < 0
+	if negative {
+		amount = -amount
+	}
+
+	digits := strconv.Itoa(amount)
+	var b strings.Builder
+	for i, d := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			b.WriteByte('.')
+		}
+		b.WriteRune(d)
+	}
+
+	if negative {
+		return "-Rp " + b.String()
+	}
+	return "Rp " + b.String()
+}
+
+var satuan = []string{"", "satu", "dua", "tiga", "empat", "lima", "enam", "tujuh", "delapan", "sembilan", "sepuluh", "sebelas"}
+
+// Terbilang - Ubah angka menjadi kalimat bahasa Indonesia, e.g. 1500000 -> "satu juta lima ratus ribu rupiah".
+// Angka negatif (koreksi, diskon) diawali "minus".
+func Terbilang(amount int) string {
+	if amount == 0 {
+		return "nol rupiah"
+	}
+	words := ""
+	if amount < 0 {
+		// -(amount+1)+1 agar math.MinInt tidak overflow
+		words = "minus " + terbilang(uint64(-(amount+1))+1)
+	} else {
+		words = terbilang(uint64(amount))
+	}
+	return strings.Join(strings.Fields(words), " ") + " rupiah"
+}
+
+func terbilang(n uint64) string {
+	switch {
+	case n < 12:
+		return satuan[n]
+	case n < 20:
+		return terbilang(n-10) + " belas"
+	case n < 100:
+		return terbilang(n/10) + " puluh " + terbilang(n%10)
+	case n < 200:
+		return "seratus " + terbilang(n-100)
+	case n < 1000:
+		return terbilang(n/100) + " ratus " + terbilang(n%100)
+	case n < 2000:
+		return "seribu " + terbilang(n-1000)
+	case n < 1000000:
+		return terbilang(n/1000) + " ribu " + terbilang(n%1000)
+	case n < 1000000000:
+		return terbilang(n/1000000) + " juta " + terbilang(n%1000000)
+	case n < 1000000000000:
+		return terbilang(n/1000000000) + " miliar " + terbilang(n%1000000000)
+	default:
+		return terbilang(n/1000000000000) + " triliun " + terbilang(n%1000000000000)
+	}
+}
+
+var namaBulan = []string{"Januari", "Februari", "Maret", "April", "Mei", "Juni", "Juli", "Agustus", "September", "Oktober", "November", "Desember"}
+
+// NamaBulan - Ubah "2025-11" menjadi "November 2025"
+func NamaBulan(bulan string) string {
+	parts := strings.SplitN(bulan, "-", 2)
+	if len(parts) != 2 {
+		return bulan
+	}
+	m, err := strconv.Atoi(parts[1])
+	if err != nil || m < 1 || m > 12 {
+		return bulan
+	}
+	return namaBulan[m-1] + " " + parts[0]
+}
+
+// FormatTanggal - Format tanggal menjadi "02 Januari 2006"
+func FormatTanggal(t time.Time) string {
+	return fmt.Sprintf("%02d %s %d", t.Day(), namaBulan[t.Month()-1], t.Year())
+}
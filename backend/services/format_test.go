@@ -0,0 +1,29 @@
+package services
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestTerbilang(t *testing.T) {
+	cases := []struct {
+		amount int
+		want   string
+	}{
+		{0, "nol rupiah"},
+		{11, "sebelas rupiah"},
+		{1999, "seribu sembilan ratus sembilan puluh sembilan rupiah"},
+		{1500000, "satu juta lima ratus ribu rupiah"},
+		{-5, "minus lima rupiah"},
+		{-1500000, "minus satu juta lima ratus ribu rupiah"},
+	}
+	for _, tc := range cases {
+		if got := Terbilang(tc.amount); got != tc.want {
+			t.Errorf("Terbilang(%d) = %q, want %q", tc.amount, got, tc.want)
+		}
+	}
+	if got := Terbilang(math.MinInt); !strings.HasPrefix(got, "minus ") {
+		t.Errorf("Terbilang(math.MinInt) = %q, want prefix minus", got)
+	}
+}
@@ -0,0 +1,156 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// Status pembayaran yang dilaporkan gateway lewat webhook
+const (
+	PaymentPending = "pending"
+	PaymentPaid    = "paid"
+	PaymentExpired = "expired"
+	PaymentFailed  = "failed"
+)
+
+var ErrInvalidSignature = errors.New("invalid webhook signature")
+
+// PaymentRequest - Permintaan pembuatan tagihan pembayaran di gateway
+type PaymentRequest struct {
+	OrderID       string
+	Amount        int
+	Method        string // va, qris, link
+	Description   string
+	CustomerName  string
+	CustomerPhone string
+	ExpiresAt     time.Time
+}
+
+// PaymentCharge - Instruksi pembayaran dari gateway
+type PaymentCharge struct {
+	Reference  string
+	PaymentURL string
+	VANumber   string
+	QRString   string
+	ExpiresAt  time.Time
+}
+
+// PaymentNotification - Isi webhook yang sudah diverifikasi tanda tangannya
+type PaymentNotification struct {
+	OrderID   string
+	Reference string
+	Status    string
+	Amount    int
+	PaidAt    time.Time
+}
+
+// PaymentGateway - Penyedia pembayaran online (Midtrans, Xendit, dll)
+type PaymentGateway interface {
+	Name() string
+	CreatePayment(req PaymentRequest) (PaymentCharge, error)
+	// ParseWebhook memverifikasi tanda tangan callback lalu membaca isinya
+	ParseWebhook(body []byte, header http.Header) (PaymentNotification, error)
+}
+
+// NewPaymentGateway - Gateway sesuai PAYMENT_GATEWAY (saat ini hanya "fake", default).
+// Tanda tangan webhook memakai PAYMENT_WEBHOOK_SECRET.
+func NewPaymentGateway() (PaymentGateway, error) {
+	secret := os.Getenv("PAYMENT_WEBHOOK_SECRET")
+	if secret == "" {
+		return nil, errors.New("PAYMENT_WEBHOOK_SECRET is not configured")
+	}
+	switch driver := os.Getenv("PAYMENT_GATEWAY"); driver {
+	case "", "fake":
+		return FakeGateway{Secret: secret}, nil
+	default:
+		return nil, fmt.Errorf("unsupported payment gateway %q", driver)
+	}
+}
+
+// FakeGateway - Gateway tiruan untuk pengujian offline. Nomor VA dan link dibuat dari order id,
+// webhook ditandatangani HMAC-SHA256 atas body di header X-Callback-Signature.
+type FakeGateway struct {
+	Secret string
+}
+
+// fakeWebhook - Body webhook FakeGateway
+type fakeWebhook struct {
+	OrderID   string    `json:"order_id"`
+	Reference string    `json:"reference"`
+	Status    string    `json:"status"`
+	Amount    int       `json:"amount"`
+	PaidAt    time.Time `json:"paid_at"`
+}
+
+func (g FakeGateway) Name() string { return "fake" }
+
+func (g FakeGateway) CreatePayment(req PaymentRequest) (PaymentCharge, error) {
+	if req.OrderID == "" || req.Amount <= 0 {
+		return PaymentCharge{}, errors.New("order id and positive amount are required")
+	}
+	charge := PaymentCharge{
+		Reference:  "FAKE-" + req.OrderID,
+		PaymentURL: "https://fake-gateway.local/pay/" + req.OrderID,
+		ExpiresAt:  req.ExpiresAt,
+	}
+	switch req.Method {
+	case "va":
+		digits := strings.Map(func(r rune) rune {
+			if r >= '0' && r <= '9' {
+				return r
+			}
+			return -1
+		}, req.OrderID)
+		digits = fmt.Sprintf("%011s", digits)
+		charge.VANumber = "88808" + digits[len(digits)-11:]
+	case "qris":
+		charge.QRString = "FAKEQRIS|" + req.OrderID + "|" + fmt.Sprint(req.Amount)
+	}
+	return charge, nil
+}
+
+func (g FakeGateway) ParseWebhook(body []byte, header http.Header) (PaymentNotification, error) {
+	expected, err := hex.DecodeString(header.Get("X-Callback-Signature"))
+	if err != nil || !hmac.Equal(expected, g.sign(body)) {
+		return PaymentNotification{}, ErrInvalidSignature
+	}
+	var payload fakeWebhook
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return PaymentNotification{}, err
+	}
+	return PaymentNotification{
+		OrderID:   payload.OrderID,
+		Reference: payload.Reference,
+		Status:    strings.ToLower(payload.Status),
+		Amount:    payload.Amount,
+		PaidAt:    payload.PaidAt,
+	}, nil
+}
+
+// Simulate - Body dan header webhook bertanda tangan, seolah dikirim gateway
+func (g FakeGateway) Simulate(orderID, status string, amount int) ([]byte, http.Header) {
+	body, _ := json.Marshal(fakeWebhook{
+		OrderID:   orderID,
+		Reference: "FAKE-" + orderID,
+		Status:    status,
+		Amount:    amount,
+		PaidAt:    time.Now(),
+	})
+	header := http.Header{}
+	header.Set("X-Callback-Signature", hex.EncodeToString(g.sign(body)))
+	return body, header
+}
+
+func (g FakeGateway) sign(body []byte) []byte {
+	mac := hmac.New(sha256.New, []byte(g.Secret))
+	mac.Write(body)
+	return mac.Sum(nil)
+}
@@ -0,0 +1,98 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// qrisField - Satu data object TLV (ID 2 digit, panjang 2 digit, nilai) pada payload EMVCo
+type qrisField struct {
+	ID    string
+	Value string
+}
+
+// ValidateQRIS - Cek payload QRIS merchant: format TLV EMVCo dan CRC pada tag 63
+func ValidateQRIS(payload string) error {
+	payload = strings.TrimSpace(payload)
+	fields, err := parseQRIS(payload)
+	if err != nil {
+		return err
+	}
+	if len(fields) == 0 || fields[0].ID != "00" || fields[0].Value != "01" {
+		return errors.New("QRIS harus diawali Payload Format Indicator 000201")
+	}
+	last := fields[len(fields)-1]
+	if last.ID != "63" || len(last.Value) != 4 {
+		return errors.New("QRIS tidak memiliki CRC (tag 63)")
+	}
+	if crc := qrisCRC(payload[:len(payload)-4]); !strings.EqualFold(crc, last.Value) {
+		return fmt.Errorf("CRC QRIS tidak valid (seharusnya %s)", crc)
+	}
+	return nil
+}
+
+// QRISDinamis - Ubah payload QRIS statis menjadi QRIS dengan nominal: Point of Initiation
+// menjadi 12 (dinamis), tag 54 diisi nominal, lalu CRC dihitung ulang.
+func QRISDinamis(statis string, amount int) (string, error) {
+	if amount <= 0 {
+		return "", errors.New("nominal QRIS harus lebih dari nol")
+	}
+	statis = strings.TrimSpace(statis)
+	if err := ValidateQRIS(statis); err != nil {
+		return "", err
+	}
+	fields, _ := parseQRIS(statis)
+
+	var result []qrisField
+	for _, f := range fields {
+		switch f.ID {
+		case "01", "54", "63":
+			continue
+		}
+		result = append(result, f)
+	}
+	result = append(result, qrisField{ID: "01", Value: "12"}, qrisField{ID: "54", Value: strconv.Itoa(amount)})
+	sort.SliceStable(result, func(i, j int) bool { return result[i].ID < result[j].ID })
+
+	var b strings.Builder
+	for _, f := range result {
+		fmt.Fprintf(&b, "%s%02d%s", f.ID, len(f.Value), f.Value)
+	}
+	b.WriteString("6304")
+	return b.String() + qrisCRC(b.String()), nil
+}
+
+func parseQRIS(payload string) ([]qrisField, error) {
+	var fields []qrisField
+	for i := 0; i < len(payload); {
+		if i+4 > len(payload) {
+			return nil, errors.New("format QRIS tidak valid")
+		}
+		length, err := strconv.Atoi(payload[i+2 : i+4])
+		if err != nil || i+4+length > len(payload) {
+			return nil, errors.New("format QRIS tidak valid")
+		}
+		fields = append(fields, qrisField{ID: payload[i : i+2], Value: payload[i+4 : i+4+length]})
+		i += 4 + length
+	}
+	return fields, nil
+}
+
+// qrisCRC - CRC-16/CCITT-FALSE (poly 0x1021, init 0xFFFF) sesuai spesifikasi EMVCo
+func qrisCRC(data string) string {
+	crc := uint16(0xFFFF)
+	for i := 0; i < len(data); i++ {
+		crc ^= uint16(data[i]) << 8
+		for bit := 0; bit < 8; bit++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return fmt.Sprintf("%04X", crc)
+}
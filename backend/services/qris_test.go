@@ -0,0 +1,104 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestQRISCRC(t *testing.T) {
+	tests := []struct {
+		data string
+		want string
+	}{
+		{"", "FFFF"},
+		{"A", "B915"},
+		{"123456789", "29B1"},
+	}
+	for _, tt := range tests {
+		if got := qrisCRC(tt.data); got != tt.want {
+			t.Errorf("qrisCRC(%q) = %s, want %s", tt.data, got, tt.want)
+		}
+	}
+}
+
+// qrisContoh - Payload QRIS dari pasangan tag/nilai, ditutup CRC tag 63
+func qrisContoh(fields ...string) string {
+	var b strings.Builder
+	for i := 0; i+1 < len(fields); i += 2 {
+		fmt.Fprintf(&b, "%s%02d%s", fields[i], len(fields[i+1]), fields[i+1])
+	}
+	b.WriteString("6304")
+	return b.String() + qrisCRC(b.String())
+}
+
+func TestQRISDinamis(t *testing.T) {
+	merchant := []string{
+		"26", "0016ID.CO.QRIS.WWW0215ID10200000001",
+		"52", "7011", "53", "360", "58", "ID", "59", "KOS MUHANDIS", "60", "BANDUNG",
+	}
+	tests := []struct {
+		name   string
+		statis string
+	}{
+		{"statis", qrisContoh(append([]string{"00", "01", "01", "11"}, merchant...)...)},
+		{"sudah ada nominal", qrisContoh(
+			"00", "01", "01", "12", "26", merchant[1], "52", "7011", "53", "360", "54", "5000",
+			"58", "ID", "59", "KOS MUHANDIS", "60", "BANDUNG",
+		)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateQRIS(tt.statis); err != nil {
+				t.Fatal("contoh tidak valid:", err)
+			}
+			got, err := QRISDinamis(tt.statis, 150123)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := ValidateQRIS(got); err != nil {
+				t.Errorf("hasil tidak valid: %v", err)
+			}
+			if crc := qrisCRC(got[:len(got)-4]); got[len(got)-4:] != crc {
+				t.Errorf("CRC = %s, want %s", got[len(got)-4:], crc)
+			}
+
+			fields, err := parseQRIS(got)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var ids []string
+			values := map[string]string{}
+			for _, f := range fields {
+				ids = append(ids, f.ID)
+				values[f.ID] = f.Value
+			}
+			if want := "00 01 26 52 53 54 58 59 60 63"; strings.Join(ids, " ") != want {
+				t.Errorf("urutan tag = %s, want %s", strings.Join(ids, " "), want)
+			}
+			if values["01"] != "12" {
+				t.Errorf("tag 01 = %q, want 12", values["01"])
+			}
+			if values["54"] != "150123" {
+				t.Errorf("tag 54 = %q, want 150123", values["54"])
+			}
+			if values["26"] != merchant[1] || values["59"] != "KOS MUHANDIS" {
+				t.Errorf("data merchant berubah: %v", values)
+			}
+		})
+	}
+}
+
+func TestQRISDinamisTolak(t *testing.T) {
+	statis := qrisContoh("00", "01", "01", "11", "53", "360", "58", "ID")
+	if _, err := QRISDinamis(statis, 0); err == nil {
+		t.Error("nominal 0 diterima")
+	}
+	rusak := statis[:len(statis)-4] + "0000"
+	if _, err := QRISDinamis(rusak, 1000); err == nil || !strings.Contains(err.Error(), "CRC") {
+		t.Errorf("CRC salah: err = %v", err)
+	}
+	if _, err := QRISDinamis("0102", 1000); err == nil {
+		t.Error("payload rusak diterima")
+	}
+}
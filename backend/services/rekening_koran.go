@@ -0,0 +1,104 @@
+package services
+
+// RekeningKoranBaris - Satu mutasi pada rekening koran penyewa
+type RekeningKoranBaris struct {
+	Tanggal    string // "02 Januari 2006"
+	Keterangan string
+	Debit      int // menambah kewajiban penyewa (tagihan, denda, koreksi)
+	Kredit     int // mengurangi kewajiban penyewa (pembayaran, kredit)
+	Saldo      int
+}
+
+// RekeningKoran - Data rekening koran penyewa untuk satu periode
+type RekeningKoran struct {
+	NamaKos     string
+	AlamatKos   string
+	Tanggal     string
+	NamaPenyewa string
+	NoHP        string
+	NamaKamar   string
+	Periode     string // "01 Januari 2025 - 31 Maret 2025"
+	SaldoAwal   int
+	TotalDebit  int
+	TotalKredit int
+	SaldoAkhir  int
+	Baris       []RekeningKoranBaris
+}
+
+// RenderRekeningKoranPDF - Cetak rekening koran penyewa, berlanjut ke halaman baru bila perlu
+func RenderRekeningKoranPDF(r RekeningKoran) []byte {
+	pdf := NewPDFDocument()
+	pdf.Text(50, 60, 18, true, r.NamaKos)
+	if r.AlamatKos != "" {
+		pdf.Text(50, 78, 9, false, r.AlamatKos)
+	}
+	pdf.TextRight(545, 60, 16, true, "REKENING KORAN")
+	pdf.TextRight(545, 78, 9, false, "Tanggal "+r.Tanggal)
+	pdf.Line(50, 110, 545, 110)
+
+	pdf.Text(50, 130, 10, true, "Penyewa")
+	pdf.Text(120, 130, 10, false, ": "+r.NamaPenyewa)
+	pdf.Text(50, 145, 10, true, "Kamar")
+	pdf.Text(120, 145, 10, false, ": "+r.NamaKamar)
+	if r.NoHP != "" {
+		pdf.Text(50, 160, 10, true, "No HP")
+		pdf.Text(120, 160, 10, false, ": "+r.NoHP)
+	}
+	pdf.Text(300, 130, 10, true, "Periode")
+	pdf.Text(350, 130, 10, false, ": "+r.Periode)
+
+	y := rekeningKoranHeader(pdf, 180)
+	y += rekeningKoranRow(pdf, y, "", "Saldo Awal", 0, 0, r.SaldoAwal, true)
+	for _, b := range r.Baris {
+		// Keterangan panjang dibungkus beberapa baris; pindah halaman bila baris terakhirnya tidak muat
+		if y+float64(len(WrapText(b.Keterangan, 9, 180))-1)*rekeningKoranSpasi > 780 {
+			pdf.AddPage()
+			y = rekeningKoranHeader(pdf, 50)
+		}
+		y += rekeningKoranRow(pdf, y, b.Tanggal, b.Keterangan, b.Debit, b.Kredit, b.Saldo, false)
+	}
+	if y > 760 {
+		pdf.AddPage()
+		y = rekeningKoranHeader(pdf, 50)
+	}
+	pdf.Line(50, y-7, 545, y-7)
+	rekeningKoranRow(pdf, y+5, "", "Saldo Akhir", r.TotalDebit, r.TotalKredit, r.SaldoAkhir, true)
+
+	keterangan := "Saldo positif berarti masih ada kewajiban yang belum dibayar."
+	if r.SaldoAkhir < 0 {
+		keterangan = "Saldo negatif berarti penyewa memiliki kelebihan bayar / kredit."
+	}
+	pdf.Text(50, y+30, 9, false, keterangan)
+	return pdf.Bytes()
+}
+
+func rekeningKoranHeader(pdf *PDFDocument, y float64) float64 {
+	pdf.Line(50, y, 545, y)
+	pdf.Text(55, y+15, 9, true, "Tanggal")
+	pdf.Text(140, y+15, 9, true, "Keterangan")
+	pdf.TextRight(395, y+15, 9, true, "Debit")
+	pdf.TextRight(470, y+15, 9, true, "Kredit")
+	pdf.TextRight(540, y+15, 9, true, "Saldo")
+	pdf.Line(50, y+22, 545, y+22)
+	return y + 37
+}
+
+// rekeningKoranSpasi - Jarak antar baris keterangan yang dibungkus
+const rekeningKoranSpasi = 11
+
+// rekeningKoranRow - Cetak satu mutasi dengan seluruh baris keterangannya, mengembalikan tinggi baris
+func rekeningKoranRow(pdf *PDFDocument, y float64, tanggal, keterangan string, debit, kredit, saldo int, bold bool) float64 {
+	pdf.Text(55, y, 9, bold, tanggal)
+	lines := WrapText(keterangan, 9, 180)
+	for i, line := range lines {
+		pdf.Text(140, y+float64(i)*rekeningKoranSpasi, 9, bold, line)
+	}
+	if debit != 0 {
+		pdf.TextRight(395, y, 9, bold, FormatRupiah(debit))
+	}
+	if kredit != 0 {
+		pdf.TextRight(470, y, 9, bold, FormatRupiah(kredit))
+	}
+	pdf.TextRight(540, y, 9, bold, FormatRupiah(saldo))
+	return 15 + float64(max(len(lines)-1, 0))*rekeningKoranSpasi
+}
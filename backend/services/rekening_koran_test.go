@@ -0,0 +1,33 @@
+package services
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestRenderRekeningKoranPDFWrapsKeterangan(t *testing.T) {
+	keterangan := "Pembayaran tagihan sewa bulan Januari 2025 melalui transfer bank dengan catatan pelunasan sisa cicilan akhir"
+	r := RekeningKoran{NamaKos: "Kos", Baris: []RekeningKoranBaris{{Tanggal: "02 Januari 2025", Keterangan: keterangan, Kredit: 1000}}}
+	lines := WrapText(keterangan, 9, 180)
+	if len(lines) < 2 {
+		t.Fatalf("keterangan should wrap, got %q", lines)
+	}
+	out := RenderRekeningKoranPDF(r)
+	for _, line := range lines {
+		if !bytes.Contains(out, []byte("("+pdfEscape(line)+")")) {
+			t.Errorf("PDF missing wrapped line %q", line)
+		}
+	}
+}
+
+func TestRenderRekeningKoranPDFPageBreak(t *testing.T) {
+	baris := make([]RekeningKoranBaris, 60)
+	for i := range baris {
+		baris[i] = RekeningKoranBaris{Tanggal: "02 Januari 2025", Keterangan: strings.Repeat("keterangan panjang ", 5), Debit: 1000}
+	}
+	out := RenderRekeningKoranPDF(RekeningKoran{NamaKos: "Kos", Baris: baris})
+	if got := bytes.Count(out, []byte("/Type /Page /Parent")); got < 3 {
+		t.Errorf("pages = %d, want at least 3 for 60 wrapped rows", got)
+	}
+}
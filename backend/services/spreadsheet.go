@@ -0,0 +1,268 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"io"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ReadSpreadsheet - Baca file CSV atau XLSX (sheet pertama) menjadi baris-baris string.
+// Baris pertama adalah header.
+func ReadSpreadsheet(filename string, data []byte) ([][]string, error) {
+	switch strings.ToLower(path.Ext(filename)) {
+	case ".csv":
+		return readCSV(data)
+	case ".xlsx":
+		return readXLSX(data)
+	default:
+		return nil, errors.New("file must be .csv or .xlsx")
+	}
+}
+
+func readCSV(data []byte) ([][]string, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	// Export dari Excel lokal Indonesia sering memakai titik koma
+	firstLine, _, _ := bytes.Cut(data, []byte("\n"))
+	if bytes.Count(firstLine, []byte(";")) > bytes.Count(firstLine, []byte(",")) {
+		reader.Comma = ';'
+	}
+	return reader.ReadAll()
+}
+
+type xlsxSharedStrings struct {
+	Items []struct {
+		Text string `xml:"t"`
+		Runs []struct {
+			Text string `xml:"t"`
+		} `xml:"r"`
+	} `xml:"si"`
+}
+
+type xlsxSheet struct {
+	Rows []struct {
+		Cells []struct {
+			Ref    string `xml:"r,attr"`
+			Type   string `xml:"t,attr"`
+			Style  int    `xml:"s,attr"`
+			Value  string `xml:"v"`
+			Inline struct {
+				Text string `xml:"t"`
+			} `xml:"is"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+type xlsxStyles struct {
+	NumFmts []struct {
+		ID   int    `xml:"numFmtId,attr"`
+		Code string `xml:"formatCode,attr"`
+	} `xml:"numFmts>numFmt"`
+	CellXfs []struct {
+		NumFmtID int `xml:"numFmtId,attr"`
+	} `xml:"cellXfs>xf"`
+}
+
+type xlsxWorkbook struct {
+	Sheets []struct {
+		RelID string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+type xlsxRelationships struct {
+	Relationships []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+func readXLSX(data []byte) ([][]string, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, errors.New("invalid xlsx file")
+	}
+	files := make(map[string]*zip.File)
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+
+	var shared []string
+	if f, ok := files["xl/sharedStrings.xml"]; ok {
+		var sst xlsxSharedStrings
+		if err := decodeZipXML(f, &sst); err != nil {
+			return nil, err
+		}
+		for _, item := range sst.Items {
+			text := item.Text
+			for _, run := range item.Runs {
+				text += run.Text
+			}
+			shared = append(shared, text)
+		}
+	}
+
+	dateStyles, err := xlsxDateStyles(files)
+	if err != nil {
+		return nil, err
+	}
+
+	sheetPath, err := firstSheetPath(files)
+	if err != nil {
+		return nil, err
+	}
+	var sheet xlsxSheet
+	if err := decodeZipXML(files[sheetPath], &sheet); err != nil {
+		return nil, err
+	}
+
+	var rows [][]string
+	for _, row := range sheet.Rows {
+		var values []string
+		for i, cell := range row.Cells {
+			col := i
+			if cell.Ref != "" {
+				col = xlsxColumnIndex(cell.Ref)
+			}
+			for len(values) <= col {
+				values = append(values, "")
+			}
+			switch cell.Type {
+			case "s":
+				idx, err := strconv.Atoi(cell.Value)
+				if err == nil && idx < len(shared) {
+					values[col] = shared[idx]
+				}
+			case "inlineStr":
+				values[col] = cell.Inline.Text
+			case "", "n":
+				values[col] = cell.Value
+				// Sel angka berformat tanggal disimpan sebagai serial date Excel
+				if dateStyles[cell.Style] {
+					if t, ok := xlsxSerialDate(cell.Value); ok {
+						values[col] = t.Format("2006-01-02")
+					}
+				}
+			default:
+				values[col] = cell.Value
+			}
+		}
+		rows = append(rows, values)
+	}
+	return rows, nil
+}
+
+// xlsxDateStyles - Index cellXfs yang memakai format tanggal (bawaan atau format kustom d/m/y)
+func xlsxDateStyles(files map[string]*zip.File) (map[int]bool, error) {
+	dateStyles := make(map[int]bool)
+	f, ok := files["xl/styles.xml"]
+	if !ok {
+		return dateStyles, nil
+	}
+	var styles xlsxStyles
+	if err := decodeZipXML(f, &styles); err != nil {
+		return nil, err
+	}
+	custom := make(map[int]bool)
+	for _, nf := range styles.NumFmts {
+		custom[nf.ID] = isDateFormat(nf.Code)
+	}
+	for i, xf := range styles.CellXfs {
+		id := xf.NumFmtID
+		if (id >= 14 && id <= 22) || (id >= 27 && id <= 36) || (id >= 45 && id <= 47) || (id >= 50 && id <= 58) || custom[id] {
+			dateStyles[i] = true
+		}
+	}
+	return dateStyles, nil
+}
+
+// formatLiteral - Teks dalam tanda kutip, [warna/kondisi] dan karakter escape pada format angka
+var formatLiteral = regexp.MustCompile(`"[^"]*"|\[[^\]]*\]|\\.`)
+
+func isDateFormat(code string) bool {
+	code = strings.ToLower(formatLiteral.ReplaceAllString(code, ""))
+	return strings.ContainsAny(code, "dy")
+}
+
+// xlsxSerialDate - Serial date Excel (sistem 1900) ke tanggal; hanya tahun 1950-2199 yang dianggap wajar,
+// sehingga angka seperti 2025 tidak menjadi tanggal tahun 1905
+func xlsxSerialDate(value string) (time.Time, bool) {
+	serial, err := strconv.ParseFloat(value, 64)
+	if err != nil || serial < 18264 || serial >= 109575 {
+		return time.Time{}, false
+	}
+	return time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC).AddDate(0, 0, int(serial)), true
+}
+
+func firstSheetPath(files map[string]*zip.File) (string, error) {
+	const fallback = "xl/worksheets/sheet1.xml"
+	wbFile, ok := files["xl/workbook.xml"]
+	relsFile, relsOk := files["xl/_rels/workbook.xml.rels"]
+	if !ok || !relsOk {
+		if _, ok := files[fallback]; ok {
+			return fallback, nil
+		}
+		return "", errors.New("xlsx file has no worksheet")
+	}
+
+	var wb xlsxWorkbook
+	var rels xlsxRelationships
+	if err := decodeZipXML(wbFile, &wb); err != nil {
+		return "", err
+	}
+	if err := decodeZipXML(relsFile, &rels); err != nil {
+		return "", err
+	}
+	if len(wb.Sheets) > 0 {
+		for _, rel := range rels.Relationships {
+			if rel.ID == wb.Sheets[0].RelID {
+				target := strings.TrimPrefix(rel.Target, "/")
+				if !strings.HasPrefix(target, "xl/") {
+					target = "xl/" + target
+				}
+				if _, ok := files[target]; ok {
+					return target, nil
+				}
+			}
+		}
+	}
+	if _, ok := files[fallback]; ok {
+		return fallback, nil
+	}
+	return "", errors.New("xlsx file has no worksheet")
+}
+
+func decodeZipXML(f *zip.File, v interface{}) error {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	content, err := io.ReadAll(rc)
+	if err != nil {
+		return err
+	}
+	return xml.Unmarshal(content, v)
+}
+
+// xlsxColumnIndex - "B12" -> 1
+func xlsxColumnIndex(ref string) int {
+	col := 0
+	for _, r := range ref {
+		if r < 'A' || r > 'Z' {
+			break
+		}
+		col = col*26 + int(r-'A'+1)
+	}
+	return col - 1
+}
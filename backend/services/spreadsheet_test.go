@@ -0,0 +1,34 @@
+package services
+
+import "testing"
+
+func TestIsDateFormat(t *testing.T) {
+	cases := map[string]bool{
+		"dd/mm/yyyy":         true,
+		"[$-421]d mmmm yyyy": true,
+		"yyyy-mm-dd h:mm":    true,
+		"#,##0":              false,
+		"[Red]#,##0.00":      false,
+		`"Day" 0`:            false,
+		"hh:mm":              false,
+	}
+	for code, want := range cases {
+		if got := isDateFormat(code); got != want {
+			t.Errorf("isDateFormat(%q) = %v, want %v", code, got, want)
+		}
+	}
+}
+
+func TestXLSXSerialDate(t *testing.T) {
+	if got, ok := xlsxSerialDate("45658"); !ok || got.Format("2006-01-02") != "2025-01-01" {
+		t.Errorf("xlsxSerialDate(45658) = %v, %v; want 2025-01-01", got, ok)
+	}
+	if got, ok := xlsxSerialDate("45658.75"); !ok || got.Format("2006-01-02") != "2025-01-01" {
+		t.Errorf("xlsxSerialDate(45658.75) = %v, %v; want 2025-01-01", got, ok)
+	}
+	for _, value := range []string{"2025", "0", "200000", "abc"} {
+		if got, ok := xlsxSerialDate(value); ok {
+			t.Errorf("xlsxSerialDate(%q) = %v, want rejected", value, got)
+		}
+	}
+}
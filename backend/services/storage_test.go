@@ -0,0 +1,75 @@
+package services
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLocalStorageRoundTrip(t *testing.T) {
+	s := LocalStorage{Dir: t.TempDir()}
+	key := "perbaikan/7/123.jpg"
+	data := []byte("isi foto")
+
+	if err := s.Put(key, data, "image/jpeg"); err != nil {
+		t.Fatal("put:", err)
+	}
+	if _, err := os.Stat(filepath.Join(s.Dir, "perbaikan", "7", "123.jpg")); err != nil {
+		t.Fatal("file not written under Dir:", err)
+	}
+	got, err := s.Get(key)
+	if err != nil {
+		t.Fatal("get:", err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Errorf("get = %q, want %q", got, data)
+	}
+
+	if err := s.Delete(key); err != nil {
+		t.Fatal("delete:", err)
+	}
+	if _, err := s.Get(key); !os.IsNotExist(err) {
+		t.Errorf("get after delete: err = %v, want not exist", err)
+	}
+	if err := s.Delete(key); err != nil {
+		t.Errorf("delete missing key: %v", err)
+	}
+}
+
+func TestLocalStorageKeySanitisation(t *testing.T) {
+	root := t.TempDir()
+	s := LocalStorage{Dir: filepath.Join(root, "uploads")}
+
+	for _, key := range []string{"../rahasia.txt", "bukti/../../rahasia.txt", "bukti/1/..", "..", "", "/", "."} {
+		if err := s.Put(key, []byte("x"), "text/plain"); err == nil {
+			t.Errorf("put %q: expected error", key)
+		}
+		if _, err := s.Get(key); err == nil {
+			t.Errorf("get %q: expected error", key)
+		}
+		if err := s.Delete(key); err == nil {
+			t.Errorf("delete %q: expected error", key)
+		}
+	}
+	if entries, _ := os.ReadDir(root); len(entries) != 0 {
+		t.Errorf("files written outside Dir: %v", entries)
+	}
+
+	// Key absolut tetap diletakkan di bawah Dir
+	if err := s.Put("/bukti/1/a.png", []byte("x"), "image/png"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(s.Dir, "bukti", "1", "a.png")); err != nil {
+		t.Errorf("absolute key not stored under Dir: %v", err)
+	}
+}
+
+func TestNewStorageLocal(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("STORAGE_DRIVER", "")
+	t.Setenv("UPLOAD_DIR", dir)
+	if s, ok := NewStorage().(LocalStorage); !ok || s.Dir != dir {
+		t.Errorf("NewStorage() = %#v, want LocalStorage{Dir: %q}", NewStorage(), dir)
+	}
+}
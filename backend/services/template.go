@@ -0,0 +1,67 @@
+package services
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// VariabelTemplate - Variabel yang bisa dipakai di template pesan, ditulis {{nama}}
+var VariabelTemplate = []string{
+	"nama", "kamar", "bulan", "jenis", "jumlah", "jumlah_rupiah", "terbayar", "sisa", "jatuh_tempo", "link_bayar",
+	"daftar_tagihan", "total_sisa", "hari_terlambat", "kontak_darurat", "alasan", "jumlah_bukti", "dokumen", "jumlah_tagihan",
+	"pembayaran_terakhir",
+}
+
+var templateVarRe = regexp.MustCompile(`\{\{\s*([a-zA-Z_]+)\s*\}\}`)
+
+var monthNames = []string{"January", "February", "March", "April", "May", "June", "July", "August", "September", "October", "November", "December"}
+
+// RenderTemplate - Ganti {{variabel}} dengan nilainya; variabel tanpa nilai menjadi kosong
+func RenderTemplate(isi string, data map[string]string) string {
+	return templateVarRe.ReplaceAllStringFunc(isi, func(m string) string {
+		return data[strings.ToLower(templateVarRe.FindStringSubmatch(m)[1])]
+	})
+}
+
+// VariabelTidakDikenal - Variabel di template yang tidak ada di VariabelTemplate
+func VariabelTidakDikenal(isi string) []string {
+	dikenal := make(map[string]bool, len(VariabelTemplate))
+	for _, v := range VariabelTemplate {
+		dikenal[v] = true
+	}
+	var asing []string
+	for _, m := range templateVarRe.FindAllStringSubmatch(isi, -1) {
+		if name := strings.ToLower(m[1]); !dikenal[name] {
+			asing = append(asing, name)
+			dikenal[name] = true
+		}
+	}
+	return asing
+}
+
+// NamaBulanBahasa - NamaBulan dalam bahasa "id" atau "en" ("2025-11" -> "November 2025")
+func NamaBulanBahasa(bulan, bahasa string) string {
+	if bahasa != "en" {
+		return NamaBulan(bulan)
+	}
+	parts := strings.SplitN(bulan, "-", 2)
+	if len(parts) != 2 {
+		return bulan
+	}
+	m, err := strconv.Atoi(parts[1])
+	if err != nil || m < 1 || m > 12 {
+		return bulan
+	}
+	return monthNames[m-1] + " " + parts[0]
+}
+
+// FormatTanggalBahasa - FormatTanggal dalam bahasa "id" atau "en" ("02 January 2006")
+func FormatTanggalBahasa(t time.Time, bahasa string) string {
+	if bahasa != "en" {
+		return FormatTanggal(t)
+	}
+	return fmt.Sprintf("%02d %s %d", t.Day(), monthNames[t.Month()-1], t.Year())
+}
@@ -0,0 +1,199 @@
+package services
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/crypto/hkdf"
+)
+
+// ErrSubscriptionGone - Push service menolak subscription (404/410); subscription harus dihapus
+var ErrSubscriptionGone = errors.New("push subscription is no longer valid")
+
+// PushSubscription - Format PushSubscription.toJSON() dari browser
+type PushSubscription struct {
+	Endpoint string `json:"endpoint"`
+	Keys     struct {
+		P256dh string `json:"p256dh"`
+		Auth   string `json:"auth"`
+	} `json:"keys"`
+}
+
+// WebPushChannel - Web Push (RFC 8030) dengan payload terenkripsi aes128gcm (RFC 8291)
+// dan autentikasi VAPID (RFC 8292). Kunci VAPID dalam base64url: publik 65 byte
+// (uncompressed P-256), privat 32 byte.
+type WebPushChannel struct {
+	PublicKey  string
+	PrivateKey *ecdsa.PrivateKey
+	Subject    string // mailto: atau https: kontak pengirim
+}
+
+// NewWebPushChannel - WebPushChannel dari VAPID_PUBLIC_KEY, VAPID_PRIVATE_KEY, VAPID_SUBJECT
+func NewWebPushChannel() (Channel, error) {
+	public := os.Getenv("VAPID_PUBLIC_KEY")
+	private := os.Getenv("VAPID_PRIVATE_KEY")
+	if public == "" || private == "" {
+		return nil, ErrChannelNotConfigured
+	}
+	pub, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(public, "="))
+	if err != nil || len(pub) != 65 || pub[0] != 4 {
+		return nil, errors.New("invalid VAPID_PUBLIC_KEY")
+	}
+	d, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(private, "="))
+	if err != nil || len(d) != 32 {
+		return nil, errors.New("invalid VAPID_PRIVATE_KEY")
+	}
+	key := &ecdsa.PrivateKey{
+		PublicKey: ecdsa.PublicKey{
+			Curve: elliptic.P256(),
+			X:     new(big.Int).SetBytes(pub[1:33]),
+			Y:     new(big.Int).SetBytes(pub[33:]),
+		},
+		D: new(big.Int).SetBytes(d),
+	}
+	subject := os.Getenv("VAPID_SUBJECT")
+	if subject == "" {
+		subject = "mailto:admin@localhost"
+	}
+	return WebPushChannel{PublicKey: base64.RawURLEncoding.EncodeToString(pub), PrivateKey: key, Subject: subject}, nil
+}
+
+func (ch WebPushChannel) Name() string { return "push" }
+
+func (ch WebPushChannel) Send(to string, msg NotificationMessage) (string, error) {
+	var sub PushSubscription
+	if err := json.Unmarshal([]byte(to), &sub); err != nil || sub.Endpoint == "" {
+		return "", errors.New("invalid push subscription")
+	}
+	endpoint, err := url.Parse(sub.Endpoint)
+	if err != nil || endpoint.Scheme != "https" {
+		return "", errors.New("invalid push endpoint")
+	}
+
+	payload, _ := json.Marshal(map[string]string{"title": msg.Subject, "body": msg.Body, "url": msg.URL, "receipt_url": msg.ReceiptURL})
+	body, err := encryptPushPayload(sub, payload)
+	if err != nil {
+		return "", err
+	}
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{
+		"aud": endpoint.Scheme + "://" + endpoint.Host,
+		"exp": time.Now().Add(12 * time.Hour).Unix(),
+		"sub": ch.Subject,
+	}).SignedString(ch.PrivateKey)
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, sub.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("Content-Encoding", "aes128gcm")
+	req.Header.Set("TTL", "86400")
+	req.Header.Set("Urgency", "normal")
+	req.Header.Set("Authorization", fmt.Sprintf("vapid t=%s, k=%s", token, ch.PublicKey))
+	resp, err := (&http.Client{Timeout: 30 * time.Second}).Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<16))
+	switch {
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone:
+		return "", ErrSubscriptionGone
+	case resp.StatusCode/100 != 2:
+		return "", fmt.Errorf("push service: %s: %s", resp.Status, strings.TrimSpace(string(respBody)))
+	}
+	return resp.Header.Get("Location"), nil
+}
+
+// encryptPushPayload - Enkripsi aes128gcm satu record (RFC 8291 bagian 3.4 dan RFC 8188)
+// dengan kunci server sementara dan salt acak
+func encryptPushPayload(sub PushSubscription, plaintext []byte) ([]byte, error) {
+	asKey, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	return encryptPushPayloadWith(sub, plaintext, asKey, salt)
+}
+
+// encryptPushPayloadWith - encryptPushPayload dengan kunci server dan salt yang ditentukan
+func encryptPushPayloadWith(sub PushSubscription, plaintext []byte, asKey *ecdh.PrivateKey, salt []byte) ([]byte, error) {
+	uaPublic, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(sub.Keys.P256dh, "="))
+	if err != nil {
+		return nil, errors.New("invalid p256dh key")
+	}
+	authSecret, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(sub.Keys.Auth, "="))
+	if err != nil || len(authSecret) == 0 {
+		return nil, errors.New("invalid auth secret")
+	}
+	uaKey, err := ecdh.P256().NewPublicKey(uaPublic)
+	if err != nil {
+		return nil, errors.New("invalid p256dh key")
+	}
+	shared, err := asKey.ECDH(uaKey)
+	if err != nil {
+		return nil, err
+	}
+	asPublic := asKey.PublicKey().Bytes()
+
+	keyInfo := append([]byte("WebPush: info\x00"), uaPublic...)
+	keyInfo = append(keyInfo, asPublic...)
+	ikm := make([]byte, 32)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, shared, authSecret, keyInfo), ikm); err != nil {
+		return nil, err
+	}
+
+	prk := hkdf.Extract(sha256.New, ikm, salt)
+	cek := make([]byte, 16)
+	if _, err := io.ReadFull(hkdf.Expand(sha256.New, prk, []byte("Content-Encoding: aes128gcm\x00")), cek); err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, 12)
+	if _, err := io.ReadFull(hkdf.Expand(sha256.New, prk, []byte("Content-Encoding: nonce\x00")), nonce); err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	// Satu-satunya record sekaligus record terakhir: delimiter 0x02
+	record := gcm.Seal(nil, nonce, append(plaintext, 0x02), nil)
+
+	header := make([]byte, 0, 16+4+1+len(asPublic))
+	header = append(header, salt...)
+	header = binary.BigEndian.AppendUint32(header, 4096)
+	header = append(header, byte(len(asPublic)))
+	header = append(header, asPublic...)
+	return append(header, record...), nil
+}
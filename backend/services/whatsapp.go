@@ -0,0 +1,286 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// InboundMessage - Pesan WhatsApp masuk yang sudah diverifikasi tanda tangannya
+type InboundMessage struct {
+	MessageID  string
+	From       string // nomor pengirim, tanpa awalan "whatsapp:"
+	Body       string
+	ReceivedAt time.Time
+}
+
+// WhatsAppProvider - Penyedia WhatsApp yang mengirim webhook pesan masuk dan status
+// pengiriman (Twilio, dll)
+type WhatsAppProvider interface {
+	Name() string
+	// ParseInbound memverifikasi tanda tangan webhook lalu membaca pesannya.
+	// webhookURL adalah URL publik lengkap yang dipanggil provider.
+	ParseInbound(webhookURL string, body []byte, header http.Header) (InboundMessage, error)
+	// ParseStatus memverifikasi dan membaca callback status pesan keluar
+	ParseStatus(webhookURL string, body []byte, header http.Header) (DeliveryStatus, error)
+}
+
+// NewWhatsAppProvider - Provider sesuai WHATSAPP_PROVIDER: "fake" (default, kunci
+// WHATSAPP_WEBHOOK_SECRET) atau "twilio" (kunci TWILIO_AUTH_TOKEN).
+func NewWhatsAppProvider() (WhatsAppProvider, error) {
+	switch provider := os.Getenv("WHATSAPP_PROVIDER"); provider {
+	case "", "fake":
+		secret := os.Getenv("WHATSAPP_WEBHOOK_SECRET")
+		if secret == "" {
+			return nil, errors.New("WHATSAPP_WEBHOOK_SECRET is not configured")
+		}
+		return FakeWhatsApp{Secret: secret}, nil
+	case "twilio":
+		return NewTwilioWhatsApp()
+	default:
+		return nil, fmt.Errorf("unsupported WhatsApp provider %q", provider)
+	}
+}
+
+// FakeWhatsApp - Provider tiruan untuk pengujian offline. Body JSON ditandatangani
+// HMAC-SHA256 di header X-Webhook-Signature.
+type FakeWhatsApp struct {
+	Secret string
+}
+
+// fakeInbound - Body webhook FakeWhatsApp
+type fakeInbound struct {
+	MessageID string    `json:"message_id"`
+	From      string    `json:"from"`
+	Body      string    `json:"body"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// fakeStatus - Body callback status FakeWhatsApp
+type fakeStatus struct {
+	MessageID string `json:"message_id"`
+	Status    string `json:"status"`
+	Error     string `json:"error"`
+}
+
+func (p FakeWhatsApp) Name() string { return "fake" }
+
+func (p FakeWhatsApp) ParseInbound(webhookURL string, body []byte, header http.Header) (InboundMessage, error) {
+	expected, err := hex.DecodeString(header.Get("X-Webhook-Signature"))
+	if err != nil || !hmac.Equal(expected, p.sign(body)) {
+		return InboundMessage{}, ErrInvalidSignature
+	}
+	var payload fakeInbound
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return InboundMessage{}, err
+	}
+	if payload.Timestamp.IsZero() {
+		payload.Timestamp = time.Now()
+	}
+	return InboundMessage{
+		MessageID:  payload.MessageID,
+		From:       payload.From,
+		Body:       payload.Body,
+		ReceivedAt: payload.Timestamp,
+	}, nil
+}
+
+func (p FakeWhatsApp) ParseStatus(webhookURL string, body []byte, header http.Header) (DeliveryStatus, error) {
+	expected, err := hex.DecodeString(header.Get("X-Webhook-Signature"))
+	if err != nil || !hmac.Equal(expected, p.sign(body)) {
+		return DeliveryStatus{}, ErrInvalidSignature
+	}
+	var payload fakeStatus
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return DeliveryStatus{}, err
+	}
+	return DeliveryStatus{
+		MessageID: payload.MessageID,
+		Status:    NormalizeDeliveryStatus(payload.Status),
+		Error:     payload.Error,
+		At:        time.Now(),
+	}, nil
+}
+
+// SimulateStatus - Body dan header callback status bertanda tangan, seolah dikirim provider
+func (p FakeWhatsApp) SimulateStatus(messageID, status, errMsg string) ([]byte, http.Header) {
+	body, _ := json.Marshal(fakeStatus{MessageID: messageID, Status: status, Error: errMsg})
+	header := http.Header{}
+	header.Set("X-Webhook-Signature", hex.EncodeToString(p.sign(body)))
+	return body, header
+}
+
+// Simulate - Body dan header webhook bertanda tangan, seolah pesan dikirim penyewa
+func (p FakeWhatsApp) Simulate(from, message string) ([]byte, http.Header) {
+	now := time.Now()
+	body, _ := json.Marshal(fakeInbound{
+		MessageID: fmt.Sprintf("FAKE-%d", now.UnixNano()),
+		From:      from,
+		Body:      message,
+		Timestamp: now,
+	})
+	header := http.Header{}
+	header.Set("X-Webhook-Signature", hex.EncodeToString(p.sign(body)))
+	return body, header
+}
+
+func (p FakeWhatsApp) sign(body []byte) []byte {
+	mac := hmac.New(sha256.New, []byte(p.Secret))
+	mac.Write(body)
+	return mac.Sum(nil)
+}
+
+// TwilioWhatsApp - Webhook Twilio (form-urlencoded). X-Twilio-Signature adalah base64
+// HMAC-SHA1 atas URL webhook diikuti pasangan nama+nilai parameter yang diurutkan.
+// Pesan keluar dikirim lewat Messages API dengan AccountSID dan nomor pengirim From.
+type TwilioWhatsApp struct {
+	AuthToken      string
+	AccountSID     string
+	From           string // nomor WhatsApp pengirim, e.g. +14155238886
+	StatusCallback string // URL callback status pesan keluar, kosong = tanpa callback
+	APIURL         string // kosong = https://api.twilio.com
+}
+
+// NewTwilioWhatsApp - TwilioWhatsApp dari TWILIO_AUTH_TOKEN, TWILIO_ACCOUNT_SID dan
+// TWILIO_PHONE_NUMBER. StatusCallback diarahkan ke webhook status bila PUBLIC_BASE_URL diatur.
+func NewTwilioWhatsApp() (TwilioWhatsApp, error) {
+	token := os.Getenv("TWILIO_AUTH_TOKEN")
+	if token == "" {
+		return TwilioWhatsApp{}, errors.New("TWILIO_AUTH_TOKEN is not configured")
+	}
+	p := TwilioWhatsApp{
+		AuthToken:  token,
+		AccountSID: os.Getenv("TWILIO_ACCOUNT_SID"),
+		From:       strings.TrimPrefix(os.Getenv("TWILIO_PHONE_NUMBER"), "whatsapp:"),
+	}
+	if baseURL := strings.TrimRight(os.Getenv("PUBLIC_BASE_URL"), "/"); baseURL != "" {
+		p.StatusCallback = baseURL + "/webhook/whatsapp/twilio/status"
+	}
+	return p, nil
+}
+
+// Send - Kirim pesan WhatsApp (dengan lampiran bila mediaURL diisi) lewat Twilio Messages API.
+// Mengembalikan SID pesan, yang dipakai callback status untuk mencocokkan pengiriman.
+func (p TwilioWhatsApp) Send(to, body, mediaURL string) (string, error) {
+	if p.AccountSID == "" || p.From == "" {
+		return "", errors.New("TWILIO_ACCOUNT_SID and TWILIO_PHONE_NUMBER must be configured")
+	}
+	apiURL := p.APIURL
+	if apiURL == "" {
+		apiURL = "https://api.twilio.com"
+	}
+	form := url.Values{}
+	form.Set("From", "whatsapp:"+p.From)
+	form.Set("To", "whatsapp:"+strings.TrimPrefix(to, "whatsapp:"))
+	form.Set("Body", body)
+	if mediaURL != "" {
+		form.Set("MediaUrl", mediaURL)
+	}
+	if p.StatusCallback != "" {
+		form.Set("StatusCallback", p.StatusCallback)
+	}
+	req, err := http.NewRequest(http.MethodPost, apiURL+"/2010-04-01/Accounts/"+url.PathEscape(p.AccountSID)+"/Messages.json", strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.SetBasicAuth(p.AccountSID, p.AuthToken)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	resp, err := (&http.Client{Timeout: 30 * time.Second}).Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	var result struct {
+		SID     string `json:"sid"`
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	}
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	json.Unmarshal(respBody, &result)
+	if resp.StatusCode/100 != 2 {
+		if result.Message != "" {
+			return "", fmt.Errorf("twilio: %s: %d %s", resp.Status, result.Code, result.Message)
+		}
+		return "", fmt.Errorf("twilio: %s: %s", resp.Status, strings.TrimSpace(string(respBody)))
+	}
+	if result.SID == "" {
+		return "", errors.New("twilio: response has no message sid")
+	}
+	return result.SID, nil
+}
+
+func (p TwilioWhatsApp) Name() string { return "twilio" }
+
+func (p TwilioWhatsApp) ParseInbound(webhookURL string, body []byte, header http.Header) (InboundMessage, error) {
+	params, err := p.verify(webhookURL, body, header)
+	if err != nil {
+		return InboundMessage{}, err
+	}
+	return InboundMessage{
+		MessageID:  params.Get("MessageSid"),
+		From:       strings.TrimPrefix(params.Get("From"), "whatsapp:"),
+		Body:       params.Get("Body"),
+		ReceivedAt: time.Now(),
+	}, nil
+}
+
+// ParseStatus - StatusCallback Twilio: MessageSid, MessageStatus, ErrorCode
+func (p TwilioWhatsApp) ParseStatus(webhookURL string, body []byte, header http.Header) (DeliveryStatus, error) {
+	params, err := p.verify(webhookURL, body, header)
+	if err != nil {
+		return DeliveryStatus{}, err
+	}
+	status := DeliveryStatus{
+		MessageID: params.Get("MessageSid"),
+		Status:    NormalizeDeliveryStatus(params.Get("MessageStatus")),
+		At:        time.Now(),
+	}
+	if code := params.Get("ErrorCode"); code != "" {
+		status.Error = "Twilio error " + code
+		if msg := params.Get("ErrorMessage"); msg != "" {
+			status.Error += ": " + msg
+		}
+	}
+	return status, nil
+}
+
+// verify - Cek X-Twilio-Signature lalu kembalikan parameter form
+func (p TwilioWhatsApp) verify(webhookURL string, body []byte, header http.Header) (url.Values, error) {
+	params, err := url.ParseQuery(string(body))
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var data strings.Builder
+	data.WriteString(webhookURL)
+	for _, key := range keys {
+		for _, value := range params[key] {
+			data.WriteString(key)
+			data.WriteString(value)
+		}
+	}
+	mac := hmac.New(sha1.New, []byte(p.AuthToken))
+	mac.Write([]byte(data.String()))
+	expected, err := base64.StdEncoding.DecodeString(header.Get("X-Twilio-Signature"))
+	if err != nil || !hmac.Equal(expected, mac.Sum(nil)) {
+		return nil, ErrInvalidSignature
+	}
+	return params, nil
+}
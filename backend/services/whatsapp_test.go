@@ -0,0 +1,103 @@
+package services
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// Contoh tanda tangan di dokumentasi keamanan webhook Twilio
+func TestTwilioWhatsAppVerify(t *testing.T) {
+	p := TwilioWhatsApp{AuthToken: "12345"}
+	webhookURL := "https://mycompany.com/myapp.php?foo=1&bar=2"
+	body := []byte("CallSid=CA1234567890ABCDE&Caller=%2B12349013030&Digits=1234&From=%2B12349013030&To=%2B18005551212")
+
+	header := http.Header{}
+	header.Set("X-Twilio-Signature", "0/KCTR6DLpKmkAf8muzZqo1nDgQ=")
+	params, err := p.verify(webhookURL, body, header)
+	if err != nil {
+		t.Fatal("valid signature rejected:", err)
+	}
+	if params.Get("From") != "+12349013030" {
+		t.Errorf("From = %q", params.Get("From"))
+	}
+
+	tests := []struct {
+		name       string
+		webhookURL string
+		body       string
+		signature  string
+	}{
+		{"url berbeda", "https://mycompany.com/myapp.php?foo=1&bar=3", string(body), "0/KCTR6DLpKmkAf8muzZqo1nDgQ="},
+		{"body diubah", webhookURL, string(body) + "&Body=halo", "0/KCTR6DLpKmkAf8muzZqo1nDgQ="},
+		{"tanpa tanda tangan", webhookURL, string(body), ""},
+		{"bukan base64", webhookURL, string(body), "%%%"},
+	}
+	for _, tt := range tests {
+		header := http.Header{}
+		header.Set("X-Twilio-Signature", tt.signature)
+		if _, err := p.verify(tt.webhookURL, []byte(tt.body), header); !errors.Is(err, ErrInvalidSignature) {
+			t.Errorf("%s: err = %v, want ErrInvalidSignature", tt.name, err)
+		}
+	}
+}
+
+func TestTwilioWhatsAppSend(t *testing.T) {
+	var form url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, _ := r.BasicAuth()
+		if r.URL.Path != "/2010-04-01/Accounts/AC123/Messages.json" || user != "AC123" || pass != "token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"code": 20003, "message": "Authenticate"}`))
+			return
+		}
+		r.ParseForm()
+		form = r.PostForm
+		if form.Get("To") == "whatsapp:+620000" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"code": 21211, "message": "Invalid 'To' Phone Number"}`))
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"sid": "SM0123456789abcdef", "status": "queued"}`))
+	}))
+	defer server.Close()
+
+	p := TwilioWhatsApp{
+		AuthToken: "token", AccountSID: "AC123", From: "+14155238886",
+		StatusCallback: "https://kos.example.com/webhook/whatsapp/twilio/status", APIURL: server.URL,
+	}
+	sid, err := p.Send("+6281234567890", "Halo", "https://kos.example.com/dokumen/kwitansi/1?token=x")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sid != "SM0123456789abcdef" {
+		t.Errorf("sid = %q", sid)
+	}
+	want := map[string]string{
+		"From":           "whatsapp:+14155238886",
+		"To":             "whatsapp:+6281234567890",
+		"Body":           "Halo",
+		"MediaUrl":       "https://kos.example.com/dokumen/kwitansi/1?token=x",
+		"StatusCallback": "https://kos.example.com/webhook/whatsapp/twilio/status",
+	}
+	for k, v := range want {
+		if form.Get(k) != v {
+			t.Errorf("%s = %q, want %q", k, form.Get(k), v)
+		}
+	}
+
+	if _, err := p.Send("+620000", "Halo", ""); err == nil || !strings.Contains(err.Error(), "21211") {
+		t.Errorf("invalid number: err = %v", err)
+	}
+	p.AuthToken = "salah"
+	if _, err := p.Send("+6281234567890", "Halo", ""); err == nil {
+		t.Error("wrong credentials accepted")
+	}
+	if _, err := (TwilioWhatsApp{AuthToken: "token"}).Send("+6281234567890", "Halo", ""); err == nil {
+		t.Error("send without account sid accepted")
+	}
+}
@@ -14,9 +14,13 @@ interface Notification {
   bulan: string
   jumlah: number
   sent_at?: string
+  dilihat_pada?: string | null
   created_at: string
 }
 
+// Dilihat pengelola ditandai lewat dilihat_pada; status hanya mengikuti laporan pengiriman provider
+const isUnread = (n: Notification) => !n.dilihat_pada
+
 export default function NotificationCenter() {
   const [notifications, setNotifications] = useState<Notification[]>([])
   const [isOpen, setIsOpen] = useState(false)
@@ -35,7 +39,7 @@ export default function NotificationCenter() {
       const response = await axios.get('http://localhost:8080/notifikasi', {
         headers: { Authorization: `Bearer ${token}` }
       })
-      setNotifications(response.data || [])
+      setNotifications(response.data?.notifikasi || [])
     } catch (error) {
       console.error('Failed to fetch notifications', error)
     }
@@ -95,7 +99,7 @@ export default function NotificationCenter() {
     }
   }
 
-  const unreadCount = notifications.filter(n => n.status === 'pending').length
+  const unreadCount = notifications.filter(isUnread).length
 
   return (
     <div className="relative">
@@ -130,7 +134,7 @@ export default function NotificationCenter() {
               <button
                 onClick={async () => {
                   // Mark all as read
-                  for (const notif of notifications.filter(n => n.status === 'pending')) {
+                  for (const notif of notifications.filter(isUnread)) {
                     await handleMarkAsRead(notif.id)
                   }
                 }}
@@ -175,7 +179,7 @@ export default function NotificationCenter() {
                       </p>
                     </div>
                     <div className="flex gap-1 flex-shrink-0">
-                      {notif.status === 'pending' && (
+                      {isUnread(notif) && (
                         <button
                           onClick={() => handleMarkAsRead(notif.id)}
                           className="p-1 hover:bg-accent/20 rounded transition-colors"